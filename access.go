@@ -0,0 +1,133 @@
+package littlecache
+
+import "sync"
+
+// readCounts tallies a DefCache's hits per key for TrackAccessCounts. The
+// DefCache has no per-entry node to hold a count, and its Get runs under
+// the read lock, so the tally is a map with a mutex of its own. Every
+// method is a no-op on a nil *readCounts, which is what a cache without
+// TrackAccessCounts has.
+type readCounts struct {
+	mu     sync.Mutex
+	counts map[string]uint64
+}
+
+func newReadCounts(config Config) *readCounts {
+	if !config.TrackAccessCounts {
+		return nil
+	}
+	return &readCounts{counts: make(map[string]uint64)}
+}
+
+func (r *readCounts) add(key string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.counts[key]++
+	r.mu.Unlock()
+}
+
+func (r *readCounts) get(key string) uint64 {
+	if r == nil {
+		return 0
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.counts[key]
+}
+
+// forget drops key's count once the key has left the cache, so a later
+// Set starts it from zero.
+func (r *readCounts) forget(key string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	delete(r.counts, key)
+	r.mu.Unlock()
+}
+
+func (r *readCounts) reset() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.counts = make(map[string]uint64)
+	r.mu.Unlock()
+}
+
+// AccessCount returns how many Gets have hit key since it was stored, and
+// false if it isn't cached. The count is only kept with
+// Config.TrackAccessCounts; without it, a cached key reports zero.
+func (d *DefCache) AccessCount(key string) (uint64, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if _, exists := d.data[key]; !exists {
+		return 0, false
+	}
+	return d.reads.get(key), true
+}
+
+// AccessCount returns how many Gets have hit key since it was stored,
+// whether or not they promoted it. See DefCache.AccessCount.
+func (lru *LRUCache) AccessCount(key string) (uint64, bool) {
+	lru.mu.RLock()
+	defer lru.mu.RUnlock()
+
+	node, exists := lru.cache[key]
+	if !exists {
+		return 0, false
+	}
+	return node.reads.Load(), true
+}
+
+// AccessCount returns how many Gets have hit key since it was stored.
+// Unlike FrequencyOf, Sets don't count, MaxFrequency doesn't cap it and
+// bucket compaction leaves it alone.
+func (lfu *LFUCache) AccessCount(key string) (uint64, bool) {
+	lfu.mu.RLock()
+	defer lfu.mu.RUnlock()
+
+	node, exists := lfu.cache[key]
+	if !exists {
+		return 0, false
+	}
+	return node.reads, true
+}
+
+// accessCount returns cache's count for key, or false if cache keeps
+// none, as a RingCache, SecondChanceCache or WeightedRandomCache doesn't.
+func accessCount(cache LittleCache, key string) (uint64, bool) {
+	if counter, ok := cache.(interface {
+		AccessCount(key string) (uint64, bool)
+	}); ok {
+		return counter.AccessCount(key)
+	}
+	return 0, false
+}
+
+// AccessCount asks the key's shard.
+func (s *ShardedCache) AccessCount(key string) (uint64, bool) {
+	return accessCount(s.shardFor(key), key)
+}
+
+// AccessCount returns the underlying cache's count for key, which counts
+// the Gets that reached it: a Get of an expired key misses before it gets
+// there. It reports false for an expired key.
+func (t *TTLCache) AccessCount(key string) (uint64, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	entry, exists := t.ttlEntries[key]
+	if !exists || entry.expiredAt(t.now()) {
+		return 0, false
+	}
+	return accessCount(t.cache, key)
+}
+
+// AccessCount returns the wrapped cache's count.
+func (c *codecCache) AccessCount(key string) (uint64, bool) {
+	return accessCount(c.cache, key)
+}
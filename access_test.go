@@ -0,0 +1,174 @@
+package littlecache
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type countingCache interface {
+	LittleCache
+	AccessCount(key string) (uint64, bool)
+}
+
+func newCountingCaches(t *testing.T, config Config) map[string]countingCache {
+	t.Helper()
+
+	caches := make(map[string]countingCache)
+	for name, policy := range map[string]EvictionPolicy{"Def": NoEviction, "LRU": LRU, "LFU": LFU} {
+		config.EvictionPolicy = policy
+		cache, err := NewLittleCache(config)
+		if err != nil {
+			t.Fatalf("%s: Failed to create cache: %v", name, err)
+		}
+		caches[name] = cache.(countingCache)
+	}
+	config.EvictionPolicy = LRU
+	config.NoPromoteOnGet = true
+	noPromote, err := NewLRUCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+	caches["LRU/NoPromoteOnGet"] = noPromote
+	return caches
+}
+
+func TestAccessCount_CountsGetHits(t *testing.T) {
+	for name, cache := range newCountingCaches(t, Config{MaxSize: 10, TrackAccessCounts: true}) {
+		cache.Set("a", 1)
+		cache.Set("b", 2)
+		if count, ok := cache.AccessCount("a"); !ok || count != 0 {
+			t.Errorf("%s: Expected a new key to start at 0, got %d (ok=%v)", name, count, ok)
+		}
+
+		for i := 0; i < 3; i++ {
+			cache.Get("a")
+		}
+		cache.Get("b")
+		cache.Get("missing")
+		// Writes aren't reads, so they don't count, and an overwrite keeps the tally
+		cache.Set("a", 10)
+
+		for key, want := range map[string]uint64{"a": 3, "b": 1} {
+			if count, ok := cache.AccessCount(key); !ok || count != want {
+				t.Errorf("%s: Expected %s read %d times, got %d (ok=%v)", name, key, want, count, ok)
+			}
+		}
+		if _, ok := cache.AccessCount("missing"); ok {
+			t.Errorf("%s: Expected no count for a missing key", name)
+		}
+
+		// A key that leaves the cache comes back with a fresh count
+		cache.Delete("a")
+		if _, ok := cache.AccessCount("a"); ok {
+			t.Errorf("%s: Expected no count for a deleted key", name)
+		}
+		cache.Set("a", 1)
+		if count, _ := cache.AccessCount("a"); count != 0 {
+			t.Errorf("%s: Expected a re-added key to start at 0, got %d", name, count)
+		}
+
+		cache.Clear()
+		cache.Set("b", 2)
+		if count, _ := cache.AccessCount("b"); count != 0 {
+			t.Errorf("%s: Expected Clear to drop the counts, got %d", name, count)
+		}
+	}
+}
+
+func TestAccessCount_Disabled(t *testing.T) {
+	for name, cache := range newCountingCaches(t, Config{MaxSize: 10}) {
+		cache.Set("a", 1)
+		cache.Get("a")
+		if count, ok := cache.AccessCount("a"); !ok || count != 0 {
+			t.Errorf("%s: Expected 0 without TrackAccessCounts, got %d (ok=%v)", name, count, ok)
+		}
+	}
+}
+
+func TestAccessCount_IndependentOfLFUFrequency(t *testing.T) {
+	cache, err := NewLFUCache(Config{MaxSize: 10, EvictionPolicy: LFU, MaxFrequency: 2, TrackAccessCounts: true})
+	if err != nil {
+		t.Fatalf("Failed to create LFU cache: %v", err)
+	}
+
+	cache.Set("a", 1)
+	cache.Set("a", 2)
+	for i := 0; i < 5; i++ {
+		cache.Get("a")
+	}
+	if freq, _ := cache.FrequencyOf("a"); freq != 2 {
+		t.Errorf("Expected the frequency capped at 2, got %d", freq)
+	}
+	if count, _ := cache.AccessCount("a"); count != 5 {
+		t.Errorf("Expected 5 reads counted past the cap and without the Sets, got %d", count)
+	}
+}
+
+func TestAccessCount_TTLCache(t *testing.T) {
+	clock := newManualClock()
+	underlyingCache, err := NewLRUCache(Config{MaxSize: 10, TrackAccessCounts: true})
+	if err != nil {
+		t.Fatalf("Failed to create underlying cache: %v", err)
+	}
+	ttlCache, err := NewTTLCache(TTLConfig{
+		UnderlyingCache:    underlyingCache,
+		DefaultTTL:         time.Minute,
+		ExpirationStrategy: ExpireLazy,
+		Clock:              clock,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create TTL cache: %v", err)
+	}
+
+	ttlCache.Set("a", 1)
+	ttlCache.SetWithTTL("short", 2, time.Second)
+	ttlCache.Get("a")
+	ttlCache.Get("a")
+	ttlCache.Get("short")
+	if count, ok := ttlCache.AccessCount("a"); !ok || count != 2 {
+		t.Errorf("Expected a read twice, got %d (ok=%v)", count, ok)
+	}
+
+	// An expired key reports no count, even while it lingers underneath
+	clock.Advance(2 * time.Second)
+	if count, ok := underlyingCache.AccessCount("short"); !ok || count != 1 {
+		t.Errorf("Expected short to linger underneath with 1 read, got %d (ok=%v)", count, ok)
+	}
+	if _, ok := ttlCache.AccessCount("short"); ok {
+		t.Errorf("Expected no count for an expired key")
+	}
+
+	fromConfig, err := NewTTLCacheFromConfig(Config{MaxSize: 10, EvictionPolicy: LRU, TrackAccessCounts: true}, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create TTL cache: %v", err)
+	}
+	defer fromConfig.Stop()
+	fromConfig.Set("a", 1)
+	fromConfig.Get("a")
+	if count, ok := fromConfig.AccessCount("a"); !ok || count != 1 {
+		t.Errorf("Expected the config's TrackAccessCounts to reach the underlying cache, got %d (ok=%v)", count, ok)
+	}
+}
+
+func TestAccessCount_ConcurrentGets(t *testing.T) {
+	for name, cache := range newCountingCaches(t, Config{MaxSize: 10, TrackAccessCounts: true}) {
+		cache.Set("hot", 1)
+
+		var wg sync.WaitGroup
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 500; i++ {
+					cache.Get("hot")
+				}
+			}()
+		}
+		wg.Wait()
+
+		if count, _ := cache.AccessCount("hot"); count != 8*500 {
+			t.Errorf("%s: Expected every concurrent Get counted, got %d", name, count)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package littlecache
+
+import (
+	"sync"
+	"time"
+)
+
+// AutoTuneConfig makes an LRUCache or LFUCache resize itself toward a
+// target hit rate. Every Interval the tuner looks at the hits, misses and
+// evictions since its last look. Below the target while evicting, the
+// cache is too small, so it grows by a quarter. At or above the target
+// with no evictions and at most half its capacity in use, it shrinks by a
+// quarter, never below the entries it holds. The capacity always stays
+// within MinSize and MaxSize.
+type AutoTuneConfig struct {
+	MinSize int
+	MaxSize int
+	// TargetHitRate is the fraction of Gets that should hit, in (0, 1].
+	TargetHitRate float64
+	// Interval is how often the tuner runs. Zero disables auto-tuning.
+	Interval time.Duration
+}
+
+func (c AutoTuneConfig) validate() error {
+	if c.Interval == 0 {
+		return nil
+	}
+	if c.Interval < 0 || c.MinSize <= 0 || c.MaxSize < c.MinSize || c.MaxSize > MaxCapacity {
+		return ErrInvalidAutoTune
+	}
+	if c.TargetHitRate <= 0 || c.TargetHitRate > 1 {
+		return ErrInvalidAutoTune
+	}
+	return nil
+}
+
+// tunable is a cache the auto-tuner can resize.
+type tunable interface {
+	Stats() Stats
+	Resize(newSize int) error
+	capacity() int
+}
+
+// autoTuner periodically resizes a cache toward a target hit rate.
+type autoTuner struct {
+	config AutoTuneConfig
+	last   Stats
+
+	mu   sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+// startAutoTune starts tuning cache if config enables it. It returns nil
+// otherwise; a nil tuner's halt does nothing.
+func startAutoTune(config Config, cache tunable) *autoTuner {
+	if config.AutoTune.Interval == 0 {
+		return nil
+	}
+
+	a := &autoTuner{
+		config: config.AutoTune,
+		last:   cache.Stats(),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	ticker := clockOrDefault(config.Clock).NewTicker(a.config.Interval)
+	go func() {
+		defer close(a.done)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C():
+				a.tune(cache)
+			case <-a.stop:
+				return
+			}
+		}
+	}()
+	return a
+}
+
+// tune makes one resizing decision from the activity since the last one.
+func (a *autoTuner) tune(cache tunable) {
+	stats := cache.Stats()
+	hits := stats.Hits - a.last.Hits
+	misses := stats.Misses - a.last.Misses
+	evictions := stats.Evictions - a.last.Evictions
+	a.last = stats
+
+	capacity := cache.capacity()
+	next := capacity
+	if hits+misses > 0 {
+		hitRate := float64(hits) / float64(hits+misses)
+		switch {
+		case hitRate < a.config.TargetHitRate && evictions > 0:
+			next = capacity + max(capacity/4, 1)
+		case hitRate >= a.config.TargetHitRate && evictions == 0 && stats.Size <= capacity/2:
+			next = max(capacity-capacity/4, stats.Size)
+		}
+	}
+	next = min(max(next, a.config.MinSize), a.config.MaxSize)
+
+	if next != capacity {
+		cache.Resize(next)
+	}
+}
+
+// halt stops the tuner and waits for it to exit. It is safe to call more
+// than once, and on a nil tuner.
+func (a *autoTuner) halt() {
+	if a == nil {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.stop != nil {
+		close(a.stop)
+		<-a.done
+		a.stop = nil
+	}
+}
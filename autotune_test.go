@@ -0,0 +1,177 @@
+package littlecache
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// cycle reads keys in order twice, storing each miss, and returns the hit
+// rate of those reads. Cycling over more keys than an LRU holds misses
+// every time.
+func cycle(cache LittleCache, keys []string) float64 {
+	hits := 0
+	for pass := 0; pass < 2; pass++ {
+		for _, key := range keys {
+			if _, ok := cache.Get(key); ok {
+				hits++
+			} else {
+				cache.Set(key, key)
+			}
+		}
+	}
+	return float64(hits) / float64(2*len(keys))
+}
+
+// waitForCapacity polls until the tuner has moved the capacity off from.
+func waitForCapacity(t *testing.T, cache tunable, from int) int {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if capacity := cache.capacity(); capacity != from {
+			return capacity
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the tuner to resize from %d", from)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestAutoTune_GrowsToTargetHitRate(t *testing.T) {
+	clock := newManualClock()
+	config := DefaultConfig()
+	config.MaxSize = 4
+	config.Clock = clock
+	config.AutoTune = AutoTuneConfig{
+		MinSize:       4,
+		MaxSize:       64,
+		TargetHitRate: 0.9,
+		Interval:      time.Minute,
+	}
+
+	cache, err := NewLRUCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+	defer cache.Stop()
+
+	keys := make([]string, 32)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key%d", i)
+	}
+
+	var hitRate float64
+	for round := 0; round < 20; round++ {
+		hitRate = cycle(cache, keys)
+		if hitRate >= 0.9 {
+			break
+		}
+
+		before := cache.capacity()
+		clock.Advance(time.Minute)
+		after := waitForCapacity(t, cache, before)
+		if after <= before {
+			t.Fatalf("Expected capacity to grow from %d at hit rate %.2f, got %d", before, hitRate, after)
+		}
+	}
+
+	if hitRate < 0.9 {
+		t.Fatalf("Expected hit rate to reach 0.9, got %.2f", hitRate)
+	}
+	if capacity := cache.capacity(); capacity < len(keys) || capacity > 64 {
+		t.Errorf("Expected capacity between %d and 64, got %d", len(keys), capacity)
+	}
+}
+
+func TestAutoTune_ShrinksIdleCapacity(t *testing.T) {
+	clock := newManualClock()
+	config := DefaultConfig()
+	config.MaxSize = 64
+	config.Clock = clock
+	config.AutoTune = AutoTuneConfig{
+		MinSize:       8,
+		MaxSize:       64,
+		TargetHitRate: 0.5,
+		Interval:      time.Minute,
+	}
+
+	cache, err := NewLFUCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create LFU cache: %v", err)
+	}
+	defer cache.Stop()
+
+	keys := []string{"a", "b", "c", "d"}
+	for round := 0; cache.capacity() > 8; round++ {
+		if round == 20 {
+			t.Fatalf("Expected capacity to shrink to 8, got %d", cache.capacity())
+		}
+		cycle(cache, keys)
+
+		before := cache.capacity()
+		clock.Advance(time.Minute)
+		if after := waitForCapacity(t, cache, before); after >= before {
+			t.Fatalf("Expected capacity to shrink from %d, got %d", before, after)
+		}
+	}
+
+	for _, key := range keys {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("Expected %s to survive shrinking", key)
+		}
+	}
+}
+
+func TestAutoTune_Stop(t *testing.T) {
+	clock := newManualClock()
+	config := DefaultConfig()
+	config.MaxSize = 4
+	config.Clock = clock
+	config.AutoTune = AutoTuneConfig{
+		MinSize:       4,
+		MaxSize:       64,
+		TargetHitRate: 0.9,
+		Interval:      time.Minute,
+	}
+
+	cache, err := NewLRUCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+	cache.Stop()
+	cache.Stop()
+
+	cycle(cache, []string{"a", "b", "c", "d", "e", "f"})
+	clock.Advance(time.Minute)
+	time.Sleep(20 * time.Millisecond)
+
+	if capacity := cache.capacity(); capacity != 4 {
+		t.Errorf("Expected a stopped tuner to leave capacity at 4, got %d", capacity)
+	}
+}
+
+func TestAutoTune_InvalidConfig(t *testing.T) {
+	valid := AutoTuneConfig{MinSize: 4, MaxSize: 64, TargetHitRate: 0.9, Interval: time.Minute}
+
+	tests := map[string]func(c *Config){
+		"negative interval":   func(c *Config) { c.AutoTune.Interval = -time.Second },
+		"zero min size":       func(c *Config) { c.AutoTune.MinSize = 0 },
+		"max below min":       func(c *Config) { c.AutoTune.MaxSize = 2 },
+		"zero target":         func(c *Config) { c.AutoTune.TargetHitRate = 0 },
+		"target above one":    func(c *Config) { c.AutoTune.TargetHitRate = 1.5 },
+		"with unsynchronized": func(c *Config) { c.Unsynchronized = true },
+	}
+
+	for name, mutate := range tests {
+		config := DefaultConfig()
+		config.AutoTune = valid
+		mutate(&config)
+
+		if _, err := NewLRUCache(config); !errors.Is(err, ErrInvalidAutoTune) {
+			t.Errorf("%s: Expected ErrInvalidAutoTune, got %v", name, err)
+		}
+	}
+}
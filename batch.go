@@ -0,0 +1,142 @@
+package littlecache
+
+// Result is the outcome of looking up one key in a batch.
+type Result struct {
+	Value interface{}
+	Found bool
+}
+
+// GetManyOrdered looks up every key under one read lock. Results line up
+// with keys, so results[i] answers keys[i] and misses keep their place.
+// Each lookup counts as a Get for the hit and miss figures.
+func (d *DefCache) GetManyOrdered(keys []string) []Result {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	results := make([]Result, len(keys))
+	for i, key := range keys {
+		value, exists := d.data[key]
+		d.counters.record(exists)
+		results[i] = Result{Value: value, Found: exists}
+	}
+	return results
+}
+
+// GetManyOrdered looks up every key under one lock and promotes each hit,
+// as Get does, in input order. That needs the write lock unless
+// NoPromoteOnGet is set. Results line up with keys.
+func (lru *LRUCache) GetManyOrdered(keys []string) []Result {
+	if lru.config.NoPromoteOnGet {
+		lru.mu.RLock()
+		defer lru.mu.RUnlock()
+	} else {
+		lru.mu.Lock()
+		defer lru.mu.Unlock()
+	}
+
+	results := make([]Result, len(keys))
+	for i, key := range keys {
+		node, exists := lru.cache[key]
+		lru.counters.record(exists)
+		if !exists {
+			continue
+		}
+		if !lru.config.NoPromoteOnGet {
+			lru.moveToHead(node)
+		}
+		results[i] = Result{Value: node.value, Found: true}
+	}
+	return results
+}
+
+// GetManyOrdered looks up every key under one write lock, bumping the
+// frequency of each hit as Get does. Results line up with keys.
+func (lfu *LFUCache) GetManyOrdered(keys []string) []Result {
+	lfu.mu.Lock()
+	defer lfu.mu.Unlock()
+
+	results := make([]Result, len(keys))
+	for i, key := range keys {
+		node, exists := lfu.cache[key]
+		lfu.counters.record(exists)
+		if !exists {
+			continue
+		}
+		lfu.updateFreq(node)
+		results[i] = Result{Value: node.value, Found: true}
+	}
+	return results
+}
+
+// GetManyOrdered looks up every key under one read lock. Results line up
+// with keys.
+func (r *RingCache) GetManyOrdered(keys []string) []Result {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	results := make([]Result, len(keys))
+	for i, key := range keys {
+		pos, exists := r.index[key]
+		r.counters.record(exists)
+		if exists {
+			results[i] = Result{Value: r.slots[pos].value, Found: true}
+		}
+	}
+	return results
+}
+
+// GetManyOrdered looks up every key under one read lock, setting the
+// reference bit of each hit. Results line up with keys.
+func (s *SecondChanceCache) GetManyOrdered(keys []string) []Result {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	results := make([]Result, len(keys))
+	for i, key := range keys {
+		pos, exists := s.index[key]
+		s.counters.record(exists)
+		if !exists {
+			continue
+		}
+		entry := s.slots[pos]
+		entry.referenced.Store(true)
+		results[i] = Result{Value: entry.value, Found: true}
+	}
+	return results
+}
+
+// GetManyOrdered looks up every key, reporting expired ones as misses.
+// Results line up with keys. The live keys are fetched with the underlying
+// cache's GetManyOrdered when it has one, and with Get otherwise. Unlike
+// Get, a batch never deletes expired entries or renews TTLs; cleanup still
+// takes care of the former.
+func (t *TTLCache) GetManyOrdered(keys []string) []Result {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	now := t.now()
+	live := make([]string, 0, len(keys))
+	positions := make([]int, 0, len(keys))
+	for i, key := range keys {
+		entry, exists := t.ttlEntries[key]
+		if exists && (t.strategy == ExpireEager || !entry.expiredAt(now)) {
+			live = append(live, key)
+			positions = append(positions, i)
+		}
+	}
+
+	results := make([]Result, len(keys))
+	if batcher, ok := t.cache.(interface {
+		GetManyOrdered(keys []string) []Result
+	}); ok {
+		for i, result := range batcher.GetManyOrdered(live) {
+			results[positions[i]] = result
+		}
+		return results
+	}
+	for i, key := range live {
+		value, found := t.cache.Get(key)
+		results[positions[i]] = Result{Value: value, Found: found}
+	}
+	return results
+}
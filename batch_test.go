@@ -0,0 +1,114 @@
+package littlecache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetManyOrdered(t *testing.T) {
+	config := Config{MaxSize: 10, EvictionPolicy: LRU}
+	def, _ := NewDefCache(config)
+	lru, _ := NewLRUCache(config)
+	lfu, _ := NewLFUCache(config)
+	ring, _ := NewRingCache(config)
+	secondChance, _ := NewSecondChanceCache(config)
+	ttl, _ := NewTTLCacheFromConfig(config, time.Minute)
+	defer ttl.Stop()
+
+	caches := map[string]interface {
+		LittleCache
+		GetManyOrdered(keys []string) []Result
+	}{
+		"def":          def,
+		"lru":          lru,
+		"lfu":          lfu,
+		"ring":         ring,
+		"secondChance": secondChance,
+		"ttl":          ttl,
+	}
+
+	keys := []string{"miss1", "a", "miss2", "b", "a", "miss3", "c"}
+	want := []Result{
+		{},
+		{Value: 1, Found: true},
+		{},
+		{Value: 2, Found: true},
+		{Value: 1, Found: true},
+		{},
+		{Value: nil, Found: true},
+	}
+
+	for name, cache := range caches {
+		cache.Set("a", 1)
+		cache.Set("b", 2)
+		cache.Set("c", nil)
+
+		results := cache.GetManyOrdered(keys)
+		if len(results) != len(keys) {
+			t.Fatalf("%s: expected %d results, got %d", name, len(keys), len(results))
+		}
+		for i, result := range results {
+			if result != want[i] {
+				t.Errorf("%s: expected %+v for %s, got %+v", name, want[i], keys[i], result)
+			}
+		}
+
+		if results := cache.GetManyOrdered(nil); len(results) != 0 {
+			t.Errorf("%s: expected no results for no keys, got %d", name, len(results))
+		}
+	}
+}
+
+func TestGetManyOrdered_TTLExpiry(t *testing.T) {
+	clock := newManualClock()
+	underlyingCache, err := NewLFUCache(Config{MaxSize: 10})
+	if err != nil {
+		t.Fatalf("Failed to create underlying cache: %v", err)
+	}
+	ttlCache, err := NewTTLCache(TTLConfig{
+		UnderlyingCache:    underlyingCache,
+		DefaultTTL:         time.Minute,
+		ExpirationStrategy: ExpireLazy,
+		Clock:              clock,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create TTL cache: %v", err)
+	}
+
+	ttlCache.Set("short", 1)
+	ttlCache.SetWithTTL("long", 2, time.Hour)
+	clock.Advance(2 * time.Minute)
+
+	results := ttlCache.GetManyOrdered([]string{"long", "short", "long"})
+	if !results[0].Found || results[1].Found || !results[2].Found {
+		t.Errorf("Expected hit, miss, hit, got %+v", results)
+	}
+
+	// Each live hit went through the underlying batch and bumped the frequency
+	if freq, _ := underlyingCache.FrequencyOf("long"); freq != 3 {
+		t.Errorf("Expected long's frequency to be 3, got %d", freq)
+	}
+}
+
+func TestLRUCache_GetManyOrderedPromotes(t *testing.T) {
+	cache, err := NewLRUCache(Config{MaxSize: 3})
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.Set("c", 3)
+	cache.GetManyOrdered([]string{"a", "missing"})
+
+	cache.Set("d", 4)
+	if _, ok := cache.Peek("a"); !ok {
+		t.Errorf("Expected a to be promoted by the batch")
+	}
+	if _, ok := cache.Peek("b"); ok {
+		t.Errorf("Expected b to be evicted")
+	}
+	if stats := cache.Stats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("Expected 1 hit and 1 miss, got %d and %d", stats.Hits, stats.Misses)
+	}
+}
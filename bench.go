@@ -0,0 +1,45 @@
+package littlecache
+
+import (
+	"math/rand/v2"
+	"strconv"
+)
+
+// BenchmarkConfig describes a workload: a Zipfian key stream over Keys
+// distinct keys against a cache holding CacheSize of them. Skew is the
+// Zipf exponent s > 1; the higher it is, the more the stream repeats its
+// hottest keys. Length is the number of keys in the stream, and Seed makes
+// it reproducible.
+type BenchmarkConfig struct {
+	Keys      int
+	CacheSize int
+	Skew      float64
+	Length    int
+	Seed      uint64
+}
+
+// DefaultBenchmarkConfig is the workload the package's own benchmarks run.
+var DefaultBenchmarkConfig = BenchmarkConfig{
+	Keys:      1 << 16,
+	CacheSize: 1 << 12,
+	Skew:      1.1,
+	Length:    1 << 16,
+	Seed:      1,
+}
+
+// ZipfKeys returns a key stream drawn from a Zipf distribution, so key0
+// is the most frequent, key1 the next and so on.
+func ZipfKeys(config BenchmarkConfig) []string {
+	rng := rand.New(rand.NewPCG(config.Seed, config.Seed))
+	zipf := rand.NewZipf(rng, config.Skew, 1, uint64(config.Keys-1))
+
+	names := make([]string, config.Keys)
+	for i := range names {
+		names[i] = "key" + strconv.Itoa(i)
+	}
+	stream := make([]string, config.Length)
+	for i := range stream {
+		stream[i] = names[zipf.Uint64()]
+	}
+	return stream
+}
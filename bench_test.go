@@ -0,0 +1,136 @@
+package littlecache
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+// benchmarkPolicies are the caches every workload runs against.
+var benchmarkPolicies = []struct {
+	name   string
+	policy EvictionPolicy
+}{
+	{"Def", NoEviction},
+	{"LRU", LRU},
+	{"LFU", LFU},
+}
+
+// replay reads every key in stream, storing it on a miss as a read-through
+// cache would, and returns the hit rate.
+func replay(cache LittleCache, stream []string) float64 {
+	hits := 0
+	for i, key := range stream {
+		if _, ok := cache.Get(key); ok {
+			hits++
+		} else {
+			cache.Set(key, i)
+		}
+	}
+	return float64(hits) / float64(len(stream))
+}
+
+func TestBenchmarkHarness_HitRateOrdering(t *testing.T) {
+	if !testing.Short() {
+		t.Skip("hit-rate ordering is checked in -short mode")
+	}
+
+	config := BenchmarkConfig{Keys: 10000, CacheSize: 100, Skew: 1.1, Length: 50000, Seed: 1}
+	stream := ZipfKeys(config)
+
+	rates := make(map[string]float64)
+	for _, p := range benchmarkPolicies {
+		cache, err := NewLittleCache(Config{MaxSize: config.CacheSize, EvictionPolicy: p.policy})
+		if err != nil {
+			t.Fatalf("%s: Failed to create cache: %v", p.name, err)
+		}
+		rates[p.name] = replay(cache, stream)
+	}
+
+	// LFU keeps the hottest keys however they interleave; LRU loses them to
+	// runs of cold keys. NoEviction keeps whatever came first, which under
+	// Zipf is mostly, but not only, hot keys.
+	if rates["LFU"] <= rates["LRU"] {
+		t.Errorf("Expected LFU to beat LRU on a skewed stream, got LFU %.3f, LRU %.3f", rates["LFU"], rates["LRU"])
+	}
+	if rates["LRU"] <= 0 {
+		t.Errorf("Expected LRU to hit at all, got %.3f", rates["LRU"])
+	}
+}
+
+func TestZipfKeys_Skewed(t *testing.T) {
+	stream := ZipfKeys(BenchmarkConfig{Keys: 1000, Skew: 1.5, Length: 10000, Seed: 1})
+
+	counts := make(map[string]int)
+	for _, key := range stream {
+		counts[key]++
+	}
+	if counts["key0"] <= counts["key1"] || counts["key1"] <= counts["key10"] {
+		t.Errorf("Expected frequency to fall with rank, got key0=%d key1=%d key10=%d", counts["key0"], counts["key1"], counts["key10"])
+	}
+	if len(counts) < 50 {
+		t.Errorf("Expected a long tail of keys, got only %d distinct", len(counts))
+	}
+}
+
+// benchmarkWorkload runs the default stream against each policy from
+// parallel goroutines, writing writePercent of the keys and reading the
+// rest.
+func benchmarkWorkload(b *testing.B, writePercent int) {
+	config := DefaultBenchmarkConfig
+	stream := ZipfKeys(config)
+
+	for _, p := range benchmarkPolicies {
+		b.Run(p.name, func(b *testing.B) {
+			cache, _ := NewLittleCache(Config{MaxSize: config.CacheSize, EvictionPolicy: p.policy})
+			for i, key := range stream[:config.CacheSize] {
+				cache.Set(key, i)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := rand.IntN(len(stream))
+				for pb.Next() {
+					key := stream[i%len(stream)]
+					if i%100 < writePercent {
+						cache.Set(key, i)
+					} else {
+						cache.Get(key)
+					}
+					i++
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkZipfSetHeavy(b *testing.B) {
+	benchmarkWorkload(b, 90)
+}
+
+func BenchmarkZipfGetHeavy(b *testing.B) {
+	benchmarkWorkload(b, 1)
+}
+
+func BenchmarkZipfMixed(b *testing.B) {
+	benchmarkWorkload(b, 25)
+}
+
+// BenchmarkZipfReadThrough replays the stream as a read-through cache,
+// reporting the hit rate alongside the timing.
+func BenchmarkZipfReadThrough(b *testing.B) {
+	config := DefaultBenchmarkConfig
+	stream := ZipfKeys(config)
+
+	for _, p := range benchmarkPolicies {
+		b.Run(p.name, func(b *testing.B) {
+			var rate float64
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				cache, _ := NewLittleCache(Config{MaxSize: config.CacheSize, EvictionPolicy: p.policy})
+				rate = replay(cache, stream)
+			}
+			b.ReportMetric(rate, "hit-rate")
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package littlecache
+
+import (
+	"sync"
+	"time"
+)
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	// breakerHalfOpen lets one trial load through after the cooldown.
+	breakerHalfOpen
+)
+
+// circuitBreaker stops a LoadingCache from calling a failing backend.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	clock     Clock
+	threshold int
+	window    time.Duration
+	cooldown  time.Duration
+
+	state        breakerState
+	failures     int
+	firstFailure time.Time
+	openedAt     time.Time
+}
+
+func newCircuitBreaker(config Config) *circuitBreaker {
+	return &circuitBreaker{
+		clock:     clockOrDefault(config.Clock),
+		threshold: config.BreakerThreshold,
+		window:    config.BreakerWindow,
+		cooldown:  config.BreakerCooldown,
+	}
+}
+
+// allow reports whether a load may run. Once the cooldown has passed, the
+// first caller becomes the half-open trial and the rest keep failing fast
+// until it reports back.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if b.clock.Now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = breakerHalfOpen
+		return true
+	case breakerHalfOpen:
+		return false
+	default:
+		return true
+	}
+}
+
+// record feeds the outcome of an allowed load back into the breaker.
+func (b *circuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.clock.Now()
+	if err == nil {
+		b.state = breakerClosed
+		b.failures = 0
+		return
+	}
+
+	if b.state == breakerHalfOpen {
+		b.state = breakerOpen
+		b.openedAt = now
+		return
+	}
+
+	if b.failures == 0 || (b.window > 0 && now.Sub(b.firstFailure) > b.window) {
+		b.failures = 0
+		b.firstFailure = now
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.state = breakerOpen
+		b.openedAt = now
+		b.failures = 0
+	}
+}
@@ -0,0 +1,118 @@
+package littlecache
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLoadingCache_CircuitBreaker(t *testing.T) {
+	clock := newManualClock()
+	config := Config{
+		MaxSize:          10,
+		EvictionPolicy:   LRU,
+		Clock:            clock,
+		BreakerThreshold: 3,
+		BreakerWindow:    time.Minute,
+		BreakerCooldown:  30 * time.Second,
+	}
+	cache, err := NewLoadingCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create loading cache: %v", err)
+	}
+
+	errBackend := errors.New("backend down")
+	calls := 0
+	failing := func() (interface{}, error) {
+		calls++
+		return nil, errBackend
+	}
+	healthy := func() (interface{}, error) {
+		calls++
+		return "fresh", nil
+	}
+
+	// A value cached before the outage keeps being served
+	if _, err := cache.GetOrCompute("cached", healthy); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := cache.GetOrCompute("key", failing); err != errBackend {
+			t.Fatalf("Expected backend error, got %v", err)
+		}
+	}
+
+	// The circuit is open: misses fail fast without calling compute
+	calls = 0
+	for i := 0; i < 5; i++ {
+		if _, err := cache.GetOrCompute("key", failing); !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("Expected ErrCircuitOpen, got %v", err)
+		}
+	}
+	if calls != 0 {
+		t.Errorf("Expected compute not to run while open, ran %d times", calls)
+	}
+	if value, err := cache.GetOrCompute("cached", failing); err != nil || value != "fresh" {
+		t.Errorf("Expected cached value while open, got %v (err=%v)", value, err)
+	}
+
+	// After the cooldown a failed trial reopens the circuit
+	clock.Advance(30 * time.Second)
+	if _, err := cache.GetOrCompute("key", failing); err != errBackend {
+		t.Errorf("Expected the half-open trial to reach the backend, got %v", err)
+	}
+	if _, err := cache.GetOrCompute("key", failing); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected a failed trial to reopen the circuit, got %v", err)
+	}
+
+	// A successful trial closes it again
+	clock.Advance(30 * time.Second)
+	if value, err := cache.GetOrCompute("key", healthy); err != nil || value != "fresh" {
+		t.Errorf("Expected the trial to succeed, got %v (err=%v)", value, err)
+	}
+	calls = 0
+	if _, err := cache.GetOrCompute("other", failing); err != errBackend || calls != 1 {
+		t.Errorf("Expected a closed circuit to call compute, got %v after %d calls", err, calls)
+	}
+}
+
+func TestLoadingCache_CircuitBreakerWindow(t *testing.T) {
+	clock := newManualClock()
+	config := Config{
+		MaxSize:          10,
+		EvictionPolicy:   LRU,
+		Clock:            clock,
+		BreakerThreshold: 2,
+		BreakerWindow:    time.Second,
+		BreakerCooldown:  time.Minute,
+	}
+	cache, err := NewLoadingCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create loading cache: %v", err)
+	}
+
+	errBackend := errors.New("backend down")
+	failing := func() (interface{}, error) { return nil, errBackend }
+
+	// Failures further apart than the window don't add up
+	for i := 0; i < 3; i++ {
+		if _, err := cache.GetOrCompute("key", failing); err != errBackend {
+			t.Fatalf("Expected backend error on attempt %d, got %v", i, err)
+		}
+		clock.Advance(2 * time.Second)
+	}
+
+	cache.GetOrCompute("key", failing)
+	cache.GetOrCompute("key", failing)
+	if _, err := cache.GetOrCompute("key", failing); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected two quick failures to open the circuit, got %v", err)
+	}
+}
+
+func TestLoadingCache_InvalidBreaker(t *testing.T) {
+	_, err := NewLoadingCache(Config{MaxSize: 10, BreakerThreshold: -1})
+	if !errors.Is(err, ErrInvalidBreaker) {
+		t.Errorf("Expected ErrInvalidBreaker, got %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package littlecache
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// callbackGuard runs user callbacks with Config.CallbackTimeout, so a hung
+// callback can't hold the cache lock indefinitely, and recovers their
+// panics, so a failing callback can't unwind through an update half done
+// or crash a background goroutine. The shards of a ShardedCache, and a
+// LoadingCache and its cache, share one.
+type callbackGuard struct {
+	timeout time.Duration
+	onPanic func(recovered interface{})
+	slow    atomic.Int64
+	panics  atomic.Int64
+}
+
+// newCallbackGuard returns a guard for timeout, which zero turns off, and
+// the OnCallbackPanic hook, which may be nil.
+func newCallbackGuard(timeout time.Duration, onPanic func(recovered interface{})) *callbackGuard {
+	return &callbackGuard{timeout: timeout, onPanic: onPanic}
+}
+
+// run calls fn and waits for it at most the timeout. A callback still
+// running then is counted as slow and left to finish on its own goroutine,
+// so fn must not touch anything the caller goes on to change. Without a
+// timeout, fn runs inline.
+func (g *callbackGuard) run(fn func()) {
+	if g == nil || g.timeout == 0 {
+		g.call(fn)
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		g.call(fn)
+	}()
+	g.wait(done)
+}
+
+// load calls compute like run, failing with ErrCallbackTimeout if it is
+// slow and with ErrCallbackPanic if it panics. A late result is dropped.
+func (g *callbackGuard) load(compute func() (interface{}, error)) (interface{}, error) {
+	var value interface{}
+	var err error
+	call := func() {
+		if recovered := g.call(func() { value, err = compute() }); recovered != nil {
+			value, err = nil, fmt.Errorf("%w: %v", ErrCallbackPanic, recovered)
+		}
+	}
+	if g == nil || g.timeout == 0 {
+		call()
+		return value, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		call()
+	}()
+	if !g.wait(done) {
+		return nil, ErrCallbackTimeout
+	}
+	return value, err
+}
+
+// call runs fn, returning what it panicked with, if anything. The panic is
+// counted and handed to OnCallbackPanic instead of propagating. A nil
+// guard still recovers, but keeps no count.
+func (g *callbackGuard) call(fn func()) (recovered interface{}) {
+	defer func() {
+		// Since Go 1.21, panic(nil) recovers a *runtime.PanicNilError, so
+		// a nil result always means fn returned.
+		if recovered = recover(); recovered != nil && g != nil {
+			g.panics.Add(1)
+			if g.onPanic != nil {
+				g.onPanic(recovered)
+			}
+		}
+	}()
+	fn()
+	return nil
+}
+
+// wait reports whether done closed within the timeout, counting a slow
+// callback if not. The timeout runs on the system clock, since Config.Clock
+// may be a fake that never advances on its own.
+func (g *callbackGuard) wait(done <-chan struct{}) bool {
+	timer := time.NewTimer(g.timeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		g.slow.Add(1)
+		return false
+	}
+}
+
+// slowCount returns how many callbacks have outlived the timeout.
+func (g *callbackGuard) slowCount() int64 {
+	if g == nil {
+		return 0
+	}
+	return g.slow.Load()
+}
+
+// panicCount returns how many callbacks have panicked.
+func (g *callbackGuard) panicCount() int64 {
+	if g == nil {
+		return 0
+	}
+	return g.panics.Load()
+}
+
+// reset zeroes the slow callback and panic counts.
+func (g *callbackGuard) reset() {
+	if g != nil {
+		g.slow.Store(0)
+		g.panics.Store(0)
+	}
+}
@@ -0,0 +1,280 @@
+package littlecache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// within fails the test if fn takes longer than limit.
+func within(t *testing.T, limit time.Duration, what string, fn func()) {
+	t.Helper()
+
+	start := time.Now()
+	fn()
+	if elapsed := time.Since(start); elapsed > limit {
+		t.Errorf("Expected %s to return within %v, took %v", what, limit, elapsed)
+	}
+}
+
+func TestCallbackTimeout_SlowOnEvict(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+	cache, err := NewLRUCache(Config{
+		MaxSize:         2,
+		CallbackTimeout: 20 * time.Millisecond,
+		OnEvict: func(key string, value interface{}, reason EvictionReason) {
+			calls.Add(1)
+			<-release
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	within(t, time.Second, "an evicting Set", func() { cache.Set("c", 3) })
+
+	// The hung callback doesn't hold the lock
+	within(t, time.Second, "Get", func() {
+		if value, ok := cache.Get("c"); !ok || value != 3 {
+			t.Errorf("Expected 3, got %v (ok=%v)", value, ok)
+		}
+	})
+	within(t, time.Second, "Clear", cache.Clear)
+
+	if slow := cache.Stats().SlowCallbacks; slow != 3 {
+		t.Errorf("Expected 3 slow callbacks (1 eviction, 2 clears), got %d", slow)
+	}
+	close(release)
+	if n := calls.Load(); n != 3 {
+		t.Errorf("Expected each callback to be called once, got %d calls", n)
+	}
+}
+
+func TestCallbackTimeout_FastCallbacksAreNotSlow(t *testing.T) {
+	var evicted []string
+	cache, err := NewLRUCache(Config{
+		MaxSize:         1,
+		CallbackTimeout: time.Second,
+		OnEvict: func(key string, value interface{}, reason EvictionReason) {
+			evicted = append(evicted, key)
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	if len(evicted) != 1 || evicted[0] != "a" {
+		t.Errorf("Expected a prompt callback to have run before Set returned, got %v", evicted)
+	}
+	if slow := cache.Stats().SlowCallbacks; slow != 0 {
+		t.Errorf("Expected no slow callbacks, got %d", slow)
+	}
+}
+
+func TestCallbackTimeout_TTLOnEvict(t *testing.T) {
+	underlying, err := NewLRUCache(Config{MaxSize: 10})
+	if err != nil {
+		t.Fatalf("Failed to create underlying cache: %v", err)
+	}
+	release := make(chan struct{})
+	defer close(release)
+	ttlCache, err := NewTTLCache(TTLConfig{
+		UnderlyingCache:    underlying,
+		ExpirationStrategy: ExpireLazy,
+		CallbackTimeout:    20 * time.Millisecond,
+		OnEvict: func(key string, value interface{}, reason EvictionReason) {
+			<-release
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create TTL cache: %v", err)
+	}
+
+	ttlCache.Set("a", 1)
+	within(t, time.Second, "Delete", func() { ttlCache.Delete("a") })
+	within(t, time.Second, "Set", func() { ttlCache.Set("b", 2) })
+}
+
+func TestCallbackTimeout_SlowCompute(t *testing.T) {
+	cache, err := NewLoadingCache(Config{
+		MaxSize:            10,
+		EvictionPolicy:     LRU,
+		CallbackTimeout:    20 * time.Millisecond,
+		MaxConcurrentLoads: 1,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create loading cache: %v", err)
+	}
+
+	release := make(chan struct{})
+	finished := make(chan struct{})
+	within(t, time.Second, "a slow GetOrCompute", func() {
+		_, err := cache.GetOrCompute("key", func() (interface{}, error) {
+			defer close(finished)
+			<-release
+			return "late", nil
+		})
+		if !errors.Is(err, ErrCallbackTimeout) {
+			t.Errorf("Expected ErrCallbackTimeout, got %v", err)
+		}
+	})
+
+	// The overrunning compute still holds the only load slot
+	second := make(chan error, 1)
+	go func() {
+		_, err := cache.GetOrCompute("other", func() (interface{}, error) { return "fresh", nil })
+		second <- err
+	}()
+	select {
+	case err := <-second:
+		t.Fatalf("Expected the second load to wait for the slot, got %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	<-finished
+	if err := <-second; err != nil {
+		t.Errorf("Expected the second load to succeed once the slot freed, got %v", err)
+	}
+	if _, ok := cache.Get("key"); ok {
+		t.Errorf("Expected the late result to be dropped")
+	}
+	if slow := cache.LittleCache.(*LRUCache).Stats().SlowCallbacks; slow != 1 {
+		t.Errorf("Expected 1 slow callback, got %d", slow)
+	}
+}
+
+func TestCallbackTimeout_Invalid(t *testing.T) {
+	if _, err := NewLRUCache(Config{MaxSize: 10, CallbackTimeout: -time.Second}); !errors.Is(err, ErrInvalidCallbackTimeout) {
+		t.Errorf("Expected ErrInvalidCallbackTimeout, got %v", err)
+	}
+
+	underlying, _ := NewLRUCache(Config{MaxSize: 10})
+	if _, err := NewTTLCache(TTLConfig{UnderlyingCache: underlying, CallbackTimeout: -time.Second}); !errors.Is(err, ErrInvalidCallbackTimeout) {
+		t.Errorf("Expected ErrInvalidCallbackTimeout from NewTTLCache, got %v", err)
+	}
+}
+
+func TestCallbackPanic_OnEvictIsRecovered(t *testing.T) {
+	for _, timeout := range []time.Duration{0, time.Second} {
+		var recovered []interface{}
+		var mu sync.Mutex
+		cache, err := NewLRUCache(Config{
+			MaxSize:         2,
+			CallbackTimeout: timeout,
+			OnEvict: func(key string, value interface{}, reason EvictionReason) {
+				panic("evicting " + key)
+			},
+			OnCallbackPanic: func(r interface{}) {
+				mu.Lock()
+				recovered = append(recovered, r)
+				mu.Unlock()
+			},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create LRU cache: %v", err)
+		}
+
+		cache.Set("a", 1)
+		cache.Set("b", 2)
+		cache.Set("c", 3) // evicts a
+		cache.Delete("b")
+
+		// The panics neither escaped nor left the cache half updated
+		if err := cache.checkInvariants(); err != nil {
+			t.Errorf("timeout %v: Invariant violated: %v", timeout, err)
+		}
+		if cache.Size() != 1 {
+			t.Errorf("timeout %v: Expected only c left, got size %d", timeout, cache.Size())
+		}
+		if value, ok := cache.Get("c"); !ok || value != 3 {
+			t.Errorf("timeout %v: Expected c=3, got %v (ok=%v)", timeout, value, ok)
+		}
+		cache.Set("d", 4)
+		if cache.Size() != 2 {
+			t.Errorf("timeout %v: Expected the cache to keep working, got size %d", timeout, cache.Size())
+		}
+
+		if panics := cache.Stats().CallbackPanics; panics != 2 {
+			t.Errorf("timeout %v: Expected 2 panics counted, got %d", timeout, panics)
+		}
+		mu.Lock()
+		if len(recovered) != 2 || recovered[0] != "evicting a" || recovered[1] != "evicting b" {
+			t.Errorf("timeout %v: Expected the hook to see both panics, got %v", timeout, recovered)
+		}
+		mu.Unlock()
+
+		cache.Reset()
+		if panics := cache.Stats().CallbackPanics; panics != 0 {
+			t.Errorf("timeout %v: Expected Reset to zero the count, got %d", timeout, panics)
+		}
+	}
+}
+
+func TestCallbackPanic_TTLCleanupSurvives(t *testing.T) {
+	underlying, err := NewLRUCache(Config{MaxSize: 10})
+	if err != nil {
+		t.Fatalf("Failed to create underlying cache: %v", err)
+	}
+	var panics atomic.Int32
+	ttlCache, err := NewTTLCache(TTLConfig{
+		UnderlyingCache: underlying,
+		DefaultTTL:      5 * time.Millisecond,
+		CleanupInterval: 5 * time.Millisecond,
+		OnEvict: func(key string, value interface{}, reason EvictionReason) {
+			panic(errors.New("flush failed"))
+		},
+		OnCallbackPanic: func(recovered interface{}) { panics.Add(1) },
+	})
+	if err != nil {
+		t.Fatalf("Failed to create TTL cache: %v", err)
+	}
+	defer ttlCache.Stop()
+
+	// Each round needs a live cleanup goroutine to expire the entry
+	for round := 1; round <= 2; round++ {
+		ttlCache.Set("key", round)
+		deadline := time.Now().Add(time.Second)
+		for underlying.Size() != 0 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if underlying.Size() != 0 {
+			t.Fatalf("Round %d: Expected cleanup to remove the expired entry", round)
+		}
+		if n := panics.Load(); n != int32(round) {
+			t.Errorf("Round %d: Expected %d panics recovered, got %d", round, round, n)
+		}
+	}
+}
+
+func TestCallbackPanic_ComputeFailsTheLoad(t *testing.T) {
+	cache, err := NewLoadingCache(Config{MaxSize: 10, EvictionPolicy: LRU, MaxConcurrentLoads: 1})
+	if err != nil {
+		t.Fatalf("Failed to create loading cache: %v", err)
+	}
+
+	_, err = cache.GetOrCompute("key", func() (interface{}, error) {
+		panic("backend down")
+	})
+	if !errors.Is(err, ErrCallbackPanic) {
+		t.Errorf("Expected ErrCallbackPanic, got %v", err)
+	}
+
+	// The load slot was released and nothing was cached
+	within(t, time.Second, "the next GetOrCompute", func() {
+		value, err := cache.GetOrCompute("key", func() (interface{}, error) { return "fresh", nil })
+		if err != nil || value != "fresh" {
+			t.Errorf("Expected fresh, got %v (err=%v)", value, err)
+		}
+	})
+	if panics := cache.LittleCache.(*LRUCache).Stats().CallbackPanics; panics != 1 {
+		t.Errorf("Expected 1 panic counted, got %d", panics)
+	}
+}
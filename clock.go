@@ -0,0 +1,70 @@
+package littlecache
+
+import (
+	"time"
+)
+
+// Clock is the time source for background work such as auto-saving. Tests
+// can supply their own to drive time by hand.
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers ticks on C, like time.Ticker.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{ticker: time.NewTicker(d)}
+}
+
+type realTicker struct {
+	ticker *time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.ticker.C
+}
+
+func (t realTicker) Stop() {
+	t.ticker.Stop()
+}
+
+// clockOrDefault returns clock, falling back to the system clock.
+func clockOrDefault(clock Clock) Clock {
+	if clock == nil {
+		return realClock{}
+	}
+	return clock
+}
+
+// monotonicEpoch anchors monotonic readings taken from a plain Clock. The
+// system clock's Now carries a monotonic reading, so differences from it
+// are immune to wall clock changes.
+var monotonicEpoch = time.Now()
+
+// instant is a reading of the wall clock, for reporting, and of a monotonic
+// clock, for deciding when something is due.
+type instant struct {
+	wall time.Time
+	mono time.Duration
+}
+
+// now reads clock. A clock with a Monotonic method supplies the monotonic
+// reading itself; otherwise it is Now's distance from monotonicEpoch.
+func now(clock Clock) instant {
+	wall := clock.Now()
+	if m, ok := clock.(interface{ Monotonic() time.Duration }); ok {
+		return instant{wall: wall, mono: m.Monotonic()}
+	}
+	return instant{wall: wall, mono: wall.Sub(monotonicEpoch)}
+}
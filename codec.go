@@ -0,0 +1,308 @@
+package littlecache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrCiphertextTooShort is returned by AESGCMCipher.Open for truncated input.
+var ErrCiphertextTooShort = errors.New("ciphertext too short")
+
+// Compressor compresses and decompresses cached []byte values.
+type Compressor interface {
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+// GzipCompressor is a Compressor using compress/gzip at the default level.
+type GzipCompressor struct{}
+
+func (GzipCompressor) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GzipCompressor) Decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// Cipher encrypts cached []byte values so they are not kept in memory as
+// plaintext.
+//
+// This only protects value bytes from someone reading a heap dump or core
+// file without also recovering the key. The key lives in the same process,
+// cache keys stay in plaintext for lookup, non-[]byte values are stored
+// unencrypted, and Get hands plaintext back to the caller.
+type Cipher interface {
+	Seal(plaintext []byte) ([]byte, error)
+	Open(ciphertext []byte) ([]byte, error)
+}
+
+// AESGCMCipher is a Cipher using AES-GCM with a random nonce per value.
+type AESGCMCipher struct {
+	aead cipher.AEAD
+}
+
+// NewAESGCMCipher creates an AES-GCM cipher. The key must be 16, 24 or 32
+// bytes long to select AES-128, AES-192 or AES-256.
+func NewAESGCMCipher(key []byte) (*AESGCMCipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &AESGCMCipher{aead: aead}, nil
+}
+
+func (a *AESGCMCipher) Seal(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, a.aead.NonceSize(), a.aead.NonceSize()+len(plaintext)+a.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return a.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (a *AESGCMCipher) Open(ciphertext []byte) ([]byte, error) {
+	nonceSize := a.aead.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return nil, ErrCiphertextTooShort
+	}
+	return a.aead.Open(nil, ciphertext[:nonceSize], ciphertext[nonceSize:], nil)
+}
+
+// encodedValue marks a stored value as compressed and/or sealed so Get
+// knows how to reverse it; plain []byte values pass through untouched.
+type encodedValue struct {
+	data       []byte
+	compressed bool
+	sealed     bool
+}
+
+// codecCache transforms []byte values on their way in and out of the
+// wrapped cache. Other value types are stored as is.
+type codecCache struct {
+	cache      LittleCache
+	compressor Compressor
+	threshold  int
+	cipher     Cipher
+	copyIn     bool // Config.CopyByteValues
+	copyOut    bool // Config.CopyByteValuesOnGet
+}
+
+func newCodecCache(cache LittleCache, config Config) *codecCache {
+	return &codecCache{
+		cache:      cache,
+		compressor: config.Compressor,
+		threshold:  config.CompressThreshold,
+		cipher:     config.Cipher,
+		copyIn:     config.CopyByteValues,
+		copyOut:    config.CopyByteValuesOnGet,
+	}
+}
+
+// wantsCodec reports whether config needs a codecCache around its cache.
+func wantsCodec(config Config) bool {
+	return config.Compressor != nil || config.Cipher != nil || config.CopyByteValues || config.CopyByteValuesOnGet
+}
+
+// encode compresses then seals a []byte value. It reports false if the
+// value can't be stored without leaking plaintext.
+func (c *codecCache) encode(value interface{}) (interface{}, bool) {
+	data, ok := value.([]byte)
+	if !ok {
+		return value, true
+	}
+
+	encoded := encodedValue{data: data}
+	if c.compressor != nil && len(data) > c.threshold {
+		compressed, err := c.compressor.Compress(data)
+		if err == nil && len(compressed) < len(data) {
+			encoded.data = compressed
+			encoded.compressed = true
+		}
+	}
+
+	if c.cipher != nil {
+		sealed, err := c.cipher.Seal(encoded.data)
+		if err != nil {
+			return nil, false
+		}
+		encoded.data = sealed
+		encoded.sealed = true
+	}
+
+	// Compressing and sealing already wrote new buffers.
+	if !encoded.compressed && !encoded.sealed {
+		if c.copyIn {
+			return bytes.Clone(data), true
+		}
+		return value, true
+	}
+	return encoded, true
+}
+
+func (c *codecCache) decode(stored interface{}) (interface{}, bool) {
+	encoded, ok := stored.(encodedValue)
+	if !ok {
+		if data, isBytes := stored.([]byte); isBytes && c.copyOut {
+			return bytes.Clone(data), true
+		}
+		return stored, true
+	}
+
+	data := encoded.data
+	var err error
+	if encoded.sealed {
+		if data, err = c.cipher.Open(data); err != nil {
+			return nil, false
+		}
+	}
+	if encoded.compressed {
+		if data, err = c.compressor.Decompress(data); err != nil {
+			return nil, false
+		}
+	}
+	return data, true
+}
+
+func (c *codecCache) Set(key string, value interface{}) {
+	encoded, ok := c.encode(value)
+	if !ok {
+		// Drop the write, and any older value, rather than store plaintext.
+		c.cache.Delete(key)
+		return
+	}
+	c.cache.Set(key, encoded)
+}
+
+// SetWithTTL encodes value as Set does and stores it with the wrapped
+// cache's SetWithTTL.
+func (c *codecCache) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	encoded, ok := c.encode(value)
+	if !ok {
+		c.cache.Delete(key)
+		return
+	}
+	c.cache.SetWithTTL(key, encoded, ttl)
+}
+
+func (c *codecCache) Get(key string) (interface{}, bool) {
+	stored, exists := c.cache.Get(key)
+	if !exists {
+		return nil, false
+	}
+	return c.decode(stored)
+}
+
+// Peek decodes the wrapped cache's value without counting an access, where
+// the wrapped cache supports Peek.
+func (c *codecCache) Peek(key string) (interface{}, bool) {
+	stored, exists := peek(c.cache, key)
+	if !exists {
+		return nil, false
+	}
+	return c.decode(stored)
+}
+
+func (c *codecCache) Swap(key string, value interface{}) (interface{}, bool) {
+	encoded, ok := c.encode(value)
+	if !ok {
+		previous, exists := c.Get(key)
+		c.cache.Delete(key)
+		return previous, exists
+	}
+
+	stored, exists := c.cache.Swap(key, encoded)
+	if !exists {
+		return nil, false
+	}
+	return c.decode(stored)
+}
+
+// LoadOrStore encodes value as Set does and decodes the value the wrapped
+// cache loads. A value that can't be encoded is never stored, and a loaded
+// value that can't be decoded is replaced, since Get would miss it too.
+func (c *codecCache) LoadOrStore(key string, value interface{}) (interface{}, bool) {
+	encoded, ok := c.encode(value)
+	if !ok {
+		if existing, exists := c.Get(key); exists {
+			return existing, true
+		}
+		return value, false
+	}
+
+	stored, loaded := loadOrStore(c.cache, key, encoded)
+	if !loaded {
+		return value, false
+	}
+	if existing, ok := c.decode(stored); ok {
+		return existing, true
+	}
+	c.cache.Set(key, encoded)
+	return value, false
+}
+
+// Dump returns the wrapped cache's snapshot with values decoded. Entries
+// that fail to decode are left out.
+func (c *codecCache) Dump() []Entry {
+	dumper, ok := c.cache.(interface{ Dump() []Entry })
+	if !ok {
+		return nil
+	}
+
+	stored := dumper.Dump()
+	entries := make([]Entry, 0, len(stored))
+	for _, e := range stored {
+		value, ok := c.decode(e.Value)
+		if !ok {
+			continue
+		}
+		e.Value = value
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func (c *codecCache) Delete(key string) {
+	c.cache.Delete(key)
+}
+
+func (c *codecCache) Clear() {
+	c.cache.Clear()
+}
+
+func (c *codecCache) Size() int {
+	return c.cache.Size()
+}
+
+func (c *codecCache) Resize(newSize int) error {
+	return c.cache.Resize(newSize)
+}
+
+// Stop stops the wrapped cache, if it has anything to stop.
+func (c *codecCache) Stop() {
+	if stopper, ok := c.cache.(interface{ Stop() }); ok {
+		stopper.Stop()
+	}
+}
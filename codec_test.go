@@ -0,0 +1,357 @@
+package littlecache
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestCodecCache_Compression(t *testing.T) {
+	config := Config{
+		MaxSize:           10,
+		EvictionPolicy:    LRU,
+		Compressor:        GzipCompressor{},
+		CompressThreshold: 64,
+	}
+	cache, err := NewLittleCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	blob := bytes.Repeat([]byte(`{"user":"alice","roles":["admin"]},`), 200)
+	cache.Set("blob", blob)
+
+	// The wrapped cache holds the compressed form
+	inner := cache.(*codecCache).cache
+	stored, exists := inner.Get("blob")
+	if !exists {
+		t.Fatalf("Expected blob to be stored")
+	}
+	compressed, ok := stored.(encodedValue)
+	if !ok || !compressed.compressed {
+		t.Fatalf("Expected stored value to be compressed, got %T", stored)
+	}
+	if len(compressed.data) >= len(blob) {
+		t.Errorf("Expected compressed size < %d, got %d", len(blob), len(compressed.data))
+	}
+
+	// Get returns the original bytes
+	value, exists := cache.Get("blob")
+	if !exists {
+		t.Fatalf("Expected blob to exist")
+	}
+	if !bytes.Equal(value.([]byte), blob) {
+		t.Errorf("Expected round-tripped bytes to match the original")
+	}
+}
+
+func TestCodecCache_BelowThresholdAndNonBytes(t *testing.T) {
+	config := Config{
+		MaxSize:           10,
+		EvictionPolicy:    LRU,
+		Compressor:        GzipCompressor{},
+		CompressThreshold: 64,
+	}
+	cache, err := NewLittleCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	inner := cache.(*codecCache).cache
+
+	small := []byte("tiny")
+	cache.Set("small", small)
+	if stored, _ := inner.Get("small"); !bytes.Equal(stored.([]byte), small) {
+		t.Errorf("Expected small value to be stored uncompressed")
+	}
+
+	cache.Set("string", "not bytes")
+	if value, _ := cache.Get("string"); value != "not bytes" {
+		t.Errorf("Expected non-[]byte value to pass through, got %v", value)
+	}
+}
+
+func TestCodecCache_InvalidThreshold(t *testing.T) {
+	config := Config{MaxSize: 10, EvictionPolicy: LRU, CompressThreshold: -1}
+	if _, err := NewLittleCache(config); !errors.Is(err, ErrInvalidCompressThreshold) {
+		t.Errorf("Expected ErrInvalidCompressThreshold, got %v", err)
+	}
+}
+
+func TestCodecCache_Encryption(t *testing.T) {
+	cipher, err := NewAESGCMCipher(bytes.Repeat([]byte{0x42}, 32))
+	if err != nil {
+		t.Fatalf("Failed to create cipher: %v", err)
+	}
+	config := Config{MaxSize: 10, EvictionPolicy: LFU, Cipher: cipher}
+	cache, err := NewLittleCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	secret := []byte("correct horse battery staple")
+	cache.Set("secret", secret)
+
+	inner := cache.(*codecCache).cache
+	stored, _ := inner.Get("secret")
+	sealed, ok := stored.(encodedValue)
+	if !ok || !sealed.sealed {
+		t.Fatalf("Expected stored value to be sealed, got %T", stored)
+	}
+	if bytes.Contains(sealed.data, secret) {
+		t.Errorf("Expected stored bytes not to contain the plaintext")
+	}
+
+	value, exists := cache.Get("secret")
+	if !exists || !bytes.Equal(value.([]byte), secret) {
+		t.Errorf("Expected decrypted value %q, got %v", secret, value)
+	}
+}
+
+func TestCodecCache_EncryptionUnderTTLPolicy(t *testing.T) {
+	cipher, err := NewAESGCMCipher(bytes.Repeat([]byte{0x42}, 32))
+	if err != nil {
+		t.Fatalf("Failed to create cipher: %v", err)
+	}
+	var evicted []interface{}
+	cache, err := NewLittleCache(Config{
+		MaxSize:        10,
+		EvictionPolicy: TTL,
+		Cipher:         cipher,
+		OnEvict: func(key string, value interface{}, reason EvictionReason) {
+			evicted = append(evicted, value)
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	ttlCache := cache.(*TTLCache)
+	defer ttlCache.Stop()
+
+	secret := []byte("correct horse battery staple")
+	cache.Set("secret", secret)
+
+	// The TTL layer keeps no value of its own, so only ciphertext is stored
+	stored, _ := ttlCache.cache.(*codecCache).cache.Get("secret")
+	if sealed, ok := stored.(encodedValue); !ok || !sealed.sealed || bytes.Contains(sealed.data, secret) {
+		t.Fatalf("Expected the underlying value to be sealed, got %T", stored)
+	}
+
+	// Reads and callbacks still see the plaintext
+	if value, ttl, ok := ttlCache.PeekWithTTL("secret"); !ok || !bytes.Equal(value.([]byte), secret) || ttl <= 0 {
+		t.Errorf("Expected to peek the plaintext, got %v (ttl=%v, ok=%v)", value, ttl, ok)
+	}
+	if value, ok := cache.Get("secret"); !ok || !bytes.Equal(value.([]byte), secret) {
+		t.Errorf("Expected to get the plaintext, got %v (ok=%v)", value, ok)
+	}
+	replacement := []byte("tr0ub4dor&3")
+	cache.Set("secret", replacement)
+	cache.Delete("secret")
+	if len(evicted) != 2 || !bytes.Equal(evicted[0].([]byte), secret) || !bytes.Equal(evicted[1].([]byte), replacement) {
+		t.Errorf("Expected OnEvict to receive the plaintext, got %v", evicted)
+	}
+}
+
+func TestCodecCache_CallbacksSeeCallerValues(t *testing.T) {
+	cipher, err := NewAESGCMCipher(bytes.Repeat([]byte{0x42}, 32))
+	if err != nil {
+		t.Fatalf("Failed to create cipher: %v", err)
+	}
+
+	var seen []interface{}
+	var reasons []EvictionReason
+	observe := func(value interface{}) { seen = append(seen, value) }
+	cache, err := NewLittleCache(Config{
+		MaxSize:           1,
+		EvictionPolicy:    LRU,
+		Cipher:            cipher,
+		Compressor:        GzipCompressor{},
+		CompressThreshold: 8,
+		SkipEqualWrites:   true,
+		MaxValueBytes:     1 << 10,
+		Admit: func(key string, value interface{}, currentSize, capacity int) bool {
+			observe(value)
+			return true
+		},
+		SizeOf: func(value interface{}) int64 {
+			observe(value)
+			return int64(len(value.([]byte)))
+		},
+		Equal: func(a, b interface{}) bool {
+			observe(a)
+			observe(b)
+			return bytes.Equal(a.([]byte), b.([]byte))
+		},
+		OnEvict: func(key string, value interface{}, reason EvictionReason) {
+			observe(value)
+			reasons = append(reasons, reason)
+		},
+		OnClear: func(snapshot map[string]interface{}) {
+			for _, value := range snapshot {
+				observe(value)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	first := bytes.Repeat([]byte("first,"), 10)
+	cache.Set("a", first)
+	// Every sealing differs, but the decoded values are equal
+	cache.Set("a", bytes.Clone(first))
+	cache.Set("b", []byte("second value"))
+	cache.Clear()
+
+	// The equal write was skipped, so a was never reported as Replaced
+	if len(reasons) != 2 || reasons[0] != CapacityEviction || reasons[1] != Cleared {
+		t.Errorf("Expected a capacity eviction then a clear, got %v", reasons)
+	}
+	for i, value := range seen {
+		if _, ok := value.([]byte); !ok {
+			t.Errorf("Callback %d received %T, expected the caller's []byte", i, value)
+		}
+	}
+}
+
+func TestCodecCache_CompressionAndEncryption(t *testing.T) {
+	cipher, err := NewAESGCMCipher(bytes.Repeat([]byte{0x42}, 16))
+	if err != nil {
+		t.Fatalf("Failed to create cipher: %v", err)
+	}
+	config := Config{
+		MaxSize:           10,
+		EvictionPolicy:    LRU,
+		Compressor:        GzipCompressor{},
+		CompressThreshold: 16,
+		Cipher:            cipher,
+	}
+	cache, err := NewLittleCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	blob := bytes.Repeat([]byte("sensitive-and-repetitive;"), 100)
+	cache.Set("blob", blob)
+
+	stored, _ := cache.(*codecCache).cache.Get("blob")
+	encoded := stored.(encodedValue)
+	if !encoded.compressed || !encoded.sealed {
+		t.Errorf("Expected value to be both compressed and sealed")
+	}
+
+	value, _ := cache.Get("blob")
+	if !bytes.Equal(value.([]byte), blob) {
+		t.Errorf("Expected round-tripped bytes to match the original")
+	}
+}
+
+func TestCodecCache_Swap(t *testing.T) {
+	config := Config{
+		MaxSize:           10,
+		EvictionPolicy:    LRU,
+		Compressor:        GzipCompressor{},
+		CompressThreshold: 64,
+	}
+	cache, err := NewLittleCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	first := bytes.Repeat([]byte("first,"), 100)
+	second := bytes.Repeat([]byte("second,"), 100)
+	cache.Set("blob", first)
+
+	// The previous value comes back decompressed
+	previous, existed := cache.Swap("blob", second)
+	if !existed || !bytes.Equal(previous.([]byte), first) {
+		t.Errorf("Expected Swap to return the original bytes")
+	}
+	if value, _ := cache.Get("blob"); !bytes.Equal(value.([]byte), second) {
+		t.Errorf("Expected the swapped-in bytes to be stored")
+	}
+}
+
+func TestCopyByteValues(t *testing.T) {
+	newCache := func(copyIn, copyOut bool) LittleCache {
+		cache, err := NewLittleCache(Config{
+			MaxSize:             10,
+			EvictionPolicy:      LRU,
+			CopyByteValues:      copyIn,
+			CopyByteValuesOnGet: copyOut,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create cache: %v", err)
+		}
+		return cache
+	}
+
+	// Without copying, the cache holds the caller's slice
+	aliased := newCache(false, false)
+	buf := []byte("hello")
+	aliased.Set("key", buf)
+	buf[0] = 'j'
+	if value, _ := aliased.Get("key"); string(value.([]byte)) != "jello" {
+		t.Errorf("Expected the cached value to alias the caller's slice, got %q", value)
+	}
+
+	copied := newCache(true, false)
+	buf = []byte("hello")
+	copied.Set("key", buf)
+	buf[0] = 'j'
+	value, _ := copied.Get("key")
+	if string(value.([]byte)) != "hello" {
+		t.Errorf("Expected the cached copy to be unchanged, got %q", value)
+	}
+
+	// Reads still share the stored slice unless copied on Get too
+	value.([]byte)[0] = 'y'
+	if again, _ := copied.Get("key"); string(again.([]byte)) != "yello" {
+		t.Errorf("Expected Get to return the stored slice, got %q", again)
+	}
+
+	copiedBothWays := newCache(true, true)
+	copiedBothWays.Set("key", []byte("hello"))
+	value, _ = copiedBothWays.Get("key")
+	value.([]byte)[0] = 'y'
+	if again, _ := copiedBothWays.Get("key"); string(again.([]byte)) != "hello" {
+		t.Errorf("Expected Get to return a copy, got %q", again)
+	}
+	if previous, _ := copiedBothWays.Swap("key", []byte("world")); string(previous.([]byte)) != "hello" {
+		t.Errorf("Expected Swap to return the old value, got %q", previous)
+	}
+
+	// Other value types pass through untouched
+	copiedBothWays.Set("number", 42)
+	if value, _ := copiedBothWays.Get("number"); value != 42 {
+		t.Errorf("Expected 42, got %v", value)
+	}
+}
+
+func TestNewAESGCMCipher_InvalidKey(t *testing.T) {
+	if _, err := NewAESGCMCipher([]byte("short")); err == nil {
+		t.Errorf("Expected error for invalid key length")
+	}
+}
+
+func TestCodecCache_LoadOrStoreDecodes(t *testing.T) {
+	config := Config{
+		MaxSize:           10,
+		EvictionPolicy:    LRU,
+		Compressor:        GzipCompressor{},
+		CompressThreshold: 64,
+	}
+	cache, err := NewLittleCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	codec := cache.(*codecCache)
+
+	blob := bytes.Repeat([]byte("payload,"), 100)
+	if actual, loaded := codec.LoadOrStore("blob", blob); loaded || !bytes.Equal(actual.([]byte), blob) {
+		t.Fatalf("Expected the first call to store blob, got loaded=%v", loaded)
+	}
+	actual, loaded := codec.LoadOrStore("blob", []byte("other"))
+	if !loaded || !bytes.Equal(actual.([]byte), blob) {
+		t.Errorf("Expected the second call to load the decoded blob, got loaded=%v", loaded)
+	}
+}
@@ -1,32 +1,50 @@
 package littlecache
 
 import (
-	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type DefCache struct {
-	config Config
-	data   map[string]interface{}
-	mu     sync.RWMutex
+	config  Config
+	data    map[string]interface{}
+	initial int // MaxSize as constructed, for Reset
+	mu      rwMutex
+	cacheStats
+	reads *readCounts // nil unless Config.TrackAccessCounts
+	saver autoSaver
 }
 
 func NewDefCache(config Config) (*DefCache, error) {
 	if err := config.Validate(); err != nil {
-		return nil, err
+		return nil, config.error("new", err)
 	}
+	config.initShared()
 
-	return &DefCache{
-		config: config,
-		data:   make(map[string]interface{}),
-	}, nil
+	d := &DefCache{
+		config:  config,
+		data:    make(map[string]interface{}, config.initialMapSize()),
+		initial: config.MaxSize,
+		mu:      newRWMutex(config),
+		reads:   newReadCounts(config),
+	}
+	d.bind(&d.config, &d.mu, func() int { return len(d.data) })
+	return d, nil
 }
 
 func (d *DefCache) Set(key string, value interface{}) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	if _, exists := d.data[key]; exists {
-		d.data[key] = value
+	if !d.config.admits(key, value, len(d.data)) {
+		return
+	}
+
+	if previous, exists := d.data[key]; exists {
+		if !d.config.ImmutableKeys && !d.config.unchanged(previous, value) {
+			d.config.evicted(key, previous, Replaced)
+			d.data[key] = value
+		}
 		return
 	}
 
@@ -35,6 +53,12 @@ func (d *DefCache) Set(key string, value interface{}) {
 	}
 
 	d.data[key] = value
+	d.counters.observeSize(len(d.data))
+}
+
+// SetWithTTL is Set; a DefCache never expires entries, so ttl is ignored.
+func (d *DefCache) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	d.Set(key, value)
 }
 
 func (d *DefCache) Get(key string) (interface{}, bool) {
@@ -42,21 +66,127 @@ func (d *DefCache) Get(key string) (interface{}, bool) {
 	defer d.mu.RUnlock()
 
 	value, exists := d.data[key]
+	d.counters.record(exists)
+	if exists {
+		d.reads.add(key)
+	}
 	return value, exists
 }
 
+// LoadOrStore returns the existing value for key if present. Otherwise it
+// stores value, if there is room and Set would admit it, and returns it.
+// The loaded result is true if the value was loaded, false if stored or
+// rejected.
+func (d *DefCache) LoadOrStore(key string, value interface{}) (interface{}, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	existing, exists := d.data[key]
+	d.counters.record(exists)
+	if exists {
+		d.reads.add(key)
+		return existing, true
+	}
+
+	if len(d.data) < d.config.MaxSize && d.config.admits(key, value, len(d.data)) {
+		d.data[key] = value
+		d.counters.observeSize(len(d.data))
+	}
+	return value, false
+}
+
+// Swap stores value and returns the previous value, if any. A new key is
+// only stored if there is room, as with Set.
+func (d *DefCache) Swap(key string, value interface{}) (interface{}, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	previous, exists := d.data[key]
+	if exists {
+		d.config.evicted(key, previous, Replaced)
+	}
+	if exists || len(d.data) < d.config.MaxSize {
+		d.data[key] = value
+		d.counters.observeSize(len(d.data))
+	}
+	return previous, exists
+}
+
 func (d *DefCache) Delete(key string) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	delete(d.data, key)
+	if value, exists := d.data[key]; exists {
+		delete(d.data, key)
+		d.reads.forget(key)
+		d.config.evicted(key, value, Deleted)
+	}
 }
 
 func (d *DefCache) Clear() {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
+	if d.config.OnClear != nil {
+		// The map is about to be dropped, so it can be handed over as is.
+		d.config.cleared(d.data)
+	}
+	d.reportCleared()
+	d.data = make(map[string]interface{})
+	d.reads.reset()
+}
+
+// reportCleared reports every entry to OnEvict as Cleared.
+func (d *DefCache) reportCleared() {
+	if d.config.OnEvict != nil {
+		for key, value := range d.data {
+			d.config.notify(key, value, Cleared)
+		}
+	}
+}
+
+// ReplaceAll swaps in items as the entire contents under one write lock, so
+// readers see either the old or the new data set, never an empty cache.
+// If items holds more than MaxSize entries, an arbitrary MaxSize of them
+// are kept.
+func (d *DefCache) ReplaceAll(items map[string]interface{}) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.reportCleared()
+	d.reads.reset()
+	d.data = make(map[string]interface{}, min(len(items), d.config.MaxSize))
+	for key, value := range items {
+		if len(d.data) >= d.config.MaxSize {
+			break
+		}
+		d.data[key] = value
+	}
+	d.counters.observeSize(len(d.data))
+}
+
+// Drain empties the cache and returns its previous contents. Both happen
+// under one write lock, so no Set can land in between.
+func (d *DefCache) Drain() map[string]interface{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	entries := d.data
 	d.data = make(map[string]interface{})
+	d.reads.reset()
+	return entries
+}
+
+// Dump returns a consistent snapshot of every entry.
+func (d *DefCache) Dump() []Entry {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	entries := make([]Entry, 0, len(d.data))
+	for key, value := range d.data {
+		entries = append(entries, Entry{Key: key, Value: value})
+	}
+	return entries
 }
 
 func (d *DefCache) Size() int {
@@ -66,12 +196,15 @@ func (d *DefCache) Size() int {
 	return len(d.data)
 }
 
+// Resize changes the capacity. Shrinking below the current size keeps every
+// entry, since NoEviction never removes data; Size stays above MaxSize until
+// entries are deleted. Use ResizeStrict to enforce the new capacity.
 func (d *DefCache) Resize(newSize int) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	if newSize <= 0 {
-		return ErrInvalidMaxSize
+	if err := checkSize(newSize); err != nil {
+		return d.config.error("resize", err)
 	}
 
 	d.config.MaxSize = newSize
@@ -80,3 +213,78 @@ func (d *DefCache) Resize(newSize int) error {
 	// since NoEviction policy doesn't remove items
 	return nil
 }
+
+// ResizeStrict is Resize that, on shrink, deletes arbitrary entries until
+// the cache fits the new capacity. Which entries go is unspecified.
+func (d *DefCache) ResizeStrict(newSize int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := checkSize(newSize); err != nil {
+		return d.config.error("resize", err)
+	}
+
+	d.config.MaxSize = newSize
+	for key, value := range d.data {
+		if len(d.data) <= newSize {
+			break
+		}
+		delete(d.data, key)
+		d.reads.forget(key)
+		d.evictions.record(clockOrDefault(d.config.Clock).Now(), 1)
+		d.config.evicted(key, value, CapacityEviction)
+	}
+	return nil
+}
+
+// Upgrade builds a cache with the same config but the given eviction
+// policy, as NewLittleCache would, and copies the current entries into it.
+// If they don't all fit, which is possible after a shrinking Resize, the
+// new cache evicts the overflow as usual; entries are copied in map order,
+// so which ones go is arbitrary. Those evictions aren't reported to
+// OnEvict, since the entries are still in the DefCache: the new cache
+// calls OnEvict only once the copy is done. The DefCache is left as it
+// was, and writes to it after the copy don't reach the new cache.
+func (d *DefCache) Upgrade(policy EvictionPolicy) (LittleCache, error) {
+	d.mu.RLock()
+	config := d.config
+	entries := make(map[string]interface{}, len(d.data))
+	for key, value := range d.data {
+		entries[key] = value
+	}
+	d.mu.RUnlock()
+
+	// The new cache gets its own history and callback guard, and none of
+	// the hooks a wrapping TTLCache chained onto the DefCache: they would
+	// reach into that TTLCache without its lock.
+	config.EvictionPolicy = policy
+	config.history = nil
+	config.callbacks = nil
+	config.evictHook = nil
+	config.decode = nil
+
+	var filled atomic.Bool
+	if onEvict := config.OnEvict; onEvict != nil {
+		config.OnEvict = func(key string, value interface{}, reason EvictionReason) {
+			if filled.Load() {
+				onEvict(key, value, reason)
+			}
+		}
+	}
+	cache, err := NewLittleCache(config)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range entries {
+		cache.Set(key, value)
+	}
+	filled.Store(true)
+	return cache, nil
+}
+
+// chainOnEvict adds fn to the OnEvict callback, for a wrapping TTLCache.
+func (d *DefCache) chainOnEvict(fn func(key string, value interface{}, reason EvictionReason)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.config.chainOnEvict(fn)
+}
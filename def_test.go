@@ -1,9 +1,11 @@
 package littlecache
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestDefCache_BasicOperations(t *testing.T) {
@@ -173,6 +175,52 @@ func TestDefCache_Resize(t *testing.T) {
 	}
 }
 
+func TestDefCache_ResizeShrink(t *testing.T) {
+	config := Config{MaxSize: 4, EvictionPolicy: NoEviction}
+	lenient, err := NewDefCache(config)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	strict, err := NewDefCache(config)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, key := range []string{"key1", "key2", "key3", "key4"} {
+		lenient.Set(key, key)
+		strict.Set(key, key)
+	}
+
+	// Resize keeps everything, so Size exceeds the new capacity
+	if err := lenient.Resize(2); err != nil {
+		t.Errorf("Unexpected error during resize: %v", err)
+	}
+	if lenient.Size() != 4 {
+		t.Errorf("Expected size 4 after lenient shrink, got %d", lenient.Size())
+	}
+	lenient.Set("key5", "value5")
+	if _, found := lenient.Get("key5"); found {
+		t.Errorf("Expected key5 to be rejected while over capacity")
+	}
+
+	// ResizeStrict drops entries down to the new capacity
+	if err := strict.ResizeStrict(2); err != nil {
+		t.Errorf("Unexpected error during strict resize: %v", err)
+	}
+	if strict.Size() != 2 {
+		t.Errorf("Expected size 2 after strict shrink, got %d", strict.Size())
+	}
+	for key, value := range strict.Drain() {
+		if key != value {
+			t.Errorf("Expected surviving key %s to keep its value, got %v", key, value)
+		}
+	}
+
+	if err := strict.ResizeStrict(0); err == nil {
+		t.Errorf("Expected error for invalid resize")
+	}
+}
+
 func TestDefCache_Concurrency(t *testing.T) {
 	config := Config{MaxSize: 100, EvictionPolicy: NoEviction}
 	cache, err := NewDefCache(config)
@@ -212,3 +260,139 @@ func TestDefCache_Concurrency(t *testing.T) {
 	wg.Wait()
 	// Just check that we don't panic during concurrent operations
 }
+
+func TestDefCache_Drain(t *testing.T) {
+	config := Config{MaxSize: 3, EvictionPolicy: NoEviction}
+	cache, err := NewDefCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create DefCache: %v", err)
+	}
+
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+
+	entries := cache.Drain()
+	if len(entries) != 2 || entries["key1"] != "value1" || entries["key2"] != "value2" {
+		t.Errorf("Expected drained entries to match prior contents, got %v", entries)
+	}
+	if cache.Size() != 0 {
+		t.Errorf("Expected size 0 after drain, got %d", cache.Size())
+	}
+
+	// The cache remains usable
+	cache.Set("key3", "value3")
+	if cache.Size() != 1 {
+		t.Errorf("Expected size 1 after set, got %d", cache.Size())
+	}
+}
+
+func TestDefCache_Upgrade(t *testing.T) {
+	cache, err := NewDefCache(Config{MaxSize: 5, EvictionPolicy: NoEviction})
+	if err != nil {
+		t.Fatalf("Failed to create def cache: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		cache.Set(fmt.Sprintf("key%d", i), i)
+	}
+
+	upgraded, err := cache.Upgrade(LRU)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := upgraded.(*LRUCache); !ok {
+		t.Fatalf("Expected an LRU cache, got %T", upgraded)
+	}
+	for i := 0; i < 5; i++ {
+		key := fmt.Sprintf("key%d", i)
+		if value, ok := upgraded.Get(key); !ok || value != i {
+			t.Errorf("Expected %s=%d to carry over, got %v (ok=%v)", key, i, value, ok)
+		}
+	}
+
+	// Unlike the DefCache, the upgraded cache makes room for new keys
+	upgraded.Set("new", 5)
+	if upgraded.Size() != 5 || !Has(upgraded, "new") {
+		t.Errorf("Expected the new key to evict an old one, got size %d", upgraded.Size())
+	}
+	if cache.Size() != 5 || Has(cache, "new") {
+		t.Errorf("Expected the original cache to be left alone")
+	}
+}
+
+func TestDefCache_UpgradeEvictsOverflow(t *testing.T) {
+	var evicted []string
+	cache, err := NewDefCache(Config{
+		MaxSize: 10,
+		OnEvict: func(key string, value interface{}, reason EvictionReason) {
+			if reason == CapacityEviction {
+				evicted = append(evicted, key)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create def cache: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		cache.Set(fmt.Sprintf("key%d", i), i)
+	}
+	// NoEviction keeps all ten entries over the smaller capacity
+	cache.Resize(4)
+
+	upgraded, err := cache.Upgrade(LFU)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if upgraded.Size() != 4 {
+		t.Errorf("Expected the upgraded cache to hold 4 entries, got %d", upgraded.Size())
+	}
+	// The overflow is still in the DefCache, so it isn't reported as evicted
+	if len(evicted) != 0 {
+		t.Errorf("Expected no OnEvict calls on the way in, got %v", evicted)
+	}
+	upgraded.Set("new", 10)
+	if len(evicted) != 1 {
+		t.Errorf("Expected OnEvict once the copy is done, got %v", evicted)
+	}
+
+	if _, err := cache.Upgrade(EvictionPolicy(99)); !errors.Is(err, ErrInvalidEvictionPolicy) {
+		t.Errorf("Expected ErrInvalidEvictionPolicy, got %v", err)
+	}
+}
+
+func TestDefCache_UpgradeUnderTTLCache(t *testing.T) {
+	cache, err := NewDefCache(Config{MaxSize: 2})
+	if err != nil {
+		t.Fatalf("Failed to create def cache: %v", err)
+	}
+	ttlCache, err := NewTTLCache(TTLConfig{
+		UnderlyingCache:    cache,
+		DefaultTTL:         time.Minute,
+		ExpirationStrategy: ExpireLazy,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create TTL cache: %v", err)
+	}
+	defer ttlCache.Stop()
+
+	ttlCache.Set("a", 1)
+	ttlCache.Set("b", 2)
+
+	upgraded, err := cache.Upgrade(LRU)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if upgraded.(*LRUCache).config.callbacks == cache.config.callbacks {
+		t.Errorf("Expected the upgraded cache to get its own callback guard")
+	}
+
+	// Evictions in the upgraded cache are its own business; the TTL cache
+	// wrapping the DefCache must not lose its records for them
+	upgraded.Get("b")
+	upgraded.Set("c", 3)
+	if _, exists := upgraded.Get("a"); exists {
+		t.Fatalf("Expected the upgraded cache to evict a")
+	}
+	if value, ok := ttlCache.Get("a"); !ok || value != 1 {
+		t.Errorf("Expected the TTL cache to still serve a, got %v (ok=%v)", value, ok)
+	}
+}
@@ -0,0 +1,245 @@
+package littlecache
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// NodeClient is the transport to one remote cache node. The package ships
+// no implementation: wrap whatever protocol the nodes speak. Get reports a
+// missing key with false and a nil error; an error means the node couldn't
+// be asked.
+type NodeClient interface {
+	Get(key string) (interface{}, bool, error)
+	Set(key string, value interface{}) error
+	Delete(key string) error
+}
+
+// DistributedCache is a cache whose keys are spread across several nodes.
+// Unlike LittleCache, every operation may fail on the network, so each
+// returns an error.
+type DistributedCache interface {
+	Get(key string) (interface{}, bool, error)
+	Set(key string, value interface{}) error
+	Delete(key string) error
+	// Owner returns the name of the node responsible for key, and false
+	// if there are no nodes.
+	Owner(key string) (string, bool)
+}
+
+// defaultVirtualNodes is how many points each node gets on the hash ring
+// when ClusterConfig.VirtualNodes is zero; enough that a handful of nodes
+// split the keys within a few percent of evenly.
+const defaultVirtualNodes = 128
+
+type ClusterConfig struct {
+	// Local is the L1 cache every Get checks before asking a node, and
+	// that keeps what the nodes return. Other clients' writes don't reach
+	// it, so give it a TTL or a small size to bound how stale it can get.
+	Local LittleCache
+	// Nodes maps each node's name to its transport. Names place the nodes
+	// on the hash ring, so every client must use the same names.
+	Nodes map[string]NodeClient
+	// VirtualNodes is the number of ring points per node. Zero picks a
+	// default; more points spread keys more evenly.
+	VirtualNodes int
+	// Hash hashes keys and ring points; nil uses ringHash. Every client
+	// must use the same function, and its high bits must be well mixed,
+	// since they decide where on the ring a key falls.
+	Hash func(key string) uint64
+}
+
+// ringHash is FNV-1a run through the MurmurHash3 finalizer. FNV-1a alone
+// is fine for picking a shard by remainder, but keys differing only in
+// their last bytes, like "user:1" and "user:2", land close together in
+// its high bits and would crowd onto one node's arc.
+func ringHash(key string) uint64 {
+	h := fnv1a(key)
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+type ringPoint struct {
+	hash uint64
+	node string
+}
+
+// ClusterCache is a DistributedCache that consistent-hashes each key to a
+// node, with a local cache in front. Adding or removing a node only moves
+// the keys on its share of the ring.
+type ClusterCache struct {
+	local        LittleCache
+	hash         func(key string) uint64
+	virtualNodes int
+
+	mu     sync.RWMutex
+	nodes  map[string]NodeClient
+	points []ringPoint // sorted by hash
+}
+
+func NewClusterCache(config ClusterConfig) (*ClusterCache, error) {
+	if config.Local == nil {
+		return nil, newError("new", ErrNilLocalCache)
+	}
+	if config.VirtualNodes < 0 {
+		return nil, newError("new", ErrInvalidVirtualNodes)
+	}
+
+	c := &ClusterCache{
+		local:        config.Local,
+		hash:         config.Hash,
+		virtualNodes: config.VirtualNodes,
+		nodes:        make(map[string]NodeClient, len(config.Nodes)),
+	}
+	if c.hash == nil {
+		c.hash = ringHash
+	}
+	if c.virtualNodes == 0 {
+		c.virtualNodes = defaultVirtualNodes
+	}
+	for name, client := range config.Nodes {
+		c.nodes[name] = client
+	}
+	c.rebuildRing()
+	return c, nil
+}
+
+// rebuildRing places every node's points on the ring. It must be called
+// with mu held for writing, or before the cache is shared.
+func (c *ClusterCache) rebuildRing() {
+	c.points = make([]ringPoint, 0, len(c.nodes)*c.virtualNodes)
+	for name := range c.nodes {
+		for i := 0; i < c.virtualNodes; i++ {
+			c.points = append(c.points, ringPoint{hash: c.hash(name + "#" + strconv.Itoa(i)), node: name})
+		}
+	}
+	// Ties on hash are broken by name, so every client orders the ring
+	// the same way.
+	sort.Slice(c.points, func(i, j int) bool {
+		if c.points[i].hash != c.points[j].hash {
+			return c.points[i].hash < c.points[j].hash
+		}
+		return c.points[i].node < c.points[j].node
+	})
+}
+
+// owner returns the node for key: the first ring point at or after the
+// key's hash, wrapping around. It must be called with mu held.
+func (c *ClusterCache) owner(key string) (string, bool) {
+	if len(c.points) == 0 {
+		return "", false
+	}
+	hash := c.hash(key)
+	i := sort.Search(len(c.points), func(i int) bool { return c.points[i].hash >= hash })
+	if i == len(c.points) {
+		i = 0
+	}
+	return c.points[i].node, true
+}
+
+func (c *ClusterCache) Owner(key string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.owner(key)
+}
+
+// client returns the transport for key's owner, or ErrNoNodes.
+func (c *ClusterCache) client(key string) (NodeClient, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	name, ok := c.owner(key)
+	if !ok {
+		return nil, ErrNoNodes
+	}
+	return c.nodes[name], nil
+}
+
+// Get returns key from the local cache, or else from its owner, keeping a
+// remote hit locally for next time.
+func (c *ClusterCache) Get(key string) (interface{}, bool, error) {
+	if value, ok := c.local.Get(key); ok {
+		return value, true, nil
+	}
+
+	client, err := c.client(key)
+	if err != nil {
+		return nil, false, newError("get", err)
+	}
+	value, ok, err := client.Get(key)
+	if err != nil {
+		return nil, false, newError("get", err)
+	}
+	if ok {
+		c.local.Set(key, value)
+	}
+	return value, ok, nil
+}
+
+// Set writes key to its owner, then to the local cache. If the owner
+// can't be written, the local copy is dropped rather than left to
+// disagree with it.
+func (c *ClusterCache) Set(key string, value interface{}) error {
+	client, err := c.client(key)
+	if err == nil {
+		err = client.Set(key, value)
+	}
+	if err != nil {
+		c.local.Delete(key)
+		return newError("set", err)
+	}
+	c.local.Set(key, value)
+	return nil
+}
+
+// Delete removes key locally and from its owner.
+func (c *ClusterCache) Delete(key string) error {
+	c.local.Delete(key)
+
+	client, err := c.client(key)
+	if err == nil {
+		err = client.Delete(key)
+	}
+	if err != nil {
+		return newError("delete", err)
+	}
+	return nil
+}
+
+// AddNode adds a node, or replaces the transport of one already named
+// name. Keys the new node now owns aren't copied to it; until they are
+// written again, Gets for them miss.
+func (c *ClusterCache) AddNode(name string, client NodeClient) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, exists := c.nodes[name]
+	c.nodes[name] = client
+	if !exists {
+		c.rebuildRing()
+	}
+}
+
+// RemoveNode takes a node off the ring, handing its keys to the next node
+// along, and reports whether it was there.
+func (c *ClusterCache) RemoveNode(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.nodes[name]; !exists {
+		return false
+	}
+	delete(c.nodes, name)
+	c.rebuildRing()
+	return true
+}
+
+// Local returns the local cache, for its Stats or to clear it.
+func (c *ClusterCache) Local() LittleCache {
+	return c.local
+}
@@ -0,0 +1,220 @@
+package littlecache
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// fakeNode is an in-memory NodeClient that counts the calls it serves.
+type fakeNode struct {
+	mu   sync.Mutex
+	data map[string]interface{}
+	gets int
+	fail error
+}
+
+func newFakeNode() *fakeNode {
+	return &fakeNode{data: make(map[string]interface{})}
+}
+
+func (n *fakeNode) Get(key string) (interface{}, bool, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.gets++
+	if n.fail != nil {
+		return nil, false, n.fail
+	}
+	value, ok := n.data[key]
+	return value, ok, nil
+}
+
+func (n *fakeNode) Set(key string, value interface{}) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.fail != nil {
+		return n.fail
+	}
+	n.data[key] = value
+	return nil
+}
+
+func (n *fakeNode) Delete(key string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.fail != nil {
+		return n.fail
+	}
+	delete(n.data, key)
+	return nil
+}
+
+func newTestCluster(t *testing.T, names ...string) (*ClusterCache, map[string]*fakeNode) {
+	t.Helper()
+
+	local, err := NewLRUCache(Config{MaxSize: 100})
+	if err != nil {
+		t.Fatalf("Failed to create local cache: %v", err)
+	}
+	fakes := make(map[string]*fakeNode)
+	nodes := make(map[string]NodeClient)
+	for _, name := range names {
+		fakes[name] = newFakeNode()
+		nodes[name] = fakes[name]
+	}
+	cluster, err := NewClusterCache(ClusterConfig{Local: local, Nodes: nodes, VirtualNodes: 16})
+	if err != nil {
+		t.Fatalf("Failed to create cluster cache: %v", err)
+	}
+	return cluster, fakes
+}
+
+// ringOwner finds key's owner the slow way: the node with the nearest
+// virtual point at or after the key's hash, wrapping to the lowest point.
+func ringOwner(key string, names []string, virtualNodes int) string {
+	hash := ringHash(key)
+	var next, lowest ringPoint
+	foundNext, foundLowest := false, false
+	for _, name := range names {
+		for i := 0; i < virtualNodes; i++ {
+			point := ringPoint{hash: ringHash(name + "#" + strconv.Itoa(i)), node: name}
+			if point.hash >= hash && (!foundNext || point.hash < next.hash) {
+				next, foundNext = point, true
+			}
+			if !foundLowest || point.hash < lowest.hash {
+				lowest, foundLowest = point, true
+			}
+		}
+	}
+	if foundNext {
+		return next.node
+	}
+	return lowest.node
+}
+
+func TestClusterCache_RoutesToRingOwner(t *testing.T) {
+	names := []string{"node-a", "node-b", "node-c"}
+	cluster, fakes := newTestCluster(t, names...)
+
+	for i := 0; i < 300; i++ {
+		key := "key" + strconv.Itoa(i)
+		if err := cluster.Set(key, i); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		want := ringOwner(key, names, 16)
+		if owner, ok := cluster.Owner(key); !ok || owner != want {
+			t.Errorf("Expected %s to be owned by %s, got %s (ok=%v)", key, want, owner, ok)
+		}
+		for name, node := range fakes {
+			if _, stored := node.data[key]; stored != (name == want) {
+				t.Errorf("Expected %s on %s only, but %s has it: %v", key, want, name, stored)
+			}
+		}
+	}
+
+	for name, node := range fakes {
+		if len(node.data) == 0 {
+			t.Errorf("Expected %s to own some of the keys", name)
+		}
+	}
+}
+
+func TestClusterCache_LocalL1(t *testing.T) {
+	cluster, fakes := newTestCluster(t, "node-a", "node-b")
+	owner, _ := cluster.Owner("user:1")
+	node := fakes[owner]
+	node.data["user:1"] = "Ada" // written by another client
+
+	for i := 0; i < 3; i++ {
+		value, ok, err := cluster.Get("user:1")
+		if err != nil || !ok || value != "Ada" {
+			t.Fatalf("Expected Ada, got %v (ok=%v, err=%v)", value, ok, err)
+		}
+	}
+	if node.gets != 1 {
+		t.Errorf("Expected one remote Get, then local hits, got %d remote Gets", node.gets)
+	}
+
+	// A miss isn't cached, so the next Get asks again
+	if _, ok, err := cluster.Get("missing"); ok || err != nil {
+		t.Errorf("Expected a clean miss, got ok=%v, err=%v", ok, err)
+	}
+
+	// Set writes through, so the local cache answers
+	if err := cluster.Set("user:2", "Grace"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	owner2, _ := cluster.Owner("user:2")
+	before := fakes[owner2].gets
+	if value, ok, _ := cluster.Get("user:2"); !ok || value != "Grace" {
+		t.Errorf("Expected Grace, got %v (ok=%v)", value, ok)
+	}
+	if fakes[owner2].gets != before {
+		t.Errorf("Expected a written key to be served locally")
+	}
+
+	if err := cluster.Delete("user:2"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok, _ := cluster.Get("user:2"); ok {
+		t.Errorf("Expected user:2 to be deleted locally and remotely")
+	}
+}
+
+func TestClusterCache_TransportErrors(t *testing.T) {
+	cluster, fakes := newTestCluster(t, "node-a")
+	transport := errors.New("connection refused")
+
+	if err := cluster.Set("a", 1); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	fakes["node-a"].fail = transport
+
+	// The failed write drops the local copy rather than let it disagree
+	if err := cluster.Set("a", 2); !errors.Is(err, transport) {
+		t.Errorf("Expected the transport error, got %v", err)
+	}
+	if _, ok, err := cluster.Get("a"); ok || !errors.Is(err, transport) {
+		t.Errorf("Expected a remote Get failing with the transport error, got ok=%v, err=%v", ok, err)
+	}
+
+	cluster.RemoveNode("node-a")
+	if _, _, err := cluster.Get("a"); !errors.Is(err, ErrNoNodes) {
+		t.Errorf("Expected ErrNoNodes, got %v", err)
+	}
+	if _, err := NewClusterCache(ClusterConfig{}); !errors.Is(err, ErrNilLocalCache) {
+		t.Errorf("Expected ErrNilLocalCache, got %v", err)
+	}
+}
+
+func TestClusterCache_RemoveNodeMovesOnlyItsKeys(t *testing.T) {
+	cluster, _ := newTestCluster(t, "node-a", "node-b", "node-c", "node-d")
+
+	before := make(map[string]string)
+	for i := 0; i < 1000; i++ {
+		key := "key" + strconv.Itoa(i)
+		before[key], _ = cluster.Owner(key)
+	}
+
+	if !cluster.RemoveNode("node-b") {
+		t.Fatalf("Expected node-b to be removed")
+	}
+	for key, was := range before {
+		now, _ := cluster.Owner(key)
+		switch {
+		case was == "node-b" && now == "node-b":
+			t.Errorf("Expected %s to leave the removed node", key)
+		case was != "node-b" && now != was:
+			t.Errorf("Expected %s to stay on %s, moved to %s", key, was, now)
+		}
+	}
+
+	cluster.AddNode("node-b", newFakeNode())
+	for key, was := range before {
+		if now, _ := cluster.Owner(key); now != was {
+			t.Errorf("Expected re-adding node-b to restore %s to %s, got %s", key, was, now)
+		}
+	}
+}
@@ -0,0 +1,328 @@
+package littlecache
+
+import (
+	"context"
+	"time"
+)
+
+// entryLookup reads one key's entry, reporting false if it has gone. It
+// should hold the cache lock only for that key, so writers can interleave
+// with a long walk.
+type entryLookup func(key string) (Entry, bool)
+
+// streamEntries sends the entry for each key on the returned channel and
+// closes it when done, or once ctx is canceled. Keys that have gone by the
+// time they are looked up are skipped.
+func streamEntries(ctx context.Context, keys []string, lookup entryLookup) <-chan Entry {
+	ch := make(chan Entry)
+	go func() {
+		defer close(ch)
+		for _, key := range keys {
+			if ctx.Err() != nil {
+				return
+			}
+			e, ok := lookup(key)
+			if !ok {
+				continue
+			}
+			select {
+			case ch <- e:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// rangeEntries calls fn for each key still present when its turn comes,
+// stopping early when fn returns false.
+func rangeEntries(keys []string, lookup entryLookup, fn func(key string, value interface{}) bool) {
+	for _, key := range keys {
+		if e, ok := lookup(key); ok && !fn(e.Key, e.Value) {
+			return
+		}
+	}
+}
+
+// Entries streams every entry over a channel without holding the lock for
+// the whole walk. The key list is taken up front and each value is read as
+// it is sent, so the result is not a consistent snapshot: keys deleted
+// mid-stream are skipped, keys added mid-stream are missed and values may
+// be newer than the key list. Use Dump for a consistent view. To stop
+// early, cancel ctx: the sending goroutine then exits and closes the
+// channel, where otherwise it would block until the channel is drained.
+func (d *DefCache) Entries(ctx context.Context) <-chan Entry {
+	keys, lookup := d.entrySource()
+	return streamEntries(ctx, keys, lookup)
+}
+
+// RangeSnapshot calls fn for each entry until it returns false, locking
+// only to copy the key list and then to read each value, so writers are
+// not held up while fn runs and fn may itself write to the cache. It has
+// the same consistency caveats as Entries, and doesn't count hits.
+func (d *DefCache) RangeSnapshot(fn func(key string, value interface{}) bool) {
+	keys, lookup := d.entrySource()
+	rangeEntries(keys, lookup, fn)
+}
+
+// entrySource copies the key list under a brief read lock and returns it
+// with a lookup for the values.
+func (d *DefCache) entrySource() ([]string, entryLookup) {
+	d.mu.RLock()
+	keys := make([]string, 0, len(d.data))
+	for key := range d.data {
+		keys = append(keys, key)
+	}
+	d.mu.RUnlock()
+
+	return keys, func(key string) (Entry, bool) {
+		d.mu.RLock()
+		defer d.mu.RUnlock()
+
+		value, exists := d.data[key]
+		return Entry{Key: key, Value: value}, exists
+	}
+}
+
+// Entries streams every entry from most to least recently used without
+// changing the recency order. Ranks reflect the order when the stream
+// started. See DefCache.Entries for the consistency caveats.
+func (lru *LRUCache) Entries(ctx context.Context) <-chan Entry {
+	keys, lookup := lru.entrySource()
+	return streamEntries(ctx, keys, lookup)
+}
+
+// RangeSnapshot calls fn from most to least recently used until it returns
+// false, without changing the recency order. See DefCache.RangeSnapshot.
+func (lru *LRUCache) RangeSnapshot(fn func(key string, value interface{}) bool) {
+	keys, lookup := lru.entrySource()
+	rangeEntries(keys, lookup, fn)
+}
+
+func (lru *LRUCache) entrySource() ([]string, entryLookup) {
+	lru.mu.RLock()
+	keys := make([]string, 0, lru.size)
+	ranks := make(map[string]int, lru.size)
+	for node := lru.head.next; node != lru.tail; node = node.next {
+		ranks[node.key] = len(keys)
+		keys = append(keys, node.key)
+	}
+	lru.mu.RUnlock()
+
+	return keys, func(key string) (Entry, bool) {
+		lru.mu.RLock()
+		defer lru.mu.RUnlock()
+
+		node, exists := lru.cache[key]
+		if !exists {
+			return Entry{}, false
+		}
+		return Entry{Key: key, Value: node.value, Rank: ranks[key]}, true
+	}
+}
+
+// Entries streams every entry without touching frequencies. See
+// DefCache.Entries for the consistency caveats.
+func (lfu *LFUCache) Entries(ctx context.Context) <-chan Entry {
+	keys, lookup := lfu.entrySource()
+	return streamEntries(ctx, keys, lookup)
+}
+
+// RangeSnapshot calls fn for each entry until it returns false, without
+// touching frequencies. See DefCache.RangeSnapshot.
+func (lfu *LFUCache) RangeSnapshot(fn func(key string, value interface{}) bool) {
+	keys, lookup := lfu.entrySource()
+	rangeEntries(keys, lookup, fn)
+}
+
+func (lfu *LFUCache) entrySource() ([]string, entryLookup) {
+	lfu.mu.RLock()
+	keys := make([]string, 0, lfu.size)
+	for key := range lfu.cache {
+		keys = append(keys, key)
+	}
+	lfu.mu.RUnlock()
+
+	return keys, func(key string) (Entry, bool) {
+		lfu.mu.RLock()
+		defer lfu.mu.RUnlock()
+
+		node, exists := lfu.cache[key]
+		if !exists {
+			return Entry{}, false
+		}
+		return Entry{Key: key, Value: node.value}, true
+	}
+}
+
+// Entries streams every entry from oldest to newest. See DefCache.Entries
+// for the consistency caveats.
+func (r *RingCache) Entries(ctx context.Context) <-chan Entry {
+	keys, lookup := r.entrySource()
+	return streamEntries(ctx, keys, lookup)
+}
+
+// RangeSnapshot calls fn from oldest to newest until it returns false. See
+// DefCache.RangeSnapshot.
+func (r *RingCache) RangeSnapshot(fn func(key string, value interface{}) bool) {
+	keys, lookup := r.entrySource()
+	rangeEntries(keys, lookup, fn)
+}
+
+func (r *RingCache) entrySource() ([]string, entryLookup) {
+	r.mu.RLock()
+	live := r.ordered()
+	r.mu.RUnlock()
+
+	keys := make([]string, len(live))
+	for i, slot := range live {
+		keys[i] = slot.key
+	}
+
+	return keys, func(key string) (Entry, bool) {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+
+		pos, exists := r.index[key]
+		if !exists {
+			return Entry{}, false
+		}
+		return Entry{Key: key, Value: r.slots[pos].value}, true
+	}
+}
+
+// Entries streams every live entry with its remaining TTL, skipping entries
+// that expire before they are sent. See DefCache.Entries for the
+// consistency caveats.
+func (t *TTLCache) Entries(ctx context.Context) <-chan Entry {
+	keys, lookup := t.entrySource()
+	return streamEntries(ctx, keys, lookup)
+}
+
+// RangeSnapshot calls fn for each live entry until it returns false,
+// skipping entries that expire before their turn and leaving renewal
+// alone. See DefCache.RangeSnapshot.
+func (t *TTLCache) RangeSnapshot(fn func(key string, value interface{}) bool) {
+	keys, lookup := t.entrySource()
+	rangeEntries(keys, lookup, fn)
+}
+
+func (t *TTLCache) entrySource() ([]string, entryLookup) {
+	t.mu.RLock()
+	keys := make([]string, 0, len(t.ttlEntries))
+	for key := range t.ttlEntries {
+		keys = append(keys, key)
+	}
+	t.mu.RUnlock()
+
+	return keys, func(key string) (Entry, bool) {
+		t.mu.RLock()
+		defer t.mu.RUnlock()
+
+		now := t.now()
+		entry, exists := t.ttlEntries[key]
+		if !exists || entry.expiredAt(now) {
+			return Entry{}, false
+		}
+		value, ok := peek(t.cache, key)
+		if !ok {
+			return Entry{}, false
+		}
+		return Entry{Key: key, Value: value, TTL: entry.remaining(now)}, true
+	}
+}
+
+// EntryInfo is a read-only snapshot of one entry and the metadata its cache
+// keeps for eviction. Fields a cache doesn't track are left at their zero
+// value, except TTL, which is NoExpiration for caches without expiry.
+type EntryInfo struct {
+	Value interface{}
+	// Frequency is the access count (LFU only).
+	Frequency int
+	// RecencyRank is the position from the most recently used end, 0 being
+	// the newest (LRU only).
+	RecencyRank int
+	// Pinned reports whether eviction skips the entry (LRU and LFU).
+	Pinned bool
+	// TTL is the time left before expiry, and ExpiresAt the zero time for
+	// entries that never expire (TTLCache only).
+	TTL       time.Duration
+	ExpiresAt time.Time
+}
+
+// GetEntry returns a snapshot of key. It counts as neither a hit nor a miss.
+func (d *DefCache) GetEntry(key string) (*EntryInfo, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	value, exists := d.data[key]
+	if !exists {
+		return nil, false
+	}
+	return &EntryInfo{Value: value, TTL: NoExpiration}, true
+}
+
+// GetEntry returns a snapshot of key, including its recency rank, without
+// moving it in the recency list.
+func (lru *LRUCache) GetEntry(key string) (*EntryInfo, bool) {
+	lru.mu.RLock()
+	defer lru.mu.RUnlock()
+
+	target, exists := lru.cache[key]
+	if !exists {
+		return nil, false
+	}
+
+	rank := 0
+	for node := lru.head.next; node != target; node = node.next {
+		rank++
+	}
+	return &EntryInfo{Value: target.value, RecencyRank: rank, Pinned: target.pinned, TTL: NoExpiration}, true
+}
+
+// GetEntry returns a snapshot of key, including its frequency, without
+// counting as an access.
+func (lfu *LFUCache) GetEntry(key string) (*EntryInfo, bool) {
+	lfu.mu.RLock()
+	defer lfu.mu.RUnlock()
+
+	node, exists := lfu.cache[key]
+	if !exists {
+		return nil, false
+	}
+	return &EntryInfo{Value: node.value, Frequency: node.freq, Pinned: node.pinned, TTL: NoExpiration}, true
+}
+
+// GetEntry returns a snapshot of a live key with its expiry. When the
+// underlying cache has GetEntry too, its frequency, rank and pin are
+// included. Neither cache's eviction order, hit counts or expiry change.
+func (t *TTLCache) GetEntry(key string) (*EntryInfo, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	now := t.now()
+	entry, exists := t.ttlEntries[key]
+	if !exists || entry.expiredAt(now) {
+		return nil, false
+	}
+
+	// The underlying cache may have evicted the key to make room.
+	var info *EntryInfo
+	if inspector, ok := t.cache.(interface {
+		GetEntry(key string) (*EntryInfo, bool)
+	}); ok {
+		if info, ok = inspector.GetEntry(key); !ok {
+			return nil, false
+		}
+	} else {
+		value, ok := peek(t.cache, key)
+		if !ok {
+			return nil, false
+		}
+		info = &EntryInfo{Value: value}
+	}
+	info.TTL = entry.remaining(now)
+	info.ExpiresAt = entry.ExpiresAt
+	return info, true
+}
@@ -0,0 +1,288 @@
+package littlecache
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestEntries_AllCaches(t *testing.T) {
+	config := Config{MaxSize: 50, EvictionPolicy: LRU}
+	def, _ := NewDefCache(config)
+	lru, _ := NewLRUCache(config)
+	lfu, _ := NewLFUCache(config)
+	ring, _ := NewRingCache(config)
+	ttl, _ := NewTTLCacheFromConfig(config, time.Minute)
+	defer ttl.Stop()
+
+	caches := map[string]interface {
+		LittleCache
+		Entries(ctx context.Context) <-chan Entry
+	}{
+		"def":  def,
+		"lru":  lru,
+		"lfu":  lfu,
+		"ring": ring,
+		"ttl":  ttl,
+	}
+
+	for name, cache := range caches {
+		for i := 0; i < 30; i++ {
+			cache.Set("key"+strconv.Itoa(i), i)
+		}
+
+		seen := make(map[string]int)
+		for e := range cache.Entries(context.Background()) {
+			seen[e.Key]++
+			if e.Key != "key"+strconv.Itoa(e.Value.(int)) {
+				t.Errorf("%s: entry pairs %s with value %v", name, e.Key, e.Value)
+			}
+		}
+
+		if len(seen) != 30 {
+			t.Errorf("%s: expected 30 distinct keys, got %d", name, len(seen))
+		}
+		for key, count := range seen {
+			if count != 1 {
+				t.Errorf("%s: expected %s once, got %d times", name, key, count)
+			}
+		}
+	}
+}
+
+func TestLRUCache_EntriesOrder(t *testing.T) {
+	config := Config{MaxSize: 3, EvictionPolicy: LRU}
+	cache, err := NewLRUCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.Set("c", 3)
+	cache.Get("a")
+
+	var keys []string
+	for e := range cache.Entries(context.Background()) {
+		if e.Rank != len(keys) {
+			t.Errorf("Expected rank %d for %s, got %d", len(keys), e.Key, e.Rank)
+		}
+		keys = append(keys, e.Key)
+	}
+	if len(keys) != 3 || keys[0] != "a" || keys[1] != "c" || keys[2] != "b" {
+		t.Errorf("Expected [a c b], got %v", keys)
+	}
+
+	// Streaming doesn't promote anything
+	if candidate, _ := cache.EvictionCandidate(); candidate != "b" {
+		t.Errorf("Expected eviction candidate b, got %s", candidate)
+	}
+}
+
+func TestEntries_DeletedMidStream(t *testing.T) {
+	cache, err := NewDefCache(Config{MaxSize: 10})
+	if err != nil {
+		t.Fatalf("Failed to create default cache: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		cache.Set("key"+strconv.Itoa(i), i)
+	}
+
+	// The lock isn't held between sends, so a writer can get in
+	count := 0
+	for range cache.Entries(context.Background()) {
+		if count == 0 {
+			cache.Clear()
+		}
+		count++
+	}
+	if count >= 10 {
+		t.Errorf("Expected entries cleared mid-stream to be skipped, got %d", count)
+	}
+}
+
+func TestEntries_StopEarly(t *testing.T) {
+	cache, err := NewLRUCache(Config{MaxSize: 100, EvictionPolicy: LRU})
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		cache.Set("key"+strconv.Itoa(i), i)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	entries := cache.Entries(ctx)
+	<-entries
+	cancel()
+
+	// At most the send already under way gets through before the channel
+	// closes
+	rest := 0
+	for range entries {
+		rest++
+	}
+	if rest > 1 {
+		t.Errorf("Expected the stream to stop once canceled, got %d more entries", rest)
+	}
+}
+
+func TestRangeSnapshot_ModifiedDuringRange(t *testing.T) {
+	config := Config{MaxSize: 100, EvictionPolicy: LRU}
+	def, _ := NewDefCache(config)
+	lru, _ := NewLRUCache(config)
+	lfu, _ := NewLFUCache(config)
+	ring, _ := NewRingCache(config)
+	ttl, _ := NewTTLCacheFromConfig(config, time.Minute)
+	defer ttl.Stop()
+
+	caches := map[string]interface {
+		LittleCache
+		RangeSnapshot(fn func(key string, value interface{}) bool)
+	}{
+		"def":  def,
+		"lru":  lru,
+		"lfu":  lfu,
+		"ring": ring,
+		"ttl":  ttl,
+	}
+
+	for name, cache := range caches {
+		for i := 0; i < 20; i++ {
+			cache.Set("key"+strconv.Itoa(i), i)
+		}
+
+		// A concurrent writer keeps going while fn runs, and fn itself
+		// writes; either would deadlock if the walk held the lock.
+		stop := make(chan struct{})
+		writerDone := make(chan struct{})
+		go func() {
+			defer close(writerDone)
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+					cache.Set("extra"+strconv.Itoa(i%5), i)
+				}
+			}
+		}()
+
+		seen := make(map[string]bool)
+		deleted, revisited := false, false
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			cache.RangeSnapshot(func(key string, value interface{}) bool {
+				seen[key] = true
+				revisited = revisited || (deleted && key == "key19")
+				if key == "key"+strconv.Itoa(value.(int)) {
+					cache.Delete("key19")
+					deleted = true
+					cache.Set(key, value.(int)+1000)
+				}
+				return true
+			})
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: RangeSnapshot deadlocked with writers", name)
+		}
+		close(stop)
+		<-writerDone
+
+		for i := 0; i < 19; i++ {
+			if key := "key" + strconv.Itoa(i); !seen[key] {
+				t.Errorf("%s: expected %s, present throughout, to be visited", name, key)
+			}
+		}
+		if revisited {
+			t.Errorf("%s: expected key19 to be skipped once deleted mid-range", name)
+		}
+
+		visits := 0
+		cache.RangeSnapshot(func(string, interface{}) bool {
+			visits++
+			return visits < 3
+		})
+		if visits != 3 {
+			t.Errorf("%s: expected fn returning false to stop after 3 visits, got %d", name, visits)
+		}
+	}
+}
+
+func TestGetEntry(t *testing.T) {
+	config := Config{MaxSize: 10}
+	def, _ := NewDefCache(config)
+	lru, _ := NewLRUCache(config)
+	lfu, _ := NewLFUCache(config)
+	underlying, _ := NewLRUCache(config)
+	ttl, err := NewTTLCache(TTLConfig{UnderlyingCache: underlying, DefaultTTL: time.Minute})
+	if err != nil {
+		t.Fatalf("Failed to create TTL cache: %v", err)
+	}
+	defer ttl.Stop()
+
+	caches := map[string]interface {
+		LittleCache
+		GetEntry(key string) (*EntryInfo, bool)
+	}{
+		"def": def,
+		"lru": lru,
+		"lfu": lfu,
+		"ttl": ttl,
+	}
+	for _, cache := range caches {
+		cache.Set("a", 1)
+		cache.Set("b", 2)
+		cache.Get("a")
+		cache.Set("c", 3)
+	}
+
+	// Repeated lookups must not change eviction order or frequencies
+	for i := 0; i < 3; i++ {
+		for name, cache := range caches {
+			info, ok := cache.GetEntry("a")
+			if !ok || info.Value != 1 {
+				t.Fatalf("%s: expected a with value 1, got %+v (ok=%v)", name, info, ok)
+			}
+			if _, ok := cache.GetEntry("missing"); ok {
+				t.Errorf("%s: expected no entry for a missing key", name)
+			}
+		}
+	}
+
+	if info, _ := def.GetEntry("a"); info.TTL != NoExpiration || info.Frequency != 0 || info.RecencyRank != 0 {
+		t.Errorf("Expected only the value and NoExpiration for DefCache, got %+v", info)
+	}
+	if info, _ := lru.GetEntry("a"); info.RecencyRank != 1 || info.Frequency != 0 {
+		t.Errorf("Expected recency rank 1 for LRU, got %+v", info)
+	}
+	if candidate, _ := lru.EvictionCandidate(); candidate != "b" {
+		t.Errorf("Expected b to remain the LRU eviction candidate, got %s", candidate)
+	}
+	if info, _ := lfu.GetEntry("a"); info.Frequency != 2 || info.RecencyRank != 0 {
+		t.Errorf("Expected frequency 2 for LFU, got %+v", info)
+	}
+	if info, _ := lfu.GetEntry("b"); info.Frequency != 1 {
+		t.Errorf("Expected frequency 1 for LFU, got %+v", info)
+	}
+
+	info, _ := ttl.GetEntry("a")
+	if info.TTL <= 0 || info.TTL > time.Minute || info.ExpiresAt.IsZero() {
+		t.Errorf("Expected a TTL within a minute, got %+v", info)
+	}
+	if info.RecencyRank != 1 {
+		t.Errorf("Expected the underlying recency rank 1, got %d", info.RecencyRank)
+	}
+	if entry := ttl.ttlEntries["a"]; entry.Hits != 0 {
+		t.Errorf("Expected GetEntry not to count hits, got %d", entry.Hits)
+	}
+
+	lru.SetPinned("a", 1)
+	if info, _ := lru.GetEntry("a"); !info.Pinned {
+		t.Errorf("Expected a to be reported as pinned")
+	}
+}
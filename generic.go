@@ -0,0 +1,613 @@
+package littlecache
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Cache is a type-safe cache keyed by any comparable type. It runs the same
+// LRU and LFU algorithms as LRUCache and LFUCache, but the LRU list, the LFU
+// freqMap buckets and the lookup map all key on K instead of string, so
+// composite keys such as structs can be used without stringifying them.
+//
+// Cache only honours Name, MaxSize, EvictionPolicy and MaxFrequency. Hooks,
+// admission, stats and the other Config options belong to the string-keyed
+// caches, and NewCache and Reconfigure reject a Config that sets any of them
+// with ErrUnsupportedConfig instead of ignoring it.
+type Cache[K comparable, V any] struct {
+	config Config
+	policy policy[K, V]
+	mu     sync.RWMutex
+}
+
+// policy is the unsynchronized eviction algorithm behind a Cache.
+type policy[K comparable, V any] interface {
+	set(key K, value V)
+	get(key K) (V, bool)
+	delete(key K)
+	clear()
+	size() int
+	resize(newSize int)
+	evictionCandidate() (K, bool)
+	// entries lists every entry from the next to be evicted to the last.
+	entries() []policyEntry[K, V]
+	// insert adds an entry taken from another policy, evicting as set does.
+	insert(e policyEntry[K, V])
+}
+
+// policyEntry carries an entry between policies. freq is only set by LFU.
+type policyEntry[K comparable, V any] struct {
+	key   K
+	value V
+	freq  int
+}
+
+// NewCache creates a generic cache using the NoEviction, LRU or LFU policy
+// from config.
+func NewCache[K comparable, V any](config Config) (*Cache[K, V], error) {
+	if err := config.Validate(); err != nil {
+		return nil, config.error("new", err)
+	}
+	if err := genericSupports(config); err != nil {
+		return nil, config.error("new", err)
+	}
+
+	p, err := newPolicy[K, V](config)
+	if err != nil {
+		return nil, config.error("new", err)
+	}
+	return &Cache[K, V]{config: config, policy: p}, nil
+}
+
+// genericSupports reports the first Config field set that Cache would
+// otherwise drop on the floor.
+func genericSupports(config Config) error {
+	unsupported := []struct {
+		name string
+		set  bool
+	}{
+		{"MaxWeight", config.MaxWeight != 0},
+		{"Compressor", config.Compressor != nil},
+		{"CompressThreshold", config.CompressThreshold != 0},
+		{"Cipher", config.Cipher != nil},
+		{"CopyByteValues", config.CopyByteValues},
+		{"CopyByteValuesOnGet", config.CopyByteValuesOnGet},
+		{"Disabled", config.Disabled},
+		{"Clock", config.Clock != nil},
+		{"MaxConcurrentLoads", config.MaxConcurrentLoads != 0},
+		{"BreakerThreshold", config.BreakerThreshold != 0},
+		{"BreakerWindow", config.BreakerWindow != 0},
+		{"BreakerCooldown", config.BreakerCooldown != 0},
+		{"ImmutableKeys", config.ImmutableKeys},
+		{"SkipEqualWrites", config.SkipEqualWrites},
+		{"Equal", config.Equal != nil},
+		{"OnClear", config.OnClear != nil},
+		{"OnEvict", config.OnEvict != nil},
+		{"EvictionHistory", config.EvictionHistory != 0},
+		{"NoPromoteOnGet", config.NoPromoteOnGet},
+		{"TrackAccessTime", config.TrackAccessTime},
+		{"TrackAccessCounts", config.TrackAccessCounts},
+		{"TrackLockWait", config.TrackLockWait},
+		{"Unsynchronized", config.Unsynchronized},
+		{"AutoTune", config.AutoTune != AutoTuneConfig{}},
+		{"MaxFrequencyBuckets", config.MaxFrequencyBuckets != 0},
+		{"SampleSize", config.SampleSize != 0},
+		{"RandomSeed", config.RandomSeed != 0},
+		{"ShardHasher", config.ShardHasher != nil},
+		{"Admit", config.Admit != nil},
+		{"MaxValueBytes", config.MaxValueBytes != 0},
+		{"SizeOf", config.SizeOf != nil},
+		{"PreallocFraction", config.PreallocFraction != 0},
+		{"CallbackTimeout", config.CallbackTimeout != 0},
+		{"OnCallbackPanic", config.OnCallbackPanic != nil},
+	}
+	for _, field := range unsupported {
+		if field.set {
+			return fmt.Errorf("%w: %s", ErrUnsupportedConfig, field.name)
+		}
+	}
+	return nil
+}
+
+func newPolicy[K comparable, V any](config Config) (policy[K, V], error) {
+	switch config.EvictionPolicy {
+	case NoEviction:
+		return newMapPolicy[K, V](config.MaxSize), nil
+	case LRU:
+		return newLRUPolicy[K, V](config.MaxSize), nil
+	case LFU:
+		return newLFUPolicy[K, V](config.MaxSize, maxFrequency(config)), nil
+	default:
+		return nil, ErrInvalidEvictionPolicy
+	}
+}
+
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.policy.set(key, value)
+}
+
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	// LRU and LFU reorder entries on read, so Get needs the write lock.
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.policy.get(key)
+}
+
+// Name returns Config.Name.
+func (c *Cache[K, V]) Name() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.config.Name
+}
+
+// GetOrDefault returns the value for key, or def on a miss. Either way it
+// counts as a Get, and def is not stored.
+func (c *Cache[K, V]) GetOrDefault(key K, def V) V {
+	if value, ok := c.Get(key); ok {
+		return value
+	}
+	return def
+}
+
+// LoadOrStore returns the existing value for key if present, counting the
+// hit as an access. Otherwise it stores value and returns it. The loaded
+// result is true if the value was loaded, false if stored.
+func (c *Cache[K, V]) LoadOrStore(key K, value V) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if existing, exists := c.policy.get(key); exists {
+		return existing, true
+	}
+
+	c.policy.set(key, value)
+	return value, false
+}
+
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.policy.delete(key)
+}
+
+func (c *Cache[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.policy.clear()
+}
+
+func (c *Cache[K, V]) Size() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.policy.size()
+}
+
+func (c *Cache[K, V]) Resize(newSize int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := checkSize(newSize); err != nil {
+		return c.config.error("resize", err)
+	}
+
+	c.policy.resize(newSize)
+	c.config.MaxSize = newSize
+	return nil
+}
+
+// Reconfigure applies a new config in place. If only MaxSize changes, it
+// acts like Resize. Otherwise the entries move to a freshly built policy,
+// coldest first by the old policy's order, so when they don't all fit the
+// new policy evicts the coldest and keeps the hottest. LFU frequencies carry
+// over when both policies are LFU; entries coming from LRU or NoEviction
+// start at frequency 1, ordered by recency.
+func (c *Cache[K, V]) Reconfigure(config Config) error {
+	if err := config.Validate(); err != nil {
+		return c.config.error("reconfigure", err)
+	}
+	if err := genericSupports(config); err != nil {
+		return c.config.error("reconfigure", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if config.EvictionPolicy == c.config.EvictionPolicy && maxFrequency(config) == maxFrequency(c.config) {
+		c.policy.resize(config.MaxSize)
+		c.config = config
+		return nil
+	}
+
+	p, err := newPolicy[K, V](config)
+	if err != nil {
+		return c.config.error("reconfigure", err)
+	}
+	for _, e := range c.policy.entries() {
+		p.insert(e)
+	}
+	c.policy = p
+	c.config = config
+	return nil
+}
+
+// EvictionCandidate returns the key that the next overflowing Set would
+// evict, without evicting it.
+func (c *Cache[K, V]) EvictionCandidate() (K, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.policy.evictionCandidate()
+}
+
+type mapPolicy[K comparable, V any] struct {
+	maxSize int
+	data    map[K]V
+}
+
+func newMapPolicy[K comparable, V any](maxSize int) *mapPolicy[K, V] {
+	return &mapPolicy[K, V]{maxSize: maxSize, data: make(map[K]V)}
+}
+
+func (m *mapPolicy[K, V]) set(key K, value V) {
+	if _, exists := m.data[key]; !exists && len(m.data) >= m.maxSize {
+		return
+	}
+	m.data[key] = value
+}
+
+func (m *mapPolicy[K, V]) get(key K) (V, bool) {
+	value, exists := m.data[key]
+	return value, exists
+}
+
+func (m *mapPolicy[K, V]) delete(key K) {
+	delete(m.data, key)
+}
+
+func (m *mapPolicy[K, V]) clear() {
+	m.data = make(map[K]V)
+}
+
+func (m *mapPolicy[K, V]) size() int {
+	return len(m.data)
+}
+
+func (m *mapPolicy[K, V]) resize(newSize int) {
+	m.maxSize = newSize
+}
+
+func (m *mapPolicy[K, V]) evictionCandidate() (K, bool) {
+	var zero K
+	return zero, false
+}
+
+// entries lists the map in arbitrary order; NoEviction has no notion of
+// which entry is coldest.
+func (m *mapPolicy[K, V]) entries() []policyEntry[K, V] {
+	entries := make([]policyEntry[K, V], 0, len(m.data))
+	for key, value := range m.data {
+		entries = append(entries, policyEntry[K, V]{key: key, value: value})
+	}
+	return entries
+}
+
+func (m *mapPolicy[K, V]) insert(e policyEntry[K, V]) {
+	m.set(e.key, e.value)
+}
+
+type lruEntry[K comparable, V any] struct {
+	key   K
+	value V
+	prev  *lruEntry[K, V]
+	next  *lruEntry[K, V]
+}
+
+type lruPolicy[K comparable, V any] struct {
+	maxSize int
+	cache   map[K]*lruEntry[K, V]
+	head    *lruEntry[K, V]
+	tail    *lruEntry[K, V]
+}
+
+func newLRUPolicy[K comparable, V any](maxSize int) *lruPolicy[K, V] {
+	head := &lruEntry[K, V]{}
+	tail := &lruEntry[K, V]{}
+	head.next = tail
+	tail.prev = head
+
+	return &lruPolicy[K, V]{
+		maxSize: maxSize,
+		cache:   make(map[K]*lruEntry[K, V]),
+		head:    head,
+		tail:    tail,
+	}
+}
+
+func (l *lruPolicy[K, V]) addNode(node *lruEntry[K, V]) {
+	node.prev = l.head
+	node.next = l.head.next
+	l.head.next.prev = node
+	l.head.next = node
+}
+
+func (l *lruPolicy[K, V]) removeNode(node *lruEntry[K, V]) {
+	node.prev.next = node.next
+	node.next.prev = node.prev
+}
+
+func (l *lruPolicy[K, V]) set(key K, value V) {
+	if node, exists := l.cache[key]; exists {
+		node.value = value
+		l.removeNode(node)
+		l.addNode(node)
+		return
+	}
+
+	node := &lruEntry[K, V]{key: key, value: value}
+	l.cache[key] = node
+	l.addNode(node)
+
+	for len(l.cache) > l.maxSize {
+		last := l.tail.prev
+		l.removeNode(last)
+		delete(l.cache, last.key)
+	}
+}
+
+func (l *lruPolicy[K, V]) get(key K) (V, bool) {
+	node, exists := l.cache[key]
+	if !exists {
+		var zero V
+		return zero, false
+	}
+
+	l.removeNode(node)
+	l.addNode(node)
+	return node.value, true
+}
+
+func (l *lruPolicy[K, V]) delete(key K) {
+	if node, exists := l.cache[key]; exists {
+		l.removeNode(node)
+		delete(l.cache, key)
+	}
+}
+
+func (l *lruPolicy[K, V]) clear() {
+	l.cache = make(map[K]*lruEntry[K, V])
+	l.head.next = l.tail
+	l.tail.prev = l.head
+}
+
+func (l *lruPolicy[K, V]) size() int {
+	return len(l.cache)
+}
+
+func (l *lruPolicy[K, V]) resize(newSize int) {
+	l.maxSize = newSize
+	for len(l.cache) > l.maxSize {
+		last := l.tail.prev
+		l.removeNode(last)
+		delete(l.cache, last.key)
+	}
+}
+
+func (l *lruPolicy[K, V]) evictionCandidate() (K, bool) {
+	if len(l.cache) == 0 {
+		var zero K
+		return zero, false
+	}
+	return l.tail.prev.key, true
+}
+
+func (l *lruPolicy[K, V]) entries() []policyEntry[K, V] {
+	entries := make([]policyEntry[K, V], 0, len(l.cache))
+	for node := l.tail.prev; node != l.head; node = node.prev {
+		entries = append(entries, policyEntry[K, V]{key: node.key, value: node.value})
+	}
+	return entries
+}
+
+func (l *lruPolicy[K, V]) insert(e policyEntry[K, V]) {
+	l.set(e.key, e.value)
+}
+
+type lfuEntry[K comparable, V any] struct {
+	key   K
+	value V
+	freq  int
+	prev  *lfuEntry[K, V]
+	next  *lfuEntry[K, V]
+}
+
+type lfuPolicy[K comparable, V any] struct {
+	maxSize int
+	maxFreq int
+	cache   map[K]*lfuEntry[K, V]
+	freqMap map[int]*lfuEntry[K, V] // frequency -> head of doubly linked list
+	minFreq int
+}
+
+func newLFUPolicy[K comparable, V any](maxSize, maxFreq int) *lfuPolicy[K, V] {
+	return &lfuPolicy[K, V]{
+		maxSize: maxSize,
+		maxFreq: maxFreq,
+		cache:   make(map[K]*lfuEntry[K, V]),
+		freqMap: make(map[int]*lfuEntry[K, V]),
+	}
+}
+
+func (l *lfuPolicy[K, V]) addNode(node *lfuEntry[K, V], freq int) {
+	head, exists := l.freqMap[freq]
+	if !exists {
+		head = &lfuEntry[K, V]{}
+		head.next = head
+		head.prev = head
+		l.freqMap[freq] = head
+	}
+
+	node.next = head.next
+	node.prev = head
+	head.next.prev = node
+	head.next = node
+}
+
+func (l *lfuPolicy[K, V]) removeNode(node *lfuEntry[K, V]) {
+	node.prev.next = node.next
+	node.next.prev = node.prev
+
+	head := l.freqMap[node.freq]
+	if head.next == head {
+		delete(l.freqMap, node.freq)
+	}
+}
+
+func (l *lfuPolicy[K, V]) updateFreq(node *lfuEntry[K, V]) {
+	l.removeNode(node)
+	if node.freq >= l.maxFreq {
+		l.addNode(node, node.freq)
+		return
+	}
+	if _, exists := l.freqMap[node.freq]; !exists && l.minFreq == node.freq {
+		l.minFreq++
+	}
+
+	node.freq++
+	l.addNode(node, node.freq)
+}
+
+func (l *lfuPolicy[K, V]) evict() {
+	head := l.freqMap[l.minFreq]
+	last := head.prev
+	l.removeNode(last)
+	delete(l.cache, last.key)
+	l.resetMinFreq()
+}
+
+// resetMinFreq recomputes minFreq after the lowest bucket may have emptied.
+func (l *lfuPolicy[K, V]) resetMinFreq() {
+	if _, exists := l.freqMap[l.minFreq]; exists || len(l.cache) == 0 {
+		return
+	}
+
+	l.minFreq = 0
+	for freq := range l.freqMap {
+		if l.minFreq == 0 || freq < l.minFreq {
+			l.minFreq = freq
+		}
+	}
+}
+
+func (l *lfuPolicy[K, V]) set(key K, value V) {
+	if node, exists := l.cache[key]; exists {
+		node.value = value
+		l.updateFreq(node)
+		return
+	}
+
+	if len(l.cache) >= l.maxSize {
+		l.evict()
+	}
+
+	node := &lfuEntry[K, V]{key: key, value: value, freq: 1}
+	l.cache[key] = node
+	l.addNode(node, 1)
+	l.minFreq = 1
+}
+
+func (l *lfuPolicy[K, V]) get(key K) (V, bool) {
+	node, exists := l.cache[key]
+	if !exists {
+		var zero V
+		return zero, false
+	}
+
+	l.updateFreq(node)
+	return node.value, true
+}
+
+func (l *lfuPolicy[K, V]) delete(key K) {
+	node, exists := l.cache[key]
+	if !exists {
+		return
+	}
+
+	l.removeNode(node)
+	delete(l.cache, key)
+	l.resetMinFreq()
+}
+
+func (l *lfuPolicy[K, V]) clear() {
+	l.cache = make(map[K]*lfuEntry[K, V])
+	l.freqMap = make(map[int]*lfuEntry[K, V])
+	l.minFreq = 0
+}
+
+func (l *lfuPolicy[K, V]) size() int {
+	return len(l.cache)
+}
+
+func (l *lfuPolicy[K, V]) resize(newSize int) {
+	l.maxSize = newSize
+	for len(l.cache) > l.maxSize {
+		l.evict()
+	}
+}
+
+func (l *lfuPolicy[K, V]) evictionCandidate() (K, bool) {
+	head, exists := l.freqMap[l.minFreq]
+	if !exists {
+		var zero K
+		return zero, false
+	}
+	return head.prev.key, true
+}
+
+// entries lists the lowest frequency first and, within a bucket, the least
+// recently touched first.
+func (l *lfuPolicy[K, V]) entries() []policyEntry[K, V] {
+	freqs := make([]int, 0, len(l.freqMap))
+	for freq := range l.freqMap {
+		freqs = append(freqs, freq)
+	}
+	sort.Ints(freqs)
+
+	entries := make([]policyEntry[K, V], 0, len(l.cache))
+	for _, freq := range freqs {
+		head := l.freqMap[freq]
+		for node := head.prev; node != head; node = node.prev {
+			entries = append(entries, policyEntry[K, V]{key: node.key, value: node.value, freq: node.freq})
+		}
+	}
+	return entries
+}
+
+// insert places the entry at its carried frequency, clamped to
+// [1, maxFreq]. Entries arrive coldest first, so evicting the current
+// minimum never drops something hotter than the newcomer.
+func (l *lfuPolicy[K, V]) insert(e policyEntry[K, V]) {
+	if _, exists := l.cache[e.key]; exists {
+		l.set(e.key, e.value)
+		return
+	}
+	if len(l.cache) >= l.maxSize {
+		l.evict()
+	}
+
+	freq := min(max(e.freq, 1), l.maxFreq)
+	node := &lfuEntry[K, V]{key: e.key, value: e.value, freq: freq}
+	l.cache[e.key] = node
+	l.addNode(node, freq)
+	if len(l.cache) == 1 || freq < l.minFreq {
+		l.minFreq = freq
+	}
+}
@@ -0,0 +1,320 @@
+package littlecache
+
+import (
+	"errors"
+	"testing"
+)
+
+type regionKey struct {
+	UserID int
+	Region string
+}
+
+func TestCache_StructKey(t *testing.T) {
+	config := Config{MaxSize: 2, EvictionPolicy: LRU}
+	cache, err := NewCache[regionKey, string](config)
+	if err != nil {
+		t.Fatalf("Failed to create generic cache: %v", err)
+	}
+
+	// Keys that would collide if naively stringified as UserID+Region
+	cache.Set(regionKey{UserID: 1, Region: "2eu"}, "first")
+	cache.Set(regionKey{UserID: 12, Region: "eu"}, "second")
+
+	value, exists := cache.Get(regionKey{UserID: 1, Region: "2eu"})
+	if !exists || value != "first" {
+		t.Errorf("Expected first, got %v (exists=%v)", value, exists)
+	}
+	value, exists = cache.Get(regionKey{UserID: 12, Region: "eu"})
+	if !exists || value != "second" {
+		t.Errorf("Expected second, got %v (exists=%v)", value, exists)
+	}
+
+	// {1, 2eu} is now the least recently used and gets evicted
+	cache.Set(regionKey{UserID: 3, Region: "us"}, "third")
+	if _, exists := cache.Get(regionKey{UserID: 1, Region: "2eu"}); exists {
+		t.Errorf("Expected {1 2eu} to be evicted")
+	}
+	if cache.Size() != 2 {
+		t.Errorf("Expected size 2, got %d", cache.Size())
+	}
+}
+
+func TestCache_IntKeyLFU(t *testing.T) {
+	config := Config{MaxSize: 3, EvictionPolicy: LFU}
+	cache, err := NewCache[int, int](config)
+	if err != nil {
+		t.Fatalf("Failed to create generic cache: %v", err)
+	}
+
+	cache.Set(1, 10)
+	cache.Set(2, 20)
+	cache.Set(3, 30)
+	cache.Get(1)
+	cache.Get(1)
+	cache.Get(3)
+
+	candidate, ok := cache.EvictionCandidate()
+	if !ok || candidate != 2 {
+		t.Fatalf("Expected eviction candidate 2, got %d (ok=%v)", candidate, ok)
+	}
+
+	cache.Set(4, 40)
+	if _, exists := cache.Get(2); exists {
+		t.Errorf("Expected key 2 to be evicted")
+	}
+	for _, key := range []int{1, 3, 4} {
+		if value, exists := cache.Get(key); !exists || value != key*10 {
+			t.Errorf("Expected %d for key %d, got %d (exists=%v)", key*10, key, value, exists)
+		}
+	}
+}
+
+func TestCache_IntKeyLRU(t *testing.T) {
+	config := Config{MaxSize: 3, EvictionPolicy: LRU}
+	cache, err := NewCache[int, string](config)
+	if err != nil {
+		t.Fatalf("Failed to create generic cache: %v", err)
+	}
+
+	cache.Set(1, "one")
+	cache.Set(2, "two")
+	cache.Set(3, "three")
+	cache.Get(1)
+
+	cache.Set(4, "four")
+	if _, exists := cache.Get(2); exists {
+		t.Errorf("Expected key 2 to be evicted")
+	}
+
+	cache.Delete(3)
+	if cache.Size() != 2 {
+		t.Errorf("Expected size 2 after delete, got %d", cache.Size())
+	}
+
+	if err := cache.Resize(1); err != nil {
+		t.Fatalf("Unexpected error during resize: %v", err)
+	}
+	if _, exists := cache.Get(4); !exists {
+		t.Errorf("Expected most recently used key 4 to survive resize")
+	}
+	if cache.Size() != 1 {
+		t.Errorf("Expected size 1 after resize, got %d", cache.Size())
+	}
+
+	if err := cache.Resize(0); err == nil {
+		t.Errorf("Expected error for invalid resize")
+	}
+
+	cache.Clear()
+	if cache.Size() != 0 {
+		t.Errorf("Expected size 0 after clear, got %d", cache.Size())
+	}
+}
+
+func TestCache_NoEviction(t *testing.T) {
+	config := Config{MaxSize: 1, EvictionPolicy: NoEviction}
+	cache, err := NewCache[int, string](config)
+	if err != nil {
+		t.Fatalf("Failed to create generic cache: %v", err)
+	}
+
+	cache.Set(1, "one")
+	cache.Set(2, "two")
+	if _, exists := cache.Get(2); exists {
+		t.Errorf("Expected key 2 to be rejected when full")
+	}
+
+	cache.Set(1, "uno")
+	if value, _ := cache.Get(1); value != "uno" {
+		t.Errorf("Expected existing key to be updated, got %s", value)
+	}
+}
+
+func TestCache_InvalidPolicy(t *testing.T) {
+	_, err := NewCache[int, int](Config{MaxSize: 1, EvictionPolicy: TTL})
+	if !errors.Is(err, ErrInvalidEvictionPolicy) {
+		t.Errorf("Expected ErrInvalidEvictionPolicy, got %v", err)
+	}
+}
+
+func TestCache_UnsupportedConfig(t *testing.T) {
+	unsupported := map[string]Config{
+		"Admit":         {MaxSize: 2, EvictionPolicy: LRU, Admit: func(string, interface{}, int, int) bool { return true }},
+		"OnEvict":       {MaxSize: 2, EvictionPolicy: LRU, OnEvict: func(string, interface{}, EvictionReason) {}},
+		"ImmutableKeys": {MaxSize: 2, EvictionPolicy: LFU, ImmutableKeys: true},
+		"TrackLockWait": {MaxSize: 2, EvictionPolicy: NoEviction, TrackLockWait: true},
+	}
+	for name, config := range unsupported {
+		_, err := NewCache[int, int](config)
+		if !errors.Is(err, ErrUnsupportedConfig) {
+			t.Errorf("%s: Expected ErrUnsupportedConfig, got %v", name, err)
+		}
+	}
+
+	cache, err := NewCache[int, int](Config{Name: "ids", MaxSize: 2, EvictionPolicy: LFU, MaxFrequency: 5})
+	if err != nil {
+		t.Fatalf("Failed to create generic cache: %v", err)
+	}
+	cache.Set(1, 1)
+	err = cache.Reconfigure(Config{MaxSize: 2, EvictionPolicy: LRU, EvictionHistory: 4})
+	if !errors.Is(err, ErrUnsupportedConfig) {
+		t.Errorf("Expected ErrUnsupportedConfig, got %v", err)
+	}
+	if _, ok := cache.Get(1); !ok {
+		t.Errorf("Expected a rejected config to leave the cache alone")
+	}
+}
+
+func TestCache_LoadOrStore(t *testing.T) {
+	cache, err := NewCache[int, string](Config{MaxSize: 2, EvictionPolicy: LRU})
+	if err != nil {
+		t.Fatalf("Failed to create generic cache: %v", err)
+	}
+
+	if actual, loaded := cache.LoadOrStore(1, "one"); loaded || actual != "one" {
+		t.Errorf("Expected (one, false), got (%s, %v)", actual, loaded)
+	}
+	if actual, loaded := cache.LoadOrStore(1, "uno"); !loaded || actual != "one" {
+		t.Errorf("Expected (one, true), got (%s, %v)", actual, loaded)
+	}
+}
+
+func TestCache_GetOrDefault(t *testing.T) {
+	cache, err := NewCache[int, string](Config{MaxSize: 2, EvictionPolicy: LRU})
+	if err != nil {
+		t.Fatalf("Failed to create generic cache: %v", err)
+	}
+	cache.Set(1, "one")
+	cache.Set(2, "two")
+
+	if value := cache.GetOrDefault(1, "none"); value != "one" {
+		t.Errorf("Expected one, got %s", value)
+	}
+	if value := cache.GetOrDefault(3, "none"); value != "none" {
+		t.Errorf("Expected none, got %s", value)
+	}
+	if cache.Size() != 2 {
+		t.Errorf("Expected the default not to be stored, got size %d", cache.Size())
+	}
+
+	// The hit on 1 counted as an access, so 2 is evicted first
+	cache.Set(3, "three")
+	if _, ok := cache.Get(2); ok {
+		t.Errorf("Expected 2 to be evicted")
+	}
+	if _, ok := cache.Get(1); !ok {
+		t.Errorf("Expected 1 to survive")
+	}
+}
+
+func TestCache_LFUMaxFrequency(t *testing.T) {
+	cache, err := NewCache[int, int](Config{MaxSize: 2, EvictionPolicy: LFU, MaxFrequency: 5})
+	if err != nil {
+		t.Fatalf("Failed to create generic cache: %v", err)
+	}
+
+	cache.Set(1, 1)
+	for i := 0; i < 100; i++ {
+		cache.Get(1)
+	}
+
+	lfu := cache.policy.(*lfuPolicy[int, int])
+	if freq := lfu.cache[1].freq; freq != 5 {
+		t.Errorf("Expected frequency to plateau at 5, got %d", freq)
+	}
+	if len(lfu.freqMap) != 1 {
+		t.Errorf("Expected a single bucket, got %d", len(lfu.freqMap))
+	}
+}
+
+func TestCache_ReconfigureLRUToLFU(t *testing.T) {
+	cache, err := NewCache[int, string](Config{MaxSize: 5, EvictionPolicy: LRU})
+	if err != nil {
+		t.Fatalf("Failed to create generic cache: %v", err)
+	}
+
+	for i := 1; i <= 5; i++ {
+		cache.Set(i, "value")
+	}
+	// 4 and 2 are the most recently used
+	cache.Get(4)
+	cache.Get(2)
+
+	if err := cache.Reconfigure(Config{MaxSize: 3, EvictionPolicy: LFU}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cache.Size() != 3 {
+		t.Errorf("Expected size 3, got %d", cache.Size())
+	}
+	for _, key := range []int{5, 4, 2} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("Expected hot key %d to survive the switch", key)
+		}
+	}
+
+	// The cache now evicts by frequency: 5, 4 and 2 have been read once
+	// more, so the new key is the victim of the next insert
+	cache.Set(6, "value")
+	cache.Set(7, "value")
+	if _, ok := cache.Get(6); ok {
+		t.Errorf("Expected 6 to be evicted by LFU")
+	}
+}
+
+func TestCache_ReconfigureLFUToLRU(t *testing.T) {
+	cache, err := NewCache[int, string](Config{MaxSize: 4, EvictionPolicy: LFU})
+	if err != nil {
+		t.Fatalf("Failed to create generic cache: %v", err)
+	}
+
+	for i := 1; i <= 4; i++ {
+		cache.Set(i, "value")
+	}
+	for i := 0; i < 3; i++ {
+		cache.Get(1)
+		cache.Get(3)
+	}
+
+	if err := cache.Reconfigure(Config{MaxSize: 2, EvictionPolicy: LRU}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, key := range []int{1, 3} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("Expected frequent key %d to survive the switch", key)
+		}
+	}
+	if candidate, _ := cache.EvictionCandidate(); candidate != 1 {
+		t.Errorf("Expected 1 to be least recently used, got %d", candidate)
+	}
+}
+
+func TestCache_ReconfigureSamePolicy(t *testing.T) {
+	cache, err := NewCache[int, string](Config{MaxSize: 2, EvictionPolicy: LFU})
+	if err != nil {
+		t.Fatalf("Failed to create generic cache: %v", err)
+	}
+	cache.Set(1, "value")
+	cache.Get(1)
+	cache.Set(2, "value")
+
+	if err := cache.Reconfigure(Config{MaxSize: 3, EvictionPolicy: LFU}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	cache.Set(3, "value")
+	cache.Set(4, "value")
+	if cache.Size() != 3 {
+		t.Errorf("Expected size 3, got %d", cache.Size())
+	}
+	if _, ok := cache.Get(1); !ok {
+		t.Errorf("Expected 1 to keep its frequency")
+	}
+
+	err = cache.Reconfigure(Config{MaxSize: 0, EvictionPolicy: LRU})
+	if !errors.Is(err, ErrInvalidMaxSize) {
+		t.Errorf("Expected ErrInvalidMaxSize, got %v", err)
+	}
+	if cache.Size() != 3 {
+		t.Errorf("Expected an invalid config to leave the cache alone, got size %d", cache.Size())
+	}
+}
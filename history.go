@@ -0,0 +1,120 @@
+package littlecache
+
+import (
+	"sync"
+	"time"
+)
+
+// EvictionRecord describes one entry the cache removed on its own.
+type EvictionRecord struct {
+	Key    string
+	Time   time.Time
+	Reason EvictionReason
+}
+
+// historyRing keeps the last few eviction records, overwriting the oldest.
+// It has its own lock because the shards of a ShardedCache share one.
+type historyRing struct {
+	clock   Clock
+	mu      sync.Mutex
+	records []EvictionRecord
+	next    int
+	full    bool
+}
+
+func newHistoryRing(size int, clock Clock) *historyRing {
+	return &historyRing{
+		clock:   clockOrDefault(clock),
+		records: make([]EvictionRecord, size),
+	}
+}
+
+// observe records key if reason is one the cache chose: capacity evictions
+// and expiries. Deletes, clears and overwrites are the caller's doing. A
+// nil ring records nothing.
+func (h *historyRing) observe(key string, reason EvictionReason) {
+	if h == nil || (reason != CapacityEviction && reason != Expired) {
+		return
+	}
+
+	now := h.clock.Now()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.records[h.next] = EvictionRecord{Key: key, Time: now, Reason: reason}
+	h.next++
+	if h.next == len(h.records) {
+		h.next = 0
+		h.full = true
+	}
+}
+
+// snapshot returns the records from oldest to newest, or nil for a nil ring.
+func (h *historyRing) snapshot() []EvictionRecord {
+	if h == nil {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.full {
+		return append([]EvictionRecord(nil), h.records[:h.next]...)
+	}
+	records := make([]EvictionRecord, 0, len(h.records))
+	records = append(records, h.records[h.next:]...)
+	return append(records, h.records[:h.next]...)
+}
+
+// reset forgets every record. A nil ring has nothing to forget.
+func (h *historyRing) reset() {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	clear(h.records)
+	h.next = 0
+	h.full = false
+}
+
+// RecentEvictions returns the last Config.EvictionHistory capacity
+// evictions, oldest first, or nil when the history is off.
+func (s *cacheStats) RecentEvictions() []EvictionRecord {
+	return s.history.snapshot()
+}
+
+// RecentEvictions returns the last Config.EvictionHistory capacity
+// evictions and expiries, oldest first, or nil when the history is off.
+func (c *LRUTTLCache) RecentEvictions() []EvictionRecord {
+	return c.lru.config.history.snapshot()
+}
+
+// RecentEvictions returns the last Config.EvictionHistory evictions across
+// all shards, oldest first, or nil when the history is off.
+func (s *ShardedCache) RecentEvictions() []EvictionRecord {
+	return s.config.history.snapshot()
+}
+
+// RecentEvictions returns the underlying cache's eviction history, which
+// the TTL layer adds its expiries to, or nil when it keeps none.
+func (t *TTLCache) RecentEvictions() []EvictionRecord {
+	return t.history.snapshot()
+}
+
+// RecentEvictions returns the wrapped cache's eviction history, if any.
+func (c *codecCache) RecentEvictions() []EvictionRecord {
+	if recorder, ok := c.cache.(interface{ RecentEvictions() []EvictionRecord }); ok {
+		return recorder.RecentEvictions()
+	}
+	return nil
+}
+
+// historyOf returns the eviction history c records into, or nil.
+func historyOf(c LittleCache) *historyRing {
+	if config := configOf(c); config != nil {
+		return config.history
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package littlecache
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRecentEvictions(t *testing.T) {
+	clock := newManualClock()
+	start := clock.Now()
+	cache, err := NewLRUCache(Config{MaxSize: 3, EvictionPolicy: LRU, EvictionHistory: 4, Clock: clock})
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		cache.Set("key"+strconv.Itoa(i), i)
+		clock.Advance(time.Second)
+	}
+	// Deletes are the caller's doing and stay out of the history
+	cache.Delete("key9")
+
+	records := cache.RecentEvictions()
+	if len(records) != 4 {
+		t.Fatalf("Expected 4 records, got %d: %v", len(records), records)
+	}
+	// key0 to key6 were evicted by the Sets of key3 to key9
+	for i, record := range records {
+		wantKey := "key" + strconv.Itoa(i+3)
+		wantTime := start.Add(time.Duration(i+6) * time.Second)
+		if record.Key != wantKey || !record.Time.Equal(wantTime) || record.Reason != CapacityEviction {
+			t.Errorf("Record %d: expected %s at %v for capacity, got %s at %v for %v",
+				i, wantKey, wantTime, record.Key, record.Time, record.Reason)
+		}
+	}
+}
+
+func TestRecentEvictions_Disabled(t *testing.T) {
+	cache, err := NewLFUCache(Config{MaxSize: 1, EvictionPolicy: LFU})
+	if err != nil {
+		t.Fatalf("Failed to create LFU cache: %v", err)
+	}
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+
+	if records := cache.RecentEvictions(); records != nil {
+		t.Errorf("Expected no history, got %v", records)
+	}
+
+	if _, err := NewLFUCache(Config{MaxSize: 1, EvictionHistory: -1}); !errors.Is(err, ErrInvalidEvictionHistory) {
+		t.Errorf("Expected ErrInvalidEvictionHistory, got %v", err)
+	}
+}
+
+func TestRecentEvictions_TTLExpiry(t *testing.T) {
+	clock := newManualClock()
+	ttlCache, err := NewTTLCacheFromConfig(Config{MaxSize: 2, EvictionPolicy: TTL, EvictionHistory: 8, Clock: clock}, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create TTL cache: %v", err)
+	}
+	defer ttlCache.Stop()
+
+	ttlCache.Set("a", 1)
+	ttlCache.Set("b", 2)
+	ttlCache.Set("c", 3)
+	clock.Advance(2 * time.Minute)
+	ttlCache.cleanup()
+
+	records := ttlCache.RecentEvictions()
+	if len(records) != 3 {
+		t.Fatalf("Expected 3 records, got %v", records)
+	}
+	if records[0].Key != "a" || records[0].Reason != CapacityEviction {
+		t.Errorf("Expected a evicted for capacity first, got %v", records[0])
+	}
+	for _, record := range records[1:] {
+		if record.Reason != Expired {
+			t.Errorf("Expected %s to be recorded as expired, got %v", record.Key, record.Reason)
+		}
+	}
+}
+
+func TestRecentEvictions_ShardsShareHistory(t *testing.T) {
+	cache, err := NewShardedCache(Config{MaxSize: 4, EvictionPolicy: LRU, EvictionHistory: 100}, 2)
+	if err != nil {
+		t.Fatalf("Failed to create sharded cache: %v", err)
+	}
+
+	for i := 0; i < 20; i++ {
+		cache.Set("key"+strconv.Itoa(i), i)
+	}
+
+	if records := cache.RecentEvictions(); len(records) != 20-cache.Size() {
+		t.Errorf("Expected %d records across shards, got %d", 20-cache.Size(), len(records))
+	}
+}
@@ -0,0 +1,65 @@
+package littlecache
+
+// HottestKeys returns up to n keys, most recently used first. It takes
+// only the read lock and doesn't touch the recency order.
+func (lru *LRUCache) HottestKeys(n int) []string {
+	lru.mu.RLock()
+	defer lru.mu.RUnlock()
+
+	keys := make([]string, 0, max(min(n, lru.size), 0))
+	for node := lru.head.next; node != lru.tail && len(keys) < n; node = node.next {
+		keys = append(keys, node.key)
+	}
+	return keys
+}
+
+// ColdestKeys returns up to n keys, least recently used first. Pinned keys
+// are listed by recency like any other, though eviction skips them.
+func (lru *LRUCache) ColdestKeys(n int) []string {
+	lru.mu.RLock()
+	defer lru.mu.RUnlock()
+
+	keys := make([]string, 0, max(min(n, lru.size), 0))
+	for node := lru.tail.prev; node != lru.head && len(keys) < n; node = node.prev {
+		keys = append(keys, node.key)
+	}
+	return keys
+}
+
+// HottestKeys returns up to n keys, highest frequency first and, within a
+// frequency, most recently touched first. It takes only the read lock and
+// counts none of them as an access.
+func (lfu *LFUCache) HottestKeys(n int) []string {
+	lfu.mu.RLock()
+	defer lfu.mu.RUnlock()
+
+	keys := make([]string, 0, max(min(n, lfu.size), 0))
+	freqs := lfu.sortedFreqs()
+	for i := len(freqs) - 1; i >= 0 && len(keys) < n; i-- {
+		head := lfu.freqMap[freqs[i]]
+		for node := head.next; node != head && len(keys) < n; node = node.next {
+			keys = append(keys, node.key)
+		}
+	}
+	return keys
+}
+
+// ColdestKeys returns up to n keys in the order eviction would take them:
+// lowest frequency first and, within a frequency, least recently touched
+// first. Pinned keys are ranked like any other, though eviction skips them.
+func (lfu *LFUCache) ColdestKeys(n int) []string {
+	lfu.mu.RLock()
+	defer lfu.mu.RUnlock()
+
+	keys := make([]string, 0, max(min(n, lfu.size), 0))
+	for _, freq := range lfu.sortedFreqs() {
+		if len(keys) == n {
+			break
+		}
+		head := lfu.freqMap[freq]
+		for node := head.prev; node != head && len(keys) < n; node = node.prev {
+			keys = append(keys, node.key)
+		}
+	}
+	return keys
+}
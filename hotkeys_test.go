@@ -0,0 +1,87 @@
+package littlecache
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestHottestColdestKeys_LRU(t *testing.T) {
+	cache, err := NewLRUCache(Config{MaxSize: 10})
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+	for i := 0; i < 6; i++ {
+		cache.Set("k"+strconv.Itoa(i), i)
+	}
+	cache.Get("k1")
+	cache.Get("k3")
+	// Recency, newest first: k3 k1 k5 k4 k2 k0
+
+	if got, want := cache.HottestKeys(3), []string{"k3", "k1", "k5"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected hottest %v, got %v", want, got)
+	}
+	if got, want := cache.ColdestKeys(3), []string{"k0", "k2", "k4"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected coldest %v, got %v", want, got)
+	}
+
+	// Listing promotes nothing: k0 is still the next victim
+	if candidate, _ := cache.EvictionCandidate(); candidate != "k0" {
+		t.Errorf("Expected k0 to stay the eviction candidate, got %s", candidate)
+	}
+	if rank, _ := cache.RecencyRank("k3"); rank != 0 {
+		t.Errorf("Expected k3 to stay the newest, got rank %d", rank)
+	}
+
+	if got := cache.HottestKeys(100); len(got) != 6 {
+		t.Errorf("Expected every key when n exceeds the size, got %v", got)
+	}
+	if got := cache.ColdestKeys(0); len(got) != 0 {
+		t.Errorf("Expected no keys for n=0, got %v", got)
+	}
+}
+
+func TestHottestColdestKeys_LFU(t *testing.T) {
+	cache, err := NewLFUCache(Config{MaxSize: 10, EvictionPolicy: LFU})
+	if err != nil {
+		t.Fatalf("Failed to create LFU cache: %v", err)
+	}
+	// Frequencies: a=5, b=3, c=3, d=1, e=1, with c read after b and e set after d
+	reads := []struct {
+		key   string
+		times int
+	}{{"a", 4}, {"b", 2}, {"c", 2}, {"d", 0}, {"e", 0}}
+	for _, r := range reads {
+		cache.Set(r.key, r.key)
+		for i := 0; i < r.times; i++ {
+			cache.Get(r.key)
+		}
+	}
+	before := cache.DebugString()
+
+	if got, want := cache.HottestKeys(3), []string{"a", "c", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected hottest %v, got %v", want, got)
+	}
+	if got, want := cache.ColdestKeys(3), []string{"d", "e", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected coldest %v, got %v", want, got)
+	}
+
+	// The full coldest list is the eviction order, and the hottest its reverse
+	coldest := cache.ColdestKeys(10)
+	hottest := cache.HottestKeys(10)
+	for i := range coldest {
+		if coldest[i] != hottest[len(hottest)-1-i] {
+			t.Errorf("Expected hottest %v to reverse coldest %v", hottest, coldest)
+			break
+		}
+	}
+	if got := cache.DebugString(); got != before {
+		t.Errorf("Expected listing to leave the buckets alone, was:\n%s\nnow:\n%s", before, got)
+	}
+	for _, want := range coldest {
+		if candidate, _ := cache.EvictionCandidate(); candidate != want {
+			t.Errorf("Expected %s to be evicted next, got %s", want, candidate)
+		}
+		cache.Trim(cache.Size() - 1)
+	}
+}
@@ -0,0 +1,512 @@
+package littlecache
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// checkInvariants verifies that the recency list, the lookup map and the
+// counters agree with each other.
+func (lru *LRUCache) checkInvariants() error {
+	lru.mu.RLock()
+	defer lru.mu.RUnlock()
+
+	count, weight, pinned := 0, int64(0), 0
+	for node := lru.head.next; node != lru.tail; node = node.next {
+		if node.next.prev != node || node.prev.next != node {
+			return fmt.Errorf("broken links around %q", node.key)
+		}
+		if lru.cache[node.key] != node {
+			return fmt.Errorf("listed node %q is not the mapped node", node.key)
+		}
+		count++
+		weight += int64(node.weight)
+		if node.pinned {
+			pinned++
+		}
+		if count > len(lru.cache) {
+			return fmt.Errorf("list is longer than the map (%d entries), or has a cycle", len(lru.cache))
+		}
+	}
+
+	backward := 0
+	for node := lru.tail.prev; node != lru.head; node = node.prev {
+		backward++
+		if backward > count {
+			return fmt.Errorf("backward walk is longer than forward walk (%d)", count)
+		}
+	}
+
+	switch {
+	case backward != count:
+		return fmt.Errorf("forward walk found %d nodes, backward %d", count, backward)
+	case count != len(lru.cache):
+		return fmt.Errorf("list has %d nodes, map has %d", count, len(lru.cache))
+	case count != lru.size:
+		return fmt.Errorf("list has %d nodes, size is %d", count, lru.size)
+	case lru.accessed != nil && len(lru.accessed) != count:
+		return fmt.Errorf("list has %d nodes, access times cover %d", count, len(lru.accessed))
+	case weight != lru.weight:
+		return fmt.Errorf("node weights sum to %d, weight is %d", weight, lru.weight)
+	case pinned != lru.pinned:
+		return fmt.Errorf("%d nodes are pinned, pinned count is %d", pinned, lru.pinned)
+	}
+	return lru.tags.check(func(key string) bool { return lru.cache[key] != nil })
+}
+
+// checkInvariants verifies that the frequency buckets, the lookup map,
+// minFreq and the counters agree with each other.
+func (lfu *LFUCache) checkInvariants() error {
+	lfu.mu.RLock()
+	defer lfu.mu.RUnlock()
+
+	count, pinned, minFreq := 0, 0, 0
+	for freq, head := range lfu.freqMap {
+		if head.next == head {
+			return fmt.Errorf("bucket %d is empty but still mapped", freq)
+		}
+		if minFreq == 0 || freq < minFreq {
+			minFreq = freq
+		}
+		for node := head.next; node != head; node = node.next {
+			if node.next.prev != node || node.prev.next != node {
+				return fmt.Errorf("broken links around %q", node.key)
+			}
+			if node.freq != freq {
+				return fmt.Errorf("node %q has freq %d but sits in bucket %d", node.key, node.freq, freq)
+			}
+			if lfu.cache[node.key] != node {
+				return fmt.Errorf("bucketed node %q is not the mapped node", node.key)
+			}
+			count++
+			if node.pinned {
+				pinned++
+			}
+			if count > len(lfu.cache) {
+				return fmt.Errorf("buckets hold more nodes than the map (%d), or have a cycle", len(lfu.cache))
+			}
+		}
+	}
+
+	switch {
+	case count != len(lfu.cache):
+		return fmt.Errorf("buckets hold %d nodes, map has %d", count, len(lfu.cache))
+	case count != lfu.size:
+		return fmt.Errorf("buckets hold %d nodes, size is %d", count, lfu.size)
+	case count > 0 && minFreq != lfu.minFreq:
+		return fmt.Errorf("lowest bucket is %d, minFreq is %d", minFreq, lfu.minFreq)
+	case pinned != lfu.pinned:
+		return fmt.Errorf("%d nodes are pinned, pinned count is %d", pinned, lfu.pinned)
+	}
+	return lfu.tags.check(func(key string) bool { return lfu.cache[key] != nil })
+}
+
+// check verifies that both directions of the index agree, that no tag is
+// left without keys and that every tagged key is present.
+func (x *tagIndex) check(present func(key string) bool) error {
+	pairs := 0
+	for key, tags := range x.tags {
+		if !present(key) {
+			return fmt.Errorf("tagged key %q is not in the cache", key)
+		}
+		for _, tag := range tags {
+			if _, ok := x.keys[tag][key]; !ok {
+				return fmt.Errorf("key %q lists tag %q, which doesn't list it", key, tag)
+			}
+		}
+		pairs += len(tags)
+	}
+	for tag, keys := range x.keys {
+		if len(keys) == 0 {
+			return fmt.Errorf("tag %q has no keys but is still mapped", tag)
+		}
+		pairs -= len(keys)
+	}
+	if pairs != 0 {
+		return fmt.Errorf("the tag and key maps disagree by %d pairs", pairs)
+	}
+	return nil
+}
+
+// checkInvariants verifies that the slots, the lookup map and the weight
+// tree agree with each other.
+func (w *WeightedRandomCache) checkInvariants() error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if len(w.slots) != len(w.index) {
+		return fmt.Errorf("%d slots, %d mapped keys", len(w.slots), len(w.index))
+	}
+	if len(w.slots) > len(w.weights.tree) {
+		return fmt.Errorf("%d slots exceed the weight tree's %d", len(w.slots), len(w.weights.tree))
+	}
+
+	var total int64
+	for i, entry := range w.slots {
+		if entry.slot != i {
+			return fmt.Errorf("%q sits in slot %d but records %d", entry.key, i, entry.slot)
+		}
+		if w.index[entry.key] != entry {
+			return fmt.Errorf("slot %d holds %q, which is not the mapped entry", i, entry.key)
+		}
+		total += weightOf(entry.frequency)
+		if w.weights.find(total-1) != i {
+			return fmt.Errorf("weight tree misplaces slot %d", i)
+		}
+	}
+	if total != w.weights.total {
+		return fmt.Errorf("weights sum to %d, total is %d", total, w.weights.total)
+	}
+	return nil
+}
+
+// invariantCache is a cache that can check its own internal consistency.
+type invariantCache interface {
+	LittleCache
+	checkInvariants() error
+}
+
+// runRandomOps issues a seeded random mix of operations against cache from
+// several goroutines while another goroutine checks the invariants.
+func runRandomOps(t *testing.T, cache invariantCache) {
+	const (
+		workers = 8
+		ops     = 2000
+		keys    = 64
+	)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(seed uint64) {
+			defer wg.Done()
+			rng := rand.New(rand.NewPCG(seed, seed))
+			for i := 0; i < ops; i++ {
+				key := "key" + strconv.Itoa(rng.IntN(keys))
+				switch op := rng.IntN(100); {
+				case op < 40:
+					cache.Set(key, i)
+				case op < 80:
+					cache.Get(key)
+				case op < 95:
+					cache.Delete(key)
+				case op < 99:
+					cache.Resize(1 + rng.IntN(keys))
+				default:
+					cache.Clear()
+				}
+			}
+		}(uint64(w))
+	}
+
+	done := make(chan struct{})
+	checked := make(chan struct{})
+	go func() {
+		defer close(checked)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if err := cache.checkInvariants(); err != nil {
+				t.Errorf("Invariant violated during run: %v", err)
+				return
+			}
+		}
+	}()
+
+	wg.Wait()
+	close(done)
+	<-checked
+
+	if err := cache.checkInvariants(); err != nil {
+		t.Errorf("Invariant violated after run: %v", err)
+	}
+}
+
+// checkInvariants checks the underlying LRU state, which LRUTTLCache
+// manipulates directly, and that every deadline belongs to a cached node.
+func (c *LRUTTLCache) checkInvariants() error {
+	if err := c.lru.checkInvariants(); err != nil {
+		return err
+	}
+
+	c.lru.mu.RLock()
+	defer c.lru.mu.RUnlock()
+
+	expiries := c.lru.expiries
+	if len(expiries.byNode) != len(expiries.heap) {
+		return fmt.Errorf("%d deadlines but %d heap entries", len(expiries.byNode), len(expiries.heap))
+	}
+	for i, expiry := range expiries.heap {
+		if expiry.heapIndex != i || expiries.byNode[expiry.node] != expiry {
+			return fmt.Errorf("heap entry %d for %q is out of place", i, expiry.node.key)
+		}
+		if c.lru.cache[expiry.node.key] != expiry.node {
+			return fmt.Errorf("deadline kept for %q, which is not cached", expiry.node.key)
+		}
+	}
+	return nil
+}
+
+// runHotKeyOps has goroutines Get, Set and Delete one key at once: the
+// interleaving that breaks a Get which looks the node up under the read
+// lock and then relinks it under the write lock.
+func runHotKeyOps(t *testing.T, cache invariantCache) {
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				switch (w + i) % 4 {
+				case 0:
+					cache.Set("hot", i)
+				case 3:
+					cache.Delete("hot")
+				default:
+					cache.Get("hot")
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if err := cache.checkInvariants(); err != nil {
+		t.Errorf("Invariant violated after hot key run: %v", err)
+	}
+}
+
+// TestMutatingGet_RandomOpsInvariants covers every cache whose Get changes
+// its eviction state, and so must hold the write lock for the whole
+// lookup. Run it with -race.
+func TestMutatingGet_RandomOpsInvariants(t *testing.T) {
+	caches := map[string]func() (invariantCache, error){
+		"LRU": func() (invariantCache, error) {
+			return NewLRUCache(Config{MaxSize: 16})
+		},
+		"LRU with access times": func() (invariantCache, error) {
+			return NewLRUCache(Config{MaxSize: 16, TrackAccessTime: true})
+		},
+		"LFU": func() (invariantCache, error) {
+			return NewLFUCache(Config{MaxSize: 16})
+		},
+		"WeightedRandom": func() (invariantCache, error) {
+			return NewWeightedRandomCache(Config{MaxSize: 16, RandomSeed: 1})
+		},
+		"LRUTTL": func() (invariantCache, error) {
+			return NewLRUTTLCache(Config{MaxSize: 16}, time.Millisecond)
+		},
+	}
+
+	for name, construct := range caches {
+		t.Run(name, func(t *testing.T) {
+			cache, err := construct()
+			if err != nil {
+				t.Fatalf("Failed to create cache: %v", err)
+			}
+			runRandomOps(t, cache)
+			runHotKeyOps(t, cache)
+		})
+	}
+}
+
+func TestLFUCache_DeleteKeepsMinFreq(t *testing.T) {
+	cache, err := NewLFUCache(Config{MaxSize: 3})
+	if err != nil {
+		t.Fatalf("Failed to create LFU cache: %v", err)
+	}
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.Get("a")
+	cache.Get("b")
+	cache.Get("b")
+
+	// Removing the only frequency-1 key used to reset minFreq to 1 even
+	// though no such bucket remained.
+	cache.Set("c", 3)
+	cache.Delete("c")
+	if err := cache.checkInvariants(); err != nil {
+		t.Fatalf("Invariant violated after Delete: %v", err)
+	}
+
+	cache.Set("d", 4)
+	cache.Set("e", 5)
+	if _, ok := cache.Get("d"); ok {
+		t.Errorf("Expected d to be evicted as least frequently used")
+	}
+	if err := cache.checkInvariants(); err != nil {
+		t.Errorf("Invariant violated after eviction: %v", err)
+	}
+
+	// Resize drains whole buckets and must move minFreq along with them
+	if err := cache.Resize(1); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := cache.checkInvariants(); err != nil {
+		t.Errorf("Invariant violated after Resize: %v", err)
+	}
+}
+
+func TestMaxSizeOne(t *testing.T) {
+	lru, _ := NewLRUCache(Config{MaxSize: 1})
+	lfu, _ := NewLFUCache(Config{MaxSize: 1})
+
+	caches := map[string]interface {
+		LittleCache
+		checkInvariants() error
+		EvictionCandidate() (string, bool)
+	}{
+		"lru": lru,
+		"lfu": lfu,
+	}
+
+	for name, cache := range caches {
+		check := func(step string, wantSize int) {
+			t.Helper()
+			if err := cache.checkInvariants(); err != nil {
+				t.Fatalf("%s: invariant violated after %s: %v", name, step, err)
+			}
+			if cache.Size() != wantSize {
+				t.Errorf("%s: expected size %d after %s, got %d", name, wantSize, step, cache.Size())
+			}
+		}
+
+		cache.Set("a", 1)
+		check("first Set", 1)
+
+		// Heat a up; with one slot it must still give way to b
+		cache.Get("a")
+		cache.Get("a")
+		cache.Set("b", 2)
+		check("second Set", 1)
+		if _, ok := cache.Get("a"); ok {
+			t.Errorf("%s: expected a to be evicted", name)
+		}
+		if value, ok := cache.Get("b"); !ok || value != 2 {
+			t.Errorf("%s: expected b=2, got %v (ok=%v)", name, value, ok)
+		}
+
+		cache.Set("b", 3)
+		check("overwrite", 1)
+		if value, _ := cache.Get("b"); value != 3 {
+			t.Errorf("%s: expected b=3 after overwrite, got %v", name, value)
+		}
+
+		cache.Delete("b")
+		check("Delete", 0)
+		if key, ok := cache.EvictionCandidate(); ok {
+			t.Errorf("%s: expected no eviction candidate when empty, got %s", name, key)
+		}
+		cache.Delete("b")
+		check("second Delete", 0)
+
+		cache.Set("c", 4)
+		check("Set after emptying", 1)
+
+		// Fill a larger cache, make c the hottest and most recent entry,
+		// then shrink back to one slot
+		if err := cache.Resize(5); err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		for _, key := range []string{"d", "e", "f", "g"} {
+			cache.Set(key, key)
+		}
+		cache.Get("c")
+		cache.Get("c")
+		check("refill", 5)
+
+		if err := cache.Resize(1); err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		check("Resize to 1", 1)
+		if value, ok := cache.Get("c"); !ok || value != 4 {
+			t.Errorf("%s: expected Resize to keep c=4, got %v (ok=%v)", name, value, ok)
+		}
+
+		cache.Set("h", 5)
+		check("Set after Resize", 1)
+		if _, ok := cache.Get("h"); !ok {
+			t.Errorf("%s: expected h after Set", name)
+		}
+
+		cache.Clear()
+		check("Clear", 0)
+	}
+}
+
+func TestClear_ConcurrentWithGetAndSet(t *testing.T) {
+	lru, _ := NewLRUCache(Config{MaxSize: 32})
+	// With NoPromoteOnGet, Get only takes the read lock
+	readOnlyGet, _ := NewLRUCache(Config{MaxSize: 32, NoPromoteOnGet: true})
+	lfu, _ := NewLFUCache(Config{MaxSize: 32})
+
+	caches := map[string]invariantCache{
+		"lru":          lru,
+		"lruNoPromote": readOnlyGet,
+		"lfu":          lfu,
+	}
+
+	for name, cache := range caches {
+		stop := make(chan struct{})
+		var wg sync.WaitGroup
+		for w := 0; w < 8; w++ {
+			wg.Add(1)
+			go func(seed uint64) {
+				defer wg.Done()
+				rng := rand.New(rand.NewPCG(seed, seed))
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+					key := "key" + strconv.Itoa(rng.IntN(64))
+					if rng.IntN(2) == 0 {
+						cache.Set(key, key)
+					} else if value, ok := cache.Get(key); ok && value != key {
+						t.Errorf("%s: expected %s to hold its own name, got %v", name, key, value)
+					}
+				}
+			}(uint64(w))
+		}
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 200; i++ {
+				cache.Clear()
+				if err := cache.checkInvariants(); err != nil {
+					t.Errorf("%s: invariant violated after Clear: %v", name, err)
+					return
+				}
+			}
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("%s: Clear deadlocked with concurrent Get and Set", name)
+		}
+		close(stop)
+		wg.Wait()
+
+		cache.Clear()
+		if cache.Size() != 0 {
+			t.Errorf("%s: expected size 0 once Clear completed, got %d", name, cache.Size())
+		}
+		cache.Set("after", 1)
+		if value, ok := cache.Get("after"); !ok || value != 1 {
+			t.Errorf("%s: expected Set to work after Clear, got %v (ok=%v)", name, value, ok)
+		}
+		if err := cache.checkInvariants(); err != nil {
+			t.Errorf("%s: invariant violated at the end: %v", name, err)
+		}
+	}
+}
@@ -1,38 +1,80 @@
 package littlecache
 
 import (
-	"sync"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
 )
 
 type LFUNode struct {
-	key   string
-	value interface{}
-	freq  int
+	key    string
+	value  interface{}
+	freq   int
+	pinned bool
+	// reads is only kept when Config.TrackAccessCounts is set.
+	reads uint64
 	prev  *LFUNode
 	next  *LFUNode
 }
 
+const defaultMaxFrequency = 1 << 16
+
+// maxFrequency returns the LFU frequency cap for config.
+func maxFrequency(config Config) int {
+	if config.MaxFrequency == 0 {
+		return defaultMaxFrequency
+	}
+	return config.MaxFrequency
+}
+
 type LFUCache struct {
 	config  Config
+	initial int // MaxSize as constructed, for Reset
+	maxFreq int
 	size    int
+	pinned  int
 	cache   map[string]*LFUNode
+	tags    tagIndex
 	freqMap map[int]*LFUNode // frequency -> head of doubly linked list
 	minFreq int
-	mu      sync.RWMutex
+	mu      rwMutex
+	cacheStats
+	saver autoSaver
+	tuner *autoTuner
 }
 
 func NewLFUCache(config Config) (*LFUCache, error) {
 	if err := config.Validate(); err != nil {
-		return nil, err
+		return nil, config.error("new", err)
 	}
+	config.initShared()
 
-	return &LFUCache{
+	lfu := &LFUCache{
 		config:  config,
+		initial: config.MaxSize,
+		maxFreq: maxFrequency(config),
 		size:    0,
-		cache:   make(map[string]*LFUNode),
+		cache:   make(map[string]*LFUNode, config.initialMapSize()),
 		freqMap: make(map[int]*LFUNode),
 		minFreq: 0,
-	}, nil
+		mu:      newRWMutex(config),
+	}
+	lfu.bind(&lfu.config, &lfu.mu, func() int { return lfu.size })
+	lfu.tuner = startAutoTune(config, lfu)
+	return lfu, nil
+}
+
+// Stop stops the auto-tuner, if Config.AutoTune started one, and waits for
+// it to exit.
+func (lfu *LFUCache) Stop() {
+	lfu.tuner.halt()
+}
+
+func (lfu *LFUCache) capacity() int {
+	lfu.mu.RLock()
+	defer lfu.mu.RUnlock()
+	return lfu.config.MaxSize
 }
 
 func (lfu *LFUCache) addNode(node *LFUNode, freq int) {
@@ -58,6 +100,12 @@ func (lfu *LFUCache) updateFreq(node *LFUNode) {
 	freq := node.freq
 	lfu.removeNode(node)
 
+	if freq >= lfu.maxFreq {
+		// Capped: just refresh its place within the top bucket.
+		lfu.addNode(node, freq)
+		return
+	}
+
 	if lfu.freqMap[freq].next == lfu.freqMap[freq] {
 		delete(lfu.freqMap, freq)
 		if lfu.minFreq == freq {
@@ -67,84 +115,265 @@ func (lfu *LFUCache) updateFreq(node *LFUNode) {
 
 	node.freq++
 	lfu.addNode(node, node.freq)
+	lfu.compactIfNeeded()
+}
+
+// compactIfNeeded merges frequency buckets once there are more than
+// MaxFrequencyBuckets of them. It shrinks to half the limit so that the
+// sort is paid for once per many new buckets, not on every one. The merged
+// buckets are numbered from 2, leaving frequency 1 to keys added after the
+// compaction, which stay colder than every entry that was already there.
+func (lfu *LFUCache) compactIfNeeded() {
+	limit := lfu.config.MaxFrequencyBuckets
+	if limit == 0 || len(lfu.freqMap) <= limit {
+		return
+	}
+
+	freqs := make([]int, 0, len(lfu.freqMap))
+	for freq := range lfu.freqMap {
+		freqs = append(freqs, freq)
+	}
+	sort.Ints(freqs)
+
+	target := max(limit/2, 1)
+	perBucket := (len(freqs) + target - 1) / target
+
+	freqMap := make(map[int]*LFUNode, target)
+	for start, rank := 0, 2; start < len(freqs); start, rank = start+perBucket, rank+1 {
+		merged := &LFUNode{}
+		merged.next, merged.prev = merged, merged
+
+		// Eviction takes from the tail of the lowest bucket, so the
+		// highest old frequency goes nearest the head and each bucket
+		// keeps its own recency order. Victims come out in the same order
+		// as before the merge.
+		group := freqs[start:min(start+perBucket, len(freqs))]
+		for i := len(group) - 1; i >= 0; i-- {
+			head := lfu.freqMap[group[i]]
+			for node := head.next; node != head; {
+				next := node.next
+				node.freq = rank
+				node.prev, node.next = merged.prev, merged
+				merged.prev.next = node
+				merged.prev = node
+				node = next
+			}
+		}
+		freqMap[rank] = merged
+	}
+
+	lfu.freqMap = freqMap
+	lfu.minFreq = 2
+}
+
+// sortedFreqs returns the frequencies that have a bucket, ascending. It
+// must be called with mu held.
+func (lfu *LFUCache) sortedFreqs() []int {
+	freqs := make([]int, 0, len(lfu.freqMap))
+	for freq := range lfu.freqMap {
+		freqs = append(freqs, freq)
+	}
+	sort.Ints(freqs)
+	return freqs
+}
+
+// evictable returns the least recently touched unpinned node in the lowest
+// frequency bucket that has one, or nil if every entry is pinned.
+func (lfu *LFUCache) evictable() *LFUNode {
+	if head, exists := lfu.freqMap[lfu.minFreq]; exists && head.prev != head && !head.prev.pinned {
+		return head.prev
+	}
+	for _, freq := range lfu.sortedFreqs() {
+		head := lfu.freqMap[freq]
+		for node := head.prev; node != head; node = node.prev {
+			if !node.pinned {
+				return node
+			}
+		}
+	}
+	return nil
 }
 
+// removeLFU unlinks the next eviction victim, skipping pinned entries, and
+// returns it. It returns nil if every entry is pinned.
 func (lfu *LFUCache) removeLFU() *LFUNode {
-	head := lfu.freqMap[lfu.minFreq]
-	lastNode := head.prev
-	lfu.removeNode(lastNode)
+	victim := lfu.evictable()
+	if victim == nil {
+		return nil
+	}
+	lfu.removeNode(victim)
+	lfu.tags.untag(victim.key)
 
-	if head.next == head {
-		delete(lfu.freqMap, lfu.minFreq)
+	if head := lfu.freqMap[victim.freq]; head.next == head {
+		delete(lfu.freqMap, victim.freq)
+		lfu.resetMinFreq()
 	}
 
-	return lastNode
+	lfu.evictions.record(clockOrDefault(lfu.config.Clock).Now(), 1)
+	lfu.config.evicted(victim.key, victim.value, CapacityEviction)
+	return victim
 }
 
 func (lfu *LFUCache) Set(key string, value interface{}) {
 	lfu.mu.Lock()
 	defer lfu.mu.Unlock()
 
+	if node, exists := lfu.cache[key]; exists && (lfu.config.ImmutableKeys || lfu.config.unchanged(node.value, value)) {
+		return
+	}
+	if !lfu.config.admits(key, value, lfu.size) {
+		return
+	}
+	lfu.set(key, value)
+}
+
+// SetWithTTL stores key like Set. An LFUCache has no notion of expiry, so
+// ttl is ignored; wrap it in a TTLCache to honor it.
+func (lfu *LFUCache) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	lfu.Set(key, value)
+}
+
+// set stores key, evicting first if needed. It returns false, storing
+// nothing, for a new key when pinned entries fill the cache.
+func (lfu *LFUCache) set(key string, value interface{}) bool {
 	node, exists := lfu.cache[key]
 
 	if !exists {
+		// Evict before inserting so the new entry, which always starts at
+		// frequency 1, can never be chosen as its own victim.
+		if lfu.size >= lfu.config.MaxSize {
+			lru := lfu.removeLFU()
+			if lru == nil {
+				return false
+			}
+			delete(lfu.cache, lru.key)
+			lfu.size--
+		}
+
 		newNode := &LFUNode{key: key, value: value, freq: 1}
 		lfu.cache[key] = newNode
 		lfu.addNode(newNode, 1)
 		lfu.size++
+		lfu.counters.observeSize(lfu.size)
 		lfu.minFreq = 1
-
-		if lfu.size > lfu.config.MaxSize {
-			lru := lfu.removeLFU()
-			delete(lfu.cache, lru.key)
-			lfu.size--
-		}
+		lfu.compactIfNeeded()
 	} else {
+		lfu.config.evicted(key, node.value, Replaced)
 		node.value = value
 		lfu.updateFreq(node)
 	}
+	return true
 }
 
 func (lfu *LFUCache) Get(key string) (interface{}, bool) {
+	// A hit bumps the frequency, so the lookup and the update must happen
+	// under the same write lock. Dropping a read lock and re-acquiring the
+	// write lock lets a concurrent Delete unlink the node in between.
+	lfu.mu.Lock()
+	defer lfu.mu.Unlock()
+
+	node, exists := lfu.cache[key]
+	lfu.counters.record(exists)
+	if !exists {
+		return nil, false
+	}
+
+	lfu.updateFreq(node)
+	if lfu.config.TrackAccessCounts {
+		node.reads++
+	}
+	return node.value, true
+}
+
+// Peek returns the value for key without counting it as an access: the
+// key's frequency, its bucket and minFreq are left as they were, so an
+// admin read can't save a key from eviction. It needs only the read lock.
+func (lfu *LFUCache) Peek(key string) (interface{}, bool) {
 	lfu.mu.RLock()
+	defer lfu.mu.RUnlock()
+
 	node, exists := lfu.cache[key]
 	if !exists {
-		lfu.mu.RUnlock()
 		return nil, false
 	}
+	return node.value, true
+}
 
-	value := node.value
-	lfu.mu.RUnlock()
+// LoadOrStore mirrors sync.Map.LoadOrStore. A hit bumps the key's
+// frequency and is counted like Get; a miss stores value only if Set would
+// admit it.
+func (lfu *LFUCache) LoadOrStore(key string, value interface{}) (interface{}, bool) {
+	lfu.mu.Lock()
+	defer lfu.mu.Unlock()
 
+	node, exists := lfu.cache[key]
+	lfu.counters.record(exists)
+	if exists {
+		lfu.updateFreq(node)
+		if lfu.config.TrackAccessCounts {
+			node.reads++
+		}
+		return node.value, true
+	}
+
+	if lfu.config.admits(key, value, lfu.size) {
+		lfu.set(key, value)
+	}
+	return value, false
+}
+
+// Swap stores value and returns the previous value, if any. Like Set, it
+// bumps the key's frequency.
+func (lfu *LFUCache) Swap(key string, value interface{}) (interface{}, bool) {
 	lfu.mu.Lock()
-	lfu.updateFreq(node)
-	lfu.mu.Unlock()
+	defer lfu.mu.Unlock()
+
+	var previous interface{}
+	node, exists := lfu.cache[key]
+	if exists {
+		previous = node.value
+	}
 
-	return value, true
+	lfu.set(key, value)
+	return previous, exists
 }
 
 func (lfu *LFUCache) Delete(key string) {
 	lfu.mu.Lock()
 	defer lfu.mu.Unlock()
 
-	node, exists := lfu.cache[key]
-	if !exists {
-		return
+	if node, exists := lfu.cache[key]; exists {
+		lfu.remove(node)
 	}
+}
 
+// remove drops node from the cache and reports it as Deleted.
+func (lfu *LFUCache) remove(node *LFUNode) {
 	lfu.removeNode(node)
-	delete(lfu.cache, key)
+	delete(lfu.cache, node.key)
+	lfu.tags.untag(node.key)
 	lfu.size--
+	if node.pinned {
+		lfu.pinned--
+	}
 
 	if lfu.freqMap[node.freq].next == lfu.freqMap[node.freq] {
 		delete(lfu.freqMap, node.freq)
-		if lfu.minFreq == node.freq && lfu.size > 0 {
-			lfu.minFreq = 1
-			for freq := range lfu.freqMap {
-				if freq < lfu.minFreq {
-					lfu.minFreq = freq
-				}
-			}
+		lfu.resetMinFreq()
+	}
+	lfu.config.evicted(node.key, node.value, Deleted)
+}
+
+// resetMinFreq recomputes minFreq after the lowest bucket may have emptied.
+func (lfu *LFUCache) resetMinFreq() {
+	if _, exists := lfu.freqMap[lfu.minFreq]; exists {
+		return
+	}
+
+	lfu.minFreq = 0
+	for freq := range lfu.freqMap {
+		if lfu.minFreq == 0 || freq < lfu.minFreq {
+			lfu.minFreq = freq
 		}
 	}
 }
@@ -153,12 +382,123 @@ func (lfu *LFUCache) Clear() {
 	lfu.mu.Lock()
 	defer lfu.mu.Unlock()
 
-	lfu.cache = make(map[string]*LFUNode)
+	if lfu.config.OnClear != nil {
+		lfu.config.cleared(lfu.snapshot())
+	}
+	lfu.clearAll()
+}
+
+// clearAll empties the cache, reporting each entry as Cleared.
+func (lfu *LFUCache) clearAll() {
+	if lfu.config.OnEvict != nil {
+		for key, node := range lfu.cache {
+			lfu.config.notify(key, node.value, Cleared)
+		}
+	}
+	lfu.reset()
+}
+
+// snapshot copies every entry into a map.
+func (lfu *LFUCache) snapshot() map[string]interface{} {
+	entries := make(map[string]interface{}, lfu.size)
+	for key, node := range lfu.cache {
+		entries[key] = node.value
+	}
+	return entries
+}
+
+// reset empties the cache, sizing the new map for the entries it held.
+func (lfu *LFUCache) reset() {
+	lfu.cache = make(map[string]*LFUNode, min(lfu.size, maxPrealloc))
+	lfu.tags.reset()
 	lfu.freqMap = make(map[int]*LFUNode)
 	lfu.size = 0
+	lfu.pinned = 0
 	lfu.minFreq = 0
 }
 
+// ReplaceAll swaps in items as the entire contents under one write lock, so
+// readers see either the old or the new data set, never an empty cache.
+// Every item starts at frequency 1; if they overflow the cache, which ones
+// are evicted is arbitrary.
+func (lfu *LFUCache) ReplaceAll(items map[string]interface{}) {
+	lfu.mu.Lock()
+	defer lfu.mu.Unlock()
+
+	lfu.clearAll()
+	for key, value := range items {
+		lfu.set(key, value)
+	}
+}
+
+// EvictionCandidate returns the key that the next overflowing Set would
+// evict, without evicting it.
+func (lfu *LFUCache) EvictionCandidate() (string, bool) {
+	lfu.mu.RLock()
+	defer lfu.mu.RUnlock()
+
+	victim := lfu.evictable()
+	if victim == nil {
+		return "", false
+	}
+	return victim.key, true
+}
+
+// FrequencyOf returns how many times key has been set or read, without
+// counting this lookup as an access.
+func (lfu *LFUCache) FrequencyOf(key string) (int, bool) {
+	lfu.mu.RLock()
+	defer lfu.mu.RUnlock()
+
+	node, exists := lfu.cache[key]
+	if !exists {
+		return 0, false
+	}
+	return node.freq, true
+}
+
+// DebugString describes every frequency bucket in ascending order, one per
+// line. Keys within a bucket run from most recently touched to the next
+// eviction candidate.
+func (lfu *LFUCache) DebugString() string {
+	lfu.mu.RLock()
+	defer lfu.mu.RUnlock()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "LFU size=%d/%d minFreq=%d", lfu.size, lfu.config.MaxSize, lfu.minFreq)
+	for _, freq := range lfu.sortedFreqs() {
+		fmt.Fprintf(&b, "\n  freq %d:", freq)
+		head := lfu.freqMap[freq]
+		for node := head.next; node != head; node = node.next {
+			b.WriteString(" " + node.key)
+		}
+	}
+	return b.String()
+}
+
+// Drain atomically removes and returns every entry.
+func (lfu *LFUCache) Drain() map[string]interface{} {
+	lfu.mu.Lock()
+	defer lfu.mu.Unlock()
+
+	entries := lfu.snapshot()
+	lfu.reset()
+	return entries
+}
+
+// Dump returns a consistent snapshot of every entry without touching
+// frequencies.
+func (lfu *LFUCache) Dump() []Entry {
+	lfu.mu.RLock()
+	defer lfu.mu.RUnlock()
+
+	entries := make([]Entry, 0, lfu.size)
+	for key, node := range lfu.cache {
+		entries = append(entries, Entry{Key: key, Value: node.value})
+	}
+	return entries
+}
+
 func (lfu *LFUCache) Size() int {
 	lfu.mu.RLock()
 	defer lfu.mu.RUnlock()
@@ -169,15 +509,98 @@ func (lfu *LFUCache) Resize(newSize int) error {
 	lfu.mu.Lock()
 	defer lfu.mu.Unlock()
 
-	if newSize <= 0 {
-		return ErrInvalidMaxSize
+	if err := checkSize(newSize); err != nil {
+		return lfu.config.error("resize", err)
+	}
+
+	if newSize == lfu.config.MaxSize {
+		return nil
+	}
+
+	if hint, ok := preallocSize(lfu.config.MaxSize, newSize); ok {
+		cache := make(map[string]*LFUNode, hint)
+		for key, node := range lfu.cache {
+			cache[key] = node
+		}
+		lfu.cache = cache
 	}
 
 	lfu.config.MaxSize = newSize
+	lfu.shrinkToFit()
+	return nil
+}
+
+// Trim evicts the least frequently used entries until at most targetSize
+// remain, and returns how many it removed. Unlike Resize, the capacity
+// stays the same. Pinned entries are never trimmed.
+func (lfu *LFUCache) Trim(targetSize int) int {
+	lfu.mu.Lock()
+	defer lfu.mu.Unlock()
+
+	removed := 0
+	for lfu.size > targetSize {
+		victim := lfu.removeLFU()
+		if victim == nil {
+			break
+		}
+		delete(lfu.cache, victim.key)
+		lfu.size--
+		removed++
+	}
+	return removed
+}
+
+// shrinkToFit evicts until the cache fits MaxSize or only pinned entries
+// are left.
+func (lfu *LFUCache) shrinkToFit() {
 	for lfu.size > lfu.config.MaxSize {
 		lru := lfu.removeLFU()
+		if lru == nil {
+			break
+		}
 		delete(lfu.cache, lru.key)
 		lfu.size--
 	}
+}
+
+// SetPinned stores key and pins it, so eviction skips it until Unpin. Like
+// Swap, it ignores ImmutableKeys and Admit and bumps the frequency of an
+// existing key. A new key is rejected with ErrCacheFullyPinned when pinned
+// entries already fill the cache. Pinned entries can keep the cache above
+// MaxSize after a Resize.
+func (lfu *LFUCache) SetPinned(key string, value interface{}) error {
+	lfu.mu.Lock()
+	defer lfu.mu.Unlock()
+
+	if !lfu.set(key, value) {
+		return lfu.config.error("set", ErrCacheFullyPinned)
+	}
+
+	if node := lfu.cache[key]; !node.pinned {
+		node.pinned = true
+		lfu.pinned++
+	}
 	return nil
 }
+
+// Unpin makes key evictable again. It reports whether key was pinned.
+func (lfu *LFUCache) Unpin(key string) bool {
+	lfu.mu.Lock()
+	defer lfu.mu.Unlock()
+
+	node, exists := lfu.cache[key]
+	if !exists || !node.pinned {
+		return false
+	}
+	node.pinned = false
+	lfu.pinned--
+	lfu.shrinkToFit()
+	return true
+}
+
+// chainOnEvict adds fn to the OnEvict callback, for a wrapping TTLCache.
+func (lfu *LFUCache) chainOnEvict(fn func(key string, value interface{}, reason EvictionReason)) {
+	lfu.mu.Lock()
+	defer lfu.mu.Unlock()
+	lfu.config.chainOnEvict(fn)
+}
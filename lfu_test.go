@@ -1,6 +1,7 @@
 package littlecache
 
 import (
+	"errors"
 	"strconv"
 	"sync"
 	"testing"
@@ -356,3 +357,344 @@ func TestLFUCache_FrequencyTracking(t *testing.T) {
 		t.Errorf("Expected item3 to exist")
 	}
 }
+
+func TestLFUCache_EvictionCandidate(t *testing.T) {
+	config := Config{MaxSize: 3, EvictionPolicy: LFU}
+	cache, err := NewLFUCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create LFU cache: %v", err)
+	}
+
+	if _, ok := cache.EvictionCandidate(); ok {
+		t.Errorf("Expected no eviction candidate for empty cache")
+	}
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.Set("c", 3)
+	cache.Get("a")
+	cache.Get("a")
+	cache.Get("c")
+
+	// Frequencies: a=3, c=2, b=1
+	candidate, ok := cache.EvictionCandidate()
+	if !ok || candidate != "b" {
+		t.Fatalf("Expected eviction candidate b, got %q (ok=%v)", candidate, ok)
+	}
+
+	cache.Set("d", 4)
+	if _, exists := cache.Get(candidate); exists {
+		t.Errorf("Expected %s to be evicted", candidate)
+	}
+
+	// Frequencies: a=3, c=2, d=1
+	cache.Get("d")
+	cache.Get("d")
+	cache.Get("c")
+	cache.Get("c")
+
+	// Frequencies: a=3, c=4, d=3; a is the older entry at frequency 3
+	candidate, ok = cache.EvictionCandidate()
+	if !ok || candidate != "a" {
+		t.Fatalf("Expected eviction candidate a, got %q (ok=%v)", candidate, ok)
+	}
+
+	cache.Set("e", 5)
+	if _, exists := cache.Get(candidate); exists {
+		t.Errorf("Expected %s to be evicted", candidate)
+	}
+	if _, exists := cache.Get("e"); !exists {
+		t.Errorf("Expected newly inserted key e to survive its own insert")
+	}
+}
+
+func TestLFUCache_Drain(t *testing.T) {
+	config := Config{MaxSize: 3, EvictionPolicy: LFU}
+	cache, err := NewLFUCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create LFU cache: %v", err)
+	}
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.Get("a")
+
+	entries := cache.Drain()
+	if len(entries) != 2 || entries["a"] != 1 || entries["b"] != 2 {
+		t.Errorf("Expected drained entries to match prior contents, got %v", entries)
+	}
+	if cache.Size() != 0 {
+		t.Errorf("Expected size 0 after drain, got %d", cache.Size())
+	}
+
+	cache.Set("c", 3)
+	if value, exists := cache.Get("c"); !exists || value != 3 {
+		t.Errorf("Expected cache to be usable after drain")
+	}
+}
+
+func TestLFUCache_ConcurrentGetDelete(t *testing.T) {
+	config := Config{MaxSize: 8, EvictionPolicy: LFU}
+	cache, err := NewLFUCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create LFU cache: %v", err)
+	}
+
+	keys := []string{"a", "b", "c", "d"}
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(goroutineID int) {
+			defer wg.Done()
+			for j := 0; j < 20000; j++ {
+				key := keys[(goroutineID+j)%len(keys)]
+				switch j % 3 {
+				case 0:
+					cache.Set(key, j)
+				case 1:
+					cache.Get(key)
+				case 2:
+					cache.Delete(key)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Every cached node must be linked into exactly one frequency bucket
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+
+	linked := 0
+	for freq, head := range cache.freqMap {
+		for node := head.next; node != head; node = node.next {
+			if node.freq != freq {
+				t.Errorf("Node %s with freq %d found in bucket %d", node.key, node.freq, freq)
+			}
+			if cache.cache[node.key] != node {
+				t.Errorf("Bucket %d holds node %s that is not in the cache map", freq, node.key)
+			}
+			linked++
+		}
+	}
+	if linked != cache.size || len(cache.cache) != cache.size {
+		t.Errorf("Expected %d linked nodes and map entries, got %d and %d", cache.size, linked, len(cache.cache))
+	}
+}
+
+func TestLFUCache_FrequencyOf(t *testing.T) {
+	config := Config{MaxSize: 4, EvictionPolicy: LFU}
+	cache, err := NewLFUCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create LFU cache: %v", err)
+	}
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.Get("a")
+	cache.Get("a")
+	cache.Set("b", 20)
+
+	expected := map[string]int{"a": 3, "b": 2}
+	for key, want := range expected {
+		freq, exists := cache.FrequencyOf(key)
+		if !exists || freq != want {
+			t.Errorf("Expected frequency %d for %s, got %d (exists=%v)", want, key, freq, exists)
+		}
+	}
+
+	// Querying doesn't count as an access
+	cache.FrequencyOf("b")
+	if freq, _ := cache.FrequencyOf("b"); freq != 2 {
+		t.Errorf("Expected FrequencyOf not to bump frequency, got %d", freq)
+	}
+
+	if _, exists := cache.FrequencyOf("missing"); exists {
+		t.Errorf("Expected no frequency for a missing key")
+	}
+}
+
+func TestLFUCache_PeekIsFrequencyNeutral(t *testing.T) {
+	cache, err := NewLFUCache(Config{MaxSize: 3, EvictionPolicy: LFU})
+	if err != nil {
+		t.Fatalf("Failed to create LFU cache: %v", err)
+	}
+
+	cache.Set("cold", 1)
+	cache.Set("warm", 2)
+	cache.Set("hot", 3)
+	cache.Get("warm")
+	for i := 0; i < 5; i++ {
+		cache.Get("hot")
+	}
+	buckets := cache.DebugString()
+	hits := cache.Stats().Hits
+
+	// Holding the read lock proves Peek never needs the write lock, so it
+	// can't be relinking nodes between buckets
+	cache.mu.RLock()
+	for i := 0; i < 100; i++ {
+		if value, ok := cache.Peek("cold"); !ok || value != 1 {
+			t.Fatalf("Expected to peek cold=1, got %v (ok=%v)", value, ok)
+		}
+	}
+	cache.mu.RUnlock()
+
+	if freq, _ := cache.FrequencyOf("cold"); freq != 1 {
+		t.Errorf("Expected cold to keep frequency 1 after 100 peeks, got %d", freq)
+	}
+	if cache.minFreq != 1 {
+		t.Errorf("Expected minFreq to stay 1, got %d", cache.minFreq)
+	}
+	if got := cache.DebugString(); got != buckets {
+		t.Errorf("Expected the frequency buckets unchanged, was:\n%s\nnow:\n%s", buckets, got)
+	}
+	if got := cache.Stats().Hits; got != hits {
+		t.Errorf("Expected peeks not to count as hits, %d before, %d after", hits, got)
+	}
+	if err := cache.checkInvariants(); err != nil {
+		t.Errorf("Invariant violated: %v", err)
+	}
+
+	if candidate, ok := cache.EvictionCandidate(); !ok || candidate != "cold" {
+		t.Errorf("Expected cold to remain the eviction candidate, got %q (ok=%v)", candidate, ok)
+	}
+	cache.Set("new", 4)
+	if _, ok := cache.Peek("cold"); ok {
+		t.Errorf("Expected the peeked key to be the one evicted")
+	}
+}
+
+func TestLFUCache_DebugString(t *testing.T) {
+	config := Config{MaxSize: 4, EvictionPolicy: LFU}
+	cache, err := NewLFUCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create LFU cache: %v", err)
+	}
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.Set("c", 3)
+	cache.Get("a")
+	cache.Get("a")
+	cache.Get("b")
+
+	want := "LFU size=3/4 minFreq=1\n" +
+		"  freq 1: c\n" +
+		"  freq 2: b\n" +
+		"  freq 3: a"
+	if got := cache.DebugString(); got != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, got)
+	}
+	if got := cache.DebugString(); got != want {
+		t.Errorf("Expected DebugString not to change state, got:\n%s", got)
+	}
+}
+
+func TestLFUCache_MaxFrequency(t *testing.T) {
+	config := Config{MaxSize: 3, EvictionPolicy: LFU, MaxFrequency: 10}
+	cache, err := NewLFUCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create LFU cache: %v", err)
+	}
+
+	cache.Set("hot", 1)
+	cache.Set("warm", 2)
+	for i := 0; i < 1000; i++ {
+		cache.Get("hot")
+	}
+	for i := 0; i < 50; i++ {
+		cache.Get("warm")
+	}
+
+	if freq, _ := cache.FrequencyOf("hot"); freq != 10 {
+		t.Errorf("Expected hot to plateau at 10, got %d", freq)
+	}
+	if freq, _ := cache.FrequencyOf("warm"); freq != 10 {
+		t.Errorf("Expected warm to plateau at 10, got %d", freq)
+	}
+	if len(cache.freqMap) != 1 {
+		t.Errorf("Expected both keys to share the top bucket, got %d buckets", len(cache.freqMap))
+	}
+
+	// Capped keys still order by recency within the bucket: hot was read
+	// least recently, so it goes first
+	if candidate, _ := cache.EvictionCandidate(); candidate != "hot" {
+		t.Errorf("Expected hot to be the next candidate, got %s", candidate)
+	}
+
+	if _, err := NewLFUCache(Config{MaxSize: 1, MaxFrequency: -1}); !errors.Is(err, ErrInvalidMaxFrequency) {
+		t.Errorf("Expected ErrInvalidMaxFrequency, got %v", err)
+	}
+}
+
+func TestLFUCache_MaxFrequencyBuckets(t *testing.T) {
+	const keys = 40
+	cache, err := NewLFUCache(Config{MaxSize: keys, EvictionPolicy: LFU, MaxFrequencyBuckets: 8})
+	if err != nil {
+		t.Fatalf("Failed to create LFU cache: %v", err)
+	}
+
+	// key1 is read once, key2 twice and so on, giving every key its own
+	// frequency.
+	for i := 1; i <= keys; i++ {
+		key := "key" + strconv.Itoa(i)
+		cache.Set(key, i)
+		for j := 1; j < i; j++ {
+			cache.Get(key)
+		}
+		if len(cache.freqMap) > 8 {
+			t.Fatalf("Expected at most 8 buckets, got %d after %s", len(cache.freqMap), key)
+		}
+	}
+	if err := cache.checkInvariants(); err != nil {
+		t.Fatalf("Invariant violated after compaction: %v", err)
+	}
+
+	// Eviction still runs from least to most frequently used
+	for i := 1; i <= keys; i++ {
+		want := "key" + strconv.Itoa(i)
+		candidate, _ := cache.EvictionCandidate()
+		if candidate != want {
+			t.Fatalf("Expected %s to be evicted next, got %s", want, candidate)
+		}
+		cache.Delete(candidate)
+	}
+
+	if _, err := NewLFUCache(Config{MaxSize: 1, MaxFrequencyBuckets: -1}); !errors.Is(err, ErrInvalidMaxFrequencyBuckets) {
+		t.Errorf("Expected ErrInvalidMaxFrequencyBuckets, got %v", err)
+	}
+}
+
+func TestLFUCache_MaxFrequencyBucketsNewKeys(t *testing.T) {
+	const keys = 10
+	cache, err := NewLFUCache(Config{MaxSize: keys, EvictionPolicy: LFU, MaxFrequencyBuckets: 4})
+	if err != nil {
+		t.Fatalf("Failed to create LFU cache: %v", err)
+	}
+	for i := 1; i <= keys; i++ {
+		key := "key" + strconv.Itoa(i)
+		cache.Set(key, i)
+		for j := 1; j < i; j++ {
+			cache.Get(key)
+		}
+	}
+
+	// Keys added after a compaction are colder than any compacted entry,
+	// so each one is the next to go rather than a hot key
+	for i := 0; i < 5; i++ {
+		key := "new" + strconv.Itoa(i)
+		cache.Set(key, i)
+		if candidate, _ := cache.EvictionCandidate(); candidate != key {
+			t.Errorf("Expected %s to be evicted next, got %s", key, candidate)
+		}
+	}
+	for i := 2; i <= keys; i++ {
+		if _, ok := cache.Peek("key" + strconv.Itoa(i)); !ok {
+			t.Errorf("Expected key%d to survive the new keys", i)
+		}
+	}
+	if err := cache.checkInvariants(); err != nil {
+		t.Errorf("Invariant violated: %v", err)
+	}
+}
@@ -2,16 +2,107 @@ package littlecache
 
 import (
 	"errors"
+	"math"
+	"reflect"
 	"time"
 )
 
-type LittleCacheError error
+// LittleCacheError records the operation that failed and the underlying
+// sentinel error, which callers can match with errors.Is. Cache is the
+// Config.Name of the cache involved, if it has one.
+type LittleCacheError struct {
+	Cache string
+	Op    string
+	Err   error
+}
+
+func (e *LittleCacheError) Error() string {
+	if e.Cache != "" {
+		return "littlecache: " + e.Cache + ": " + e.Op + ": " + e.Err.Error()
+	}
+	return "littlecache: " + e.Op + ": " + e.Err.Error()
+}
+
+func (e *LittleCacheError) Unwrap() error {
+	return e.Err
+}
+
+func newError(op string, err error) error {
+	return &LittleCacheError{Op: op, Err: err}
+}
 
 var (
 	// ErrInvalidMaxSize is returned when the MaxSize in the config is invalid.
 	ErrInvalidMaxSize = errors.New("invalid MaxSize: must be greater than 0")
+	// ErrMaxSizeTooLarge is returned when a MaxSize or Resize argument exceeds MaxCapacity.
+	ErrMaxSizeTooLarge = errors.New("invalid MaxSize: exceeds MaxCapacity")
 	// ErrInvalidEvictionPolicy is returned when the EvictionPolicy in the config is invalid.
 	ErrInvalidEvictionPolicy = errors.New("invalid EvictionPolicy")
+	// ErrInvalidMaxWeight is returned when the MaxWeight in the config is negative,
+	// or so large that the total weight could overflow.
+	ErrInvalidMaxWeight = errors.New("invalid MaxWeight: out of range")
+	// ErrInvalidWeight is returned when an entry weight is not positive.
+	ErrInvalidWeight = errors.New("invalid weight: must be greater than 0")
+	// ErrWeightTooLarge is returned when a single entry outweighs MaxWeight.
+	ErrWeightTooLarge = errors.New("weight exceeds MaxWeight")
+	// ErrWeightOverflow is returned when adding an entry would overflow the total weight.
+	ErrWeightOverflow = errors.New("total weight would overflow")
+	// ErrInvalidCompressThreshold is returned when the CompressThreshold in the config is negative.
+	ErrInvalidCompressThreshold = errors.New("invalid CompressThreshold: must not be negative")
+	// ErrInvalidInterval is returned when a background task interval is not positive.
+	ErrInvalidInterval = errors.New("invalid interval: must be greater than 0")
+	// ErrInvalidMaxConcurrentLoads is returned when the MaxConcurrentLoads in the config is negative.
+	ErrInvalidMaxConcurrentLoads = errors.New("invalid MaxConcurrentLoads: must not be negative")
+	// ErrInvalidBreaker is returned when a circuit breaker setting in the config is negative.
+	ErrInvalidBreaker = errors.New("invalid circuit breaker settings: must not be negative")
+	// ErrCircuitOpen is returned by GetOrCompute for a miss while the circuit breaker is open.
+	ErrCircuitOpen = errors.New("circuit breaker is open")
+	// ErrInvalidShardCount is returned when a ShardedCache has no shards or more shards than MaxSize.
+	ErrInvalidShardCount = errors.New("invalid shard count: must be between 1 and MaxSize")
+	// ErrInvalidSampleSize is returned when the SampleSize in the config is negative.
+	ErrInvalidSampleSize = errors.New("invalid SampleSize: must not be negative")
+	// ErrInvalidMaxFrequency is returned when the MaxFrequency in the config is negative.
+	ErrInvalidMaxFrequency = errors.New("invalid MaxFrequency: must not be negative")
+	// ErrInvalidMaxFrequencyBuckets is returned when the MaxFrequencyBuckets in the config is negative.
+	ErrInvalidMaxFrequencyBuckets = errors.New("invalid MaxFrequencyBuckets: must not be negative")
+	// ErrNotIterable is returned when a cache can't list its entries.
+	ErrNotIterable = errors.New("cache does not support iteration")
+	// ErrNilUnderlyingCache is returned when a TTLConfig has no UnderlyingCache.
+	ErrNilUnderlyingCache = errors.New("invalid UnderlyingCache: must not be nil")
+	// ErrInvalidDefaultTTL is returned when the DefaultTTL in a TTLConfig is negative.
+	ErrInvalidDefaultTTL = errors.New("invalid DefaultTTL: must not be negative")
+	// ErrCacheFullyPinned is returned when a new key can't be stored because every slot holds a pinned entry.
+	ErrCacheFullyPinned = errors.New("cache is full of pinned entries")
+	// ErrInvalidAutoTune is returned when an enabled AutoTune has bad bounds, a
+	// TargetHitRate outside (0, 1], or is combined with Unsynchronized.
+	ErrInvalidAutoTune = errors.New("invalid AutoTune settings")
+	// ErrInvalidEvictionHistory is returned when the EvictionHistory in the config is negative or too large.
+	ErrInvalidEvictionHistory = errors.New("invalid EvictionHistory: must be between 0 and MaxCapacity")
+	// ErrInvalidMaxValueBytes is returned when the MaxValueBytes in the config is negative.
+	ErrInvalidMaxValueBytes = errors.New("invalid MaxValueBytes: must not be negative")
+	// ErrInvalidRecord is wrapped by LoadNDJSON for each line it can't decode.
+	ErrInvalidRecord = errors.New("invalid NDJSON record")
+	// ErrReadOnly is returned, or panicked with, when a read-only view is written to.
+	ErrReadOnly = errors.New("cache is read-only")
+	// ErrInvalidCallbackTimeout is returned when the CallbackTimeout in a config is negative.
+	ErrInvalidCallbackTimeout = errors.New("invalid CallbackTimeout: must not be negative")
+	// ErrCallbackTimeout is returned by GetOrCompute when compute outlives the CallbackTimeout.
+	ErrCallbackTimeout = errors.New("callback timed out")
+	// ErrCallbackPanic is returned by GetOrCompute when compute panics.
+	ErrCallbackPanic = errors.New("callback panicked")
+	// ErrInvalidSlidingThreshold is returned when the SlidingThreshold in a TTLConfig is negative.
+	ErrInvalidSlidingThreshold = errors.New("invalid SlidingThreshold: must not be negative")
+	// ErrNilLocalCache is returned when a ClusterConfig has no Local cache.
+	ErrNilLocalCache = errors.New("invalid Local: must not be nil")
+	// ErrInvalidVirtualNodes is returned when the VirtualNodes in a ClusterConfig is negative.
+	ErrInvalidVirtualNodes = errors.New("invalid VirtualNodes: must not be negative")
+	// ErrNoNodes is returned by a ClusterCache that has no nodes to route a key to.
+	ErrNoNodes = errors.New("cluster has no nodes")
+	// ErrInvalidPreallocFraction is returned when the PreallocFraction in a config is above 1.
+	ErrInvalidPreallocFraction = errors.New("invalid PreallocFraction: must not be greater than 1")
+	// ErrUnsupportedConfig is returned when a Config field is set that the
+	// cache being built has no support for.
+	ErrUnsupportedConfig = errors.New("unsupported Config field")
 )
 
 type EvictionPolicy int
@@ -25,28 +116,415 @@ const (
 	LFU
 	// TTL indicates that the Time To Live eviction policy is applied.
 	TTL
+	// SecondChance indicates the CLOCK approximation of LRU, where a read
+	// only sets a reference bit.
+	SecondChance
+	// WeightedRandom indicates random eviction weighted toward the least
+	// frequently used keys.
+	WeightedRandom
+)
+
+// EvictionReason tells an OnEvict callback why an entry left the cache.
+type EvictionReason int
+
+const (
+	// CapacityEviction means the entry was evicted to make room, by a Set,
+	// Resize or Trim.
+	CapacityEviction EvictionReason = iota
+	// Expired means the entry's TTL ran out.
+	Expired
+	// Deleted means the entry was removed by Delete.
+	Deleted
+	// Cleared means the entry was removed by Clear or ReplaceAll.
+	Cleared
+	// Replaced means a write overwrote the entry; the callback receives the
+	// old value.
+	Replaced
 )
 
+func (r EvictionReason) String() string {
+	switch r {
+	case CapacityEviction:
+		return "capacity"
+	case Expired:
+		return "expired"
+	case Deleted:
+		return "deleted"
+	case Cleared:
+		return "cleared"
+	case Replaced:
+		return "replaced"
+	default:
+		return "unknown"
+	}
+}
+
 type LittleCache interface {
 	// Set adds a key-value pair to the cache.
 	Set(key string, value interface{})
+	// SetWithTTL adds a key-value pair that expires after ttl. Caches that
+	// expire entries (TTLCache, LRUTTLCache, and ShardedCache or codec
+	// wrappers around them) honor ttl; the others treat it as Set.
+	SetWithTTL(key string, value interface{}, ttl time.Duration)
 	// Get retrieves a value from the cache by key.
 	Get(key string) (interface{}, bool)
+	// Swap stores value and returns the previous value, if any.
+	Swap(key string, value interface{}) (interface{}, bool)
 	// Delete removes a key-value pair from the cache by key.
 	Delete(key string)
 	// Clear removes all key-value pairs from the cache.
 	Clear()
-	// Size returns the number of key-value pairs in the cache.
+	// Size returns the number of key-value pairs in the cache. A Set that
+	// evicts does so under the same lock as the insert, so Size never
+	// observes the cache above its capacity. Entries a shrinking Resize
+	// keeps, pinned ones or all of a NoEviction cache's, are the only
+	// exception.
 	Size() int
 	// Resize changes the capacity of the cache.
 	Resize(newSize int) error
 }
 
 type Config struct {
+	// Name labels the cache in Stats and error messages, to tell caches
+	// apart in logs and metrics. It has no effect on behavior.
+	Name string
 	// MaxSize defines the maximum number of items the cache can hold.
 	MaxSize int
 	// EvictionPolicy defines the eviction policy to use when the cache is full.
 	EvictionPolicy EvictionPolicy
+	// MaxWeight caps the total weight of an LRU cache's entries. Entries set
+	// without an explicit weight count as 1. Zero disables weight limits.
+	MaxWeight int
+	// Compressor, when set, compresses []byte values longer than
+	// CompressThreshold before they are stored. Get decompresses them
+	// transparently. Only applied to caches built with NewLittleCache.
+	Compressor Compressor
+	// CompressThreshold is the length in bytes above which values are compressed.
+	CompressThreshold int
+	// Cipher, when set, encrypts []byte values before they are stored and
+	// decrypts them on Get. Keys are not encrypted. Only applied to caches
+	// built with NewLittleCache.
+	Cipher Cipher
+	// CopyByteValues makes Set store a copy of each []byte value, so the
+	// caller reusing or mutating its slice afterwards can't change what
+	// readers see. Each such Set allocates a copy of the value.
+	// CopyByteValuesOnGet likewise makes Get, Swap and Dump return copies,
+	// so readers can't change the cached value either; that allocates on
+	// every read. Values a Compressor or Cipher transforms are copied
+	// anyway. Only applied to caches built with NewLittleCache.
+	CopyByteValues      bool
+	CopyByteValuesOnGet bool
+	// Disabled makes NewLittleCache return a NullCache, turning caching off
+	// without changing call sites.
+	Disabled bool
+	// Clock is the time source for background tasks. Defaults to the
+	// system clock.
+	Clock Clock
+	// MaxConcurrentLoads limits how many GetOrCompute computations a
+	// LoadingCache runs at once. Further callers block until a slot frees
+	// up. Zero means no limit.
+	MaxConcurrentLoads int
+	// BreakerThreshold is the number of consecutive GetOrCompute failures,
+	// within BreakerWindow, that opens a LoadingCache's circuit breaker.
+	// While open, misses fail fast with ErrCircuitOpen instead of calling
+	// compute. After BreakerCooldown a single trial load is let through;
+	// success closes the circuit, failure reopens it. Zero disables the
+	// breaker, and a zero BreakerWindow counts failures without a time limit.
+	BreakerThreshold int
+	BreakerWindow    time.Duration
+	BreakerCooldown  time.Duration
+	// ImmutableKeys makes Set, and SetWithWeight, ignore keys that are
+	// already cached: the original value stays and the eviction order is
+	// left alone. Swap still replaces values.
+	ImmutableKeys bool
+	// SkipEqualWrites makes Set a no-op when the key already holds a value
+	// Equal to the new one: the entry isn't promoted or counted as an
+	// access, and OnEvict doesn't hear about a replacement. Swap, and
+	// writes of a different value, behave as usual. With a Compressor or
+	// Cipher the values are compared decoded, so an equal write is still
+	// skipped although every sealing of it differs.
+	SkipEqualWrites bool
+	// Equal compares values for SkipEqualWrites. Defaults to
+	// reflect.DeepEqual.
+	Equal func(a, b interface{}) bool
+	// OnClear, if set, receives every entry just before Clear empties a
+	// DefCache, LRUCache, LFUCache, RingCache, SecondChanceCache or
+	// WeightedRandomCache, so pending data can be flushed. It runs under
+	// the write lock and must not call back into the cache.
+	OnClear func(snapshot map[string]interface{})
+	// OnEvict, if set, is called for every entry that leaves a DefCache,
+	// LRUCache, LFUCache, RingCache, SecondChanceCache or
+	// WeightedRandomCache, and for every overwritten value, with the reason. Drain doesn't call it, since the
+	// caller receives the entries. Like OnClear, it runs under the write
+	// lock and must not call back into the cache. TTLCache has its own
+	// TTLConfig.OnEvict for expiry.
+	OnEvict func(key string, value interface{}, reason EvictionReason)
+	// EvictionHistory keeps the last this many capacity evictions and
+	// expiries, with their time and reason, for RecentEvictions. Zero
+	// disables it.
+	EvictionHistory int
+	// NoPromoteOnGet stops LRUCache.Get from moving the key to the front
+	// of the recency list, so reads don't extend an entry's lifetime; only
+	// writes do. Get then needs only the read lock.
+	NoPromoteOnGet bool
+	// TrackAccessTime makes an LRUCache stamp entries with Clock's time
+	// whenever they are set or read, for LastAccess.
+	TrackAccessTime bool
+	// TrackAccessCounts makes a DefCache, LRUCache or LFUCache count every
+	// Get that hits each key, for AccessCount. The count is for usage
+	// analytics and is separate from LFU's eviction frequency, which Set
+	// also bumps and MaxFrequency caps. It starts at zero when a key is
+	// stored and is dropped when the key leaves the cache.
+	TrackAccessCounts bool
+	// TrackLockWait makes DefCache, LRUCache, LFUCache, RingCache,
+	// SecondChanceCache and WeightedRandomCache time how long callers wait
+	// for the cache lock, reported by Stats. It adds a clock read to every contended
+	// acquisition, so it is off by default.
+	TrackLockWait bool
+	// Unsynchronized makes DefCache, LRUCache, LFUCache, RingCache,
+	// SecondChanceCache and WeightedRandomCache skip locking entirely. Such a cache is NOT safe for
+	// concurrent use: only enable it when a single goroutine owns the
+	// cache. TrackLockWait has no effect on an unsynchronized cache.
+	Unsynchronized bool
+	// AutoTune, when its Interval is set, runs a goroutine that resizes an
+	// LRUCache or LFUCache toward a target hit rate. Stop it with Stop.
+	AutoTune AutoTuneConfig
+	// MaxFrequency caps LFU access counts. Keys at the cap stay in the top
+	// frequency bucket, which keeps the number of buckets bounded. It caps
+	// a WeightedRandomCache's counts too, and with them how unlikely a hot
+	// key's eviction can get. Defaults to 65536.
+	MaxFrequency int
+	// MaxFrequencyBuckets bounds how many distinct frequency buckets an
+	// LFUCache keeps. When a new bucket would exceed it, adjacent buckets
+	// are merged and renumbered 1, 2, ... down to half the limit. The
+	// current eviction order is kept exactly, but FrequencyOf then reports
+	// the compacted rank rather than the raw count. Zero disables it.
+	MaxFrequencyBuckets int
+	// SampleSize is how many random entries a SampledLRUCache compares when
+	// it needs to evict. Defaults to 5.
+	SampleSize int
+	// RandomSeed seeds a WeightedRandomCache's choice of victims, so tests
+	// can replay the same evictions. Zero picks a random seed.
+	RandomSeed uint64
+	// ShardHasher picks a ShardedCache's shard for a key. Defaults to
+	// 64-bit FNV-1a.
+	ShardHasher func(key string) uint64
+	// Admit, when set, is consulted before every Set with the cache's
+	// current entry count and MaxSize. Returning false drops the write
+	// without evicting anything.
+	Admit func(key string, value interface{}, currentSize, capacity int) bool
+	// MaxValueBytes, when positive, rejects any Set whose value SizeOf
+	// measures above it, the way Admit does: nothing is evicted and an
+	// existing value for the key is kept. It stops one huge value from
+	// crowding out many small ones.
+	MaxValueBytes int64
+	// SizeOf measures a value for MaxValueBytes and MemoryUsage. The
+	// default counts the bytes of a []byte or string, after any
+	// compression, and treats other types as size 0. A custom SizeOf, like
+	// Admit, Equal, OnEvict and OnClear, is given values as the caller set
+	// them, before any Compressor or Cipher.
+	SizeOf func(value interface{}) int64
+	// PreallocFraction is the share of MaxSize a DefCache, LRUCache,
+	// LFUCache, SecondChanceCache or WeightedRandomCache reserves map room
+	// for when it is built, so filling it doesn't rehash the map as it
+	// grows. The reservation is capped at 131072 entries, so a huge MaxSize
+	// used as "unbounded" doesn't claim memory up front. Zero means 1, the
+	// whole MaxSize; a negative value turns preallocation off.
+	PreallocFraction float64
+
+	// CallbackTimeout, when positive, bounds how long the cache waits for
+	// OnEvict, OnClear and, in a LoadingCache, the compute function. Each
+	// callback runs on its own goroutine; one that overruns is counted in
+	// Stats.SlowCallbacks and the cache carries on without it. A callback
+	// is still called at most once, but may then run on after the
+	// operation that triggered it has returned, and a slow compute fails
+	// with ErrCallbackTimeout, its eventual result dropped. Zero runs
+	// callbacks inline, as before.
+	CallbackTimeout time.Duration
+	// OnCallbackPanic, if set, receives the value OnEvict, OnClear or a
+	// LoadingCache's compute function panicked with. Such a panic is
+	// recovered rather than let unwind through the cache or crash the TTL
+	// cleanup goroutine: the operation that called the callback finishes
+	// as if it had returned, the panic is counted in Stats.CallbackPanics,
+	// and a panicking compute fails its load with ErrCallbackPanic. Use it
+	// to log the panic; it must not panic itself. Functions the cache
+	// consults for a decision, such as Admit, Equal or SizeOf, are not
+	// guarded.
+	OnCallbackPanic func(recovered interface{})
+
+	// history is set by the constructor when EvictionHistory is on. The
+	// shards of a ShardedCache inherit and share it.
+	history *historyRing
+	// callbacks enforces CallbackTimeout; it is nil when there is none.
+	// Shared like history.
+	callbacks *callbackGuard
+	// evictHook is the chain of chainOnEvict hooks.
+	evictHook func(key string, value interface{}, reason EvictionReason)
+	// decode is set by NewLittleCache when a codecCache wraps the cache.
+	// It turns stored values back into the caller's for the callbacks.
+	decode func(stored interface{}) (interface{}, bool)
+}
+
+// Entry is a point-in-time copy of a cached key-value pair.
+type Entry struct {
+	Key   string
+	Value interface{}
+	// TTL is the remaining time to live, or zero for caches without expiry.
+	TTL time.Duration
+	// Rank is the recency position in an LRU cache, 0 being the most
+	// recently used. It is zero for other policies.
+	Rank int
+}
+
+// error is newError labeled with the cache's Name.
+func (c *Config) error(op string, err error) error {
+	return &LittleCacheError{Cache: c.Name, Op: op, Err: err}
+}
+
+// evicted records key in the history and calls OnEvict, if set, then any
+// hook a wrapping cache chained on.
+func (c *Config) evicted(key string, value interface{}, reason EvictionReason) {
+	c.history.observe(key, reason)
+	c.notify(key, value, reason)
+	if c.evictHook != nil {
+		c.evictHook(key, value, reason)
+	}
+}
+
+// notify calls OnEvict, if set, within CallbackTimeout.
+func (c *Config) notify(key string, value interface{}, reason EvictionReason) {
+	if c.OnEvict != nil {
+		value = c.userValue(value)
+		c.callbacks.run(func() { c.OnEvict(key, value, reason) })
+	}
+}
+
+// cleared hands snapshot to OnClear within CallbackTimeout. OnClear may
+// outlive the call, so the cache must not modify snapshot afterwards.
+func (c *Config) cleared(snapshot map[string]interface{}) {
+	if c.decode != nil {
+		decoded := make(map[string]interface{}, len(snapshot))
+		for key, value := range snapshot {
+			decoded[key] = c.userValue(value)
+		}
+		snapshot = decoded
+	}
+	c.callbacks.run(func() { c.OnClear(snapshot) })
+}
+
+// userValue undoes a codecCache's encoding of value, so callbacks never
+// see compressed or sealed bytes. A value that fails to decode is passed
+// on as nil.
+func (c *Config) userValue(value interface{}) interface{} {
+	if c.decode == nil {
+		return value
+	}
+	decoded, ok := c.decode(value)
+	if !ok {
+		return nil
+	}
+	return decoded
+}
+
+// initShared allocates the state the shards of a ShardedCache share, the
+// eviction history and the callback guard, unless a ShardedCache or a
+// LoadingCache has already passed its own down.
+func (c *Config) initShared() {
+	if c.EvictionHistory > 0 && c.history == nil {
+		c.history = newHistoryRing(c.EvictionHistory, c.Clock)
+	}
+	if c.callbacks == nil {
+		c.callbacks = newCallbackGuard(c.CallbackTimeout, c.OnCallbackPanic)
+	}
+}
+
+// chainOnEvict adds fn to the hooks evicted calls after OnEvict. Unlike
+// OnEvict, hooks run synchronously whatever the CallbackTimeout, since
+// they update the wrapping cache under its lock.
+func (c *Config) chainOnEvict(fn func(key string, value interface{}, reason EvictionReason)) {
+	previous := c.evictHook
+	if previous == nil {
+		c.evictHook = fn
+		return
+	}
+	c.evictHook = func(key string, value interface{}, reason EvictionReason) {
+		previous(key, value, reason)
+		fn(key, value, reason)
+	}
+}
+
+// configOf returns the Config c was built with, for a wrapping cache that
+// must follow the same rules, or nil if c isn't one of ours. Only fields
+// fixed at construction may be read from it without c's lock.
+func configOf(c LittleCache) *Config {
+	switch c := c.(type) {
+	case *DefCache:
+		return &c.config
+	case *LRUCache:
+		return &c.config
+	case *LFUCache:
+		return &c.config
+	case *RingCache:
+		return &c.config
+	case *SecondChanceCache:
+		return &c.config
+	case *WeightedRandomCache:
+		return &c.config
+	case *SampledLRUCache:
+		return &c.config
+	case *LRUTTLCache:
+		return &c.lru.config
+	case *ShardedCache:
+		return &c.config
+	case *codecCache:
+		return configOf(c.cache)
+	}
+	return nil
+}
+
+// admits reports whether value fits MaxValueBytes and the Admit callback,
+// if any, accepts the write.
+func (c *Config) admits(key string, value interface{}, currentSize int) bool {
+	if c.MaxValueBytes > 0 && c.sizeOf(value) > c.MaxValueBytes {
+		return false
+	}
+	return c.Admit == nil || c.Admit(key, c.userValue(value), currentSize, c.MaxSize)
+}
+
+// sizeOf measures value with SizeOf, or defaultSizeOf if it is unset. The
+// default measures the stored form, which is what takes up memory.
+func (c *Config) sizeOf(value interface{}) int64 {
+	if c.SizeOf != nil {
+		return c.SizeOf(c.userValue(value))
+	}
+	return defaultSizeOf(value)
+}
+
+// unchanged reports whether SkipEqualWrites applies to overwriting
+// existing with value.
+func (c *Config) unchanged(existing, value interface{}) bool {
+	if !c.SkipEqualWrites {
+		return false
+	}
+	existing, value = c.userValue(existing), c.userValue(value)
+	if c.Equal != nil {
+		return c.Equal(existing, value)
+	}
+	return reflect.DeepEqual(existing, value)
+}
+
+// defaultSizeOf measures byte slices and strings, including []byte values
+// a codecCache has encoded.
+func defaultSizeOf(value interface{}) int64 {
+	switch v := value.(type) {
+	case []byte:
+		return int64(len(v))
+	case string:
+		return int64(len(v))
+	case encodedValue:
+		return int64(len(v.data))
+	}
+	return 0
 }
 
 func DefaultConfig() Config {
@@ -56,31 +534,148 @@ func DefaultConfig() Config {
 	}
 }
 
-func (c *Config) Validate() error {
-	if c.MaxSize <= 0 {
+// MaxCapacity is the largest MaxSize, or Resize argument, a cache accepts.
+// Size counters briefly step one past capacity before evicting, and this
+// keeps that step from wrapping around even where int is 32 bits.
+const MaxCapacity = math.MaxInt32 - 1
+
+// maxPrealloc caps how many entries a Resize, or an LRU or LFU Clear,
+// reserves map room for.
+const maxPrealloc = 1 << 20
+
+// maxInitialPrealloc caps how many entries a constructor reserves map room
+// for, whatever PreallocFraction asks.
+const maxInitialPrealloc = 1 << 17
+
+// initialMapSize is how many entries a new cache reserves map room for.
+func (c *Config) initialMapSize() int {
+	fraction := c.PreallocFraction
+	if fraction < 0 {
+		return 0
+	}
+	if fraction == 0 {
+		fraction = 1
+	}
+	return min(int(fraction*float64(c.MaxSize)), maxInitialPrealloc)
+}
+
+// preallocSize reports whether a Resize from oldSize to newSize is large
+// enough for LRU and LFU caches to rebuild their lookup map, and for how
+// many entries. One copy up front saves the repeated rehashing of growing
+// the map entry by entry, at the cost of reserving memory for entries that
+// may never arrive; the reservation is capped at maxPrealloc.
+func preallocSize(oldSize, newSize int) (int, bool) {
+	if newSize < 2*oldSize || newSize < 1024 {
+		return 0, false
+	}
+	return min(newSize, maxPrealloc), true
+}
+
+// checkSize returns the sentinel for an unusable capacity, or nil.
+func checkSize(size int) error {
+	if size <= 0 {
 		return ErrInvalidMaxSize
 	}
-	if c.EvictionPolicy < NoEviction || c.EvictionPolicy > TTL {
+	if size > MaxCapacity {
+		return ErrMaxSizeTooLarge
+	}
+	return nil
+}
+
+func (c *Config) Validate() error {
+	if err := checkSize(c.MaxSize); err != nil {
+		return err
+	}
+	if c.EvictionPolicy < NoEviction || c.EvictionPolicy > WeightedRandom {
 		return ErrInvalidEvictionPolicy
 	}
+	// The total weight can briefly reach twice MaxWeight before eviction.
+	if c.MaxWeight < 0 || int64(c.MaxWeight) > math.MaxInt64/2 {
+		return ErrInvalidMaxWeight
+	}
+	if c.CompressThreshold < 0 {
+		return ErrInvalidCompressThreshold
+	}
+	if c.MaxConcurrentLoads < 0 {
+		return ErrInvalidMaxConcurrentLoads
+	}
+	if c.MaxFrequency < 0 {
+		return ErrInvalidMaxFrequency
+	}
+	if c.MaxFrequencyBuckets < 0 {
+		return ErrInvalidMaxFrequencyBuckets
+	}
+	if c.MaxValueBytes < 0 {
+		return ErrInvalidMaxValueBytes
+	}
+	if err := c.AutoTune.validate(); err != nil {
+		return err
+	}
+	// The tuner resizes from its own goroutine.
+	if c.AutoTune.Interval != 0 && c.Unsynchronized {
+		return ErrInvalidAutoTune
+	}
+	if c.EvictionHistory < 0 || c.EvictionHistory > MaxCapacity {
+		return ErrInvalidEvictionHistory
+	}
+	if c.SampleSize < 0 {
+		return ErrInvalidSampleSize
+	}
+	if c.BreakerThreshold < 0 || c.BreakerWindow < 0 || c.BreakerCooldown < 0 {
+		return ErrInvalidBreaker
+	}
+	if c.CallbackTimeout < 0 {
+		return ErrInvalidCallbackTimeout
+	}
+	if c.PreallocFraction > 1 || math.IsNaN(c.PreallocFraction) {
+		return ErrInvalidPreallocFraction
+	}
 	return nil
 }
 
 func NewLittleCache(config Config) (LittleCache, error) {
 	if err := config.Validate(); err != nil {
-		return nil, err
+		return nil, config.error("new", err)
+	}
+
+	if config.Disabled {
+		return NewNullCache(), nil
+	}
+
+	// The codecCache is built first so the wrapped cache's callbacks can
+	// decode what it stores.
+	var codec *codecCache
+	if wantsCodec(config) && config.EvictionPolicy != TTL {
+		codec = newCodecCache(nil, config)
+		config.decode = codec.decode
 	}
 
+	var cache LittleCache
+	var err error
+
 	switch config.EvictionPolicy {
 	case NoEviction:
-		return NewDefCache(config)
+		cache, err = NewDefCache(config)
 	case LRU:
-		return NewLRUCache(config)
+		cache, err = NewLRUCache(config)
 	case LFU:
-		return NewLFUCache(config)
+		cache, err = NewLFUCache(config)
 	case TTL:
 		return NewTTLCacheFromConfig(config, time.Duration(5*time.Minute))
+	case SecondChance:
+		cache, err = NewSecondChanceCache(config)
+	case WeightedRandom:
+		cache, err = NewWeightedRandomCache(config)
 	default:
-		return nil, ErrInvalidEvictionPolicy
+		return nil, config.error("new", ErrInvalidEvictionPolicy)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if codec != nil {
+		codec.cache = cache
+		return codec, nil
 	}
+	return cache, nil
 }
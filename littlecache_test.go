@@ -1,7 +1,17 @@
 package littlecache
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -44,6 +54,12 @@ func TestConfigValidate(t *testing.T) {
 			expectError: true,
 			errorMsg:    "invalid EvictionPolicy",
 		},
+		{
+			name:        "invalid MaxWeight negative",
+			config:      Config{MaxSize: 10, EvictionPolicy: LRU, MaxWeight: -1},
+			expectError: true,
+			errorMsg:    "invalid MaxWeight: out of range",
+		},
 	}
 
 	for _, tt := range tests {
@@ -106,3 +122,1341 @@ func TestNewLittleCache(t *testing.T) {
 		}
 	})
 }
+
+func TestErrors_Resize(t *testing.T) {
+	config := Config{MaxSize: 10, EvictionPolicy: LRU}
+
+	def, _ := NewDefCache(config)
+	lru, _ := NewLRUCache(config)
+	lfu, _ := NewLFUCache(config)
+	ring, _ := NewRingCache(config)
+	ttl, _ := NewTTLCacheFromConfig(config, time.Minute)
+	defer ttl.Stop()
+	generic, _ := NewCache[string, int](config)
+
+	caches := map[string]interface{ Resize(int) error }{
+		"def":     def,
+		"lru":     lru,
+		"lfu":     lfu,
+		"ring":    ring,
+		"null":    NewNullCache(),
+		"ttl":     ttl,
+		"generic": generic,
+	}
+
+	for name, cache := range caches {
+		for _, size := range []int{0, -1} {
+			err := cache.Resize(size)
+			if !errors.Is(err, ErrInvalidMaxSize) {
+				t.Errorf("%s: expected ErrInvalidMaxSize for Resize(%d), got %v", name, size, err)
+			}
+
+			var cacheErr *LittleCacheError
+			if !errors.As(err, &cacheErr) || cacheErr.Op != "resize" {
+				t.Errorf("%s: expected *LittleCacheError with op resize, got %v", name, err)
+			}
+		}
+	}
+}
+
+func TestCapacityBounds(t *testing.T) {
+	config := Config{MaxSize: 10, EvictionPolicy: LRU}
+
+	def, _ := NewDefCache(config)
+	lru, _ := NewLRUCache(config)
+	lfu, _ := NewLFUCache(config)
+	ring, _ := NewRingCache(config)
+	sampled, _ := NewSampledLRUCache(config)
+	sharded, _ := NewShardedCache(config, 2)
+	generic, _ := NewCache[string, int](config)
+
+	caches := map[string]interface{ Resize(int) error }{
+		"def":     def,
+		"lru":     lru,
+		"lfu":     lfu,
+		"ring":    ring,
+		"sampled": sampled,
+		"sharded": sharded,
+		"null":    NewNullCache(),
+		"generic": generic,
+	}
+
+	for name, cache := range caches {
+		tests := []struct {
+			size int
+			want error
+		}{
+			{0, ErrInvalidMaxSize},
+			{-1, ErrInvalidMaxSize},
+			{math.MinInt, ErrInvalidMaxSize},
+			{math.MaxInt32, ErrMaxSizeTooLarge},
+			{math.MaxInt, ErrMaxSizeTooLarge},
+		}
+		for _, tt := range tests {
+			if err := cache.Resize(tt.size); !errors.Is(err, tt.want) {
+				t.Errorf("%s: expected %v for Resize(%d), got %v", name, tt.want, tt.size, err)
+			}
+		}
+	}
+
+	// Nothing is preallocated for LRU, so the largest capacity is usable
+	if err := lru.Resize(MaxCapacity); err != nil {
+		t.Errorf("Expected Resize(MaxCapacity) to succeed, got %v", err)
+	}
+	if _, err := NewLRUCache(Config{MaxSize: math.MaxInt32}); !errors.Is(err, ErrMaxSizeTooLarge) {
+		t.Errorf("Expected ErrMaxSizeTooLarge, got %v", err)
+	}
+	if _, err := NewLRUCache(Config{MaxSize: 10, MaxWeight: math.MaxInt}); !errors.Is(err, ErrInvalidMaxWeight) {
+		t.Errorf("Expected ErrInvalidMaxWeight for a MaxWeight that could overflow, got %v", err)
+	}
+}
+
+func TestLRUCache_WeightOverflow(t *testing.T) {
+	cache, err := NewLRUCache(Config{MaxSize: 10})
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+
+	if err := cache.SetWithWeight("a", 1, math.MaxInt); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := cache.SetWithWeight("b", 2, 1); !errors.Is(err, ErrWeightOverflow) {
+		t.Errorf("Expected ErrWeightOverflow, got %v", err)
+	}
+	if cache.Weight() != math.MaxInt {
+		t.Errorf("Expected the total weight to be unchanged, got %d", cache.Weight())
+	}
+
+	// Lowering the weight of an existing key can't overflow
+	if err := cache.SetWithWeight("a", 1, 5); err != nil {
+		t.Errorf("Unexpected error lowering a weight: %v", err)
+	}
+	if err := cache.SetWithWeight("b", 2, 1); err != nil {
+		t.Errorf("Unexpected error once there is room: %v", err)
+	}
+	if cache.Weight() != 6 {
+		t.Errorf("Expected weight 6, got %d", cache.Weight())
+	}
+}
+
+func TestErrors_Constructors(t *testing.T) {
+	badSize := Config{MaxSize: 0, EvictionPolicy: LRU}
+	badPolicy := Config{MaxSize: 10, EvictionPolicy: 99}
+
+	constructors := map[string]func(Config) error{
+		"NewLittleCache": func(c Config) error { _, err := NewLittleCache(c); return err },
+		"NewDefCache":    func(c Config) error { _, err := NewDefCache(c); return err },
+		"NewLRUCache":    func(c Config) error { _, err := NewLRUCache(c); return err },
+		"NewLFUCache":    func(c Config) error { _, err := NewLFUCache(c); return err },
+		"NewRingCache":   func(c Config) error { _, err := NewRingCache(c); return err },
+		"NewCache":       func(c Config) error { _, err := NewCache[string, int](c); return err },
+	}
+
+	for name, construct := range constructors {
+		if err := construct(badSize); !errors.Is(err, ErrInvalidMaxSize) {
+			t.Errorf("%s: expected ErrInvalidMaxSize, got %v", name, err)
+		}
+		if err := construct(badPolicy); !errors.Is(err, ErrInvalidEvictionPolicy) {
+			t.Errorf("%s: expected ErrInvalidEvictionPolicy, got %v", name, err)
+		}
+	}
+}
+
+func TestLittleCacheError_Message(t *testing.T) {
+	err := newError("resize", ErrInvalidMaxSize)
+	expected := "littlecache: resize: invalid MaxSize: must be greater than 0"
+	if err.Error() != expected {
+		t.Errorf("Expected %q, got %q", expected, err.Error())
+	}
+}
+
+func TestLoadOrStore_AllCaches(t *testing.T) {
+	config := Config{MaxSize: 10, EvictionPolicy: LRU}
+	def, _ := NewDefCache(config)
+	lru, _ := NewLRUCache(config)
+	lfu, _ := NewLFUCache(config)
+	ring, _ := NewRingCache(config)
+	ttl, _ := NewTTLCacheFromConfig(config, time.Minute)
+	defer ttl.Stop()
+	sampled, _ := NewSampledLRUCache(config)
+	lruTTL, _ := NewLRUTTLCache(config, time.Minute)
+	sharded, _ := NewShardedCache(config, 2)
+	codec, _ := NewLittleCache(Config{MaxSize: 10, EvictionPolicy: LRU, Compressor: GzipCompressor{}})
+
+	caches := map[string]interface {
+		LittleCache
+		LoadOrStore(key string, value interface{}) (interface{}, bool)
+	}{
+		"def":     def,
+		"lru":     lru,
+		"lfu":     lfu,
+		"ring":    ring,
+		"ttl":     ttl,
+		"sampled": sampled,
+		"lruttl":  lruTTL,
+		"sharded": sharded,
+		"codec":   codec.(*codecCache),
+	}
+
+	for name, cache := range caches {
+		actual, loaded := cache.LoadOrStore("key", "first")
+		if loaded || actual != "first" {
+			t.Errorf("%s: expected first store to return (first, false), got (%v, %v)", name, actual, loaded)
+		}
+
+		actual, loaded = cache.LoadOrStore("key", "second")
+		if !loaded || actual != "first" {
+			t.Errorf("%s: expected second call to load (first, true), got (%v, %v)", name, actual, loaded)
+		}
+
+		if value, _ := cache.Get("key"); value != "first" {
+			t.Errorf("%s: expected stored value to stay first, got %v", name, value)
+		}
+	}
+}
+
+func TestLoadOrStore_AppliesSetChecks(t *testing.T) {
+	config := Config{
+		MaxSize:        10,
+		EvictionPolicy: LRU,
+		MaxValueBytes:  8,
+		Admit: func(key string, value interface{}, currentSize, maxSize int) bool {
+			return key != "rejected"
+		},
+	}
+	def, _ := NewDefCache(config)
+	lru, _ := NewLRUCache(config)
+	lfu, _ := NewLFUCache(config)
+	ring, _ := NewRingCache(config)
+	secondChance, _ := NewSecondChanceCache(config)
+	weighted, _ := NewWeightedRandomCache(config)
+	sampled, _ := NewSampledLRUCache(config)
+	lruTTL, _ := NewLRUTTLCache(config, time.Minute)
+
+	caches := map[string]interface {
+		LittleCache
+		LoadOrStore(key string, value interface{}) (interface{}, bool)
+		Stats() Stats
+	}{
+		"def":          def,
+		"lru":          lru,
+		"lfu":          lfu,
+		"ring":         ring,
+		"secondchance": secondChance,
+		"weighted":     weighted,
+		"sampled":      sampled,
+	}
+
+	for name, cache := range caches {
+		if actual, loaded := cache.LoadOrStore("rejected", "v"); loaded || actual != "v" {
+			t.Errorf("%s: expected a rejected store to return (v, false), got (%v, %v)", name, actual, loaded)
+		}
+		if actual, loaded := cache.LoadOrStore("big", "far too large"); loaded || actual != "far too large" {
+			t.Errorf("%s: expected an oversized store to return its value unloaded, got (%v, %v)", name, actual, loaded)
+		}
+		if cache.Size() != 0 {
+			t.Errorf("%s: expected Admit and MaxValueBytes to keep both out, got size %d", name, cache.Size())
+		}
+
+		cache.LoadOrStore("key", "v")
+		cache.LoadOrStore("key", "w")
+		if stats := cache.Stats(); stats.Hits != 1 || stats.Misses != 3 {
+			t.Errorf("%s: expected 1 hit and 3 misses, got %d and %d", name, stats.Hits, stats.Misses)
+		}
+	}
+
+	// LRUTTLCache keeps its counters on the LRUCache it wraps
+	lruTTL.LoadOrStore("rejected", "v")
+	lruTTL.LoadOrStore("key", "v")
+	lruTTL.LoadOrStore("key", "w")
+	if lruTTL.Size() != 1 {
+		t.Errorf("lruttl: expected only key to be stored, got size %d", lruTTL.Size())
+	}
+	if stats := lruTTL.lru.Stats(); stats.Hits != 1 || stats.Misses != 2 {
+		t.Errorf("lruttl: expected 1 hit and 2 misses, got %d and %d", stats.Hits, stats.Misses)
+	}
+}
+
+func TestLoadOrStore_ConcurrentSingleKey(t *testing.T) {
+	config := Config{MaxSize: 10, EvictionPolicy: LFU}
+	cache, err := NewLFUCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create LFU cache: %v", err)
+	}
+
+	const goroutines = 50
+	var wg sync.WaitGroup
+	var stores atomic.Int32
+	actuals := make([]interface{}, goroutines)
+
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			actual, loaded := cache.LoadOrStore("shared", id)
+			if !loaded {
+				stores.Add(1)
+			}
+			actuals[id] = actual
+		}(i)
+	}
+	wg.Wait()
+
+	// As with sync.Map, exactly one caller stores and everyone sees its value
+	if stores.Load() != 1 {
+		t.Fatalf("Expected exactly one store, got %d", stores.Load())
+	}
+	winner, _ := cache.Get("shared")
+	for id, actual := range actuals {
+		if actual != winner {
+			t.Errorf("Goroutine %d saw %v, expected the stored value %v", id, actual, winner)
+		}
+	}
+}
+
+func TestSwap_AllCaches(t *testing.T) {
+	config := Config{MaxSize: 10, EvictionPolicy: LRU}
+	def, _ := NewDefCache(config)
+	lru, _ := NewLRUCache(config)
+	lfu, _ := NewLFUCache(config)
+	ring, _ := NewRingCache(config)
+	ttl, _ := NewTTLCacheFromConfig(config, time.Minute)
+	defer ttl.Stop()
+
+	caches := map[string]LittleCache{
+		"def":  def,
+		"lru":  lru,
+		"lfu":  lfu,
+		"ring": ring,
+		"ttl":  ttl,
+	}
+
+	for name, cache := range caches {
+		if previous, existed := cache.Swap("key", "first"); existed || previous != nil {
+			t.Errorf("%s: expected (nil, false) for a new key, got (%v, %v)", name, previous, existed)
+		}
+		if previous, existed := cache.Swap("key", "second"); !existed || previous != "first" {
+			t.Errorf("%s: expected (first, true), got (%v, %v)", name, previous, existed)
+		}
+		if value, _ := cache.Get("key"); value != "second" {
+			t.Errorf("%s: expected second to be stored, got %v", name, value)
+		}
+	}
+}
+
+func TestSwap_ConcurrentChain(t *testing.T) {
+	config := Config{MaxSize: 10, EvictionPolicy: LRU}
+	cache, err := NewLittleCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	const goroutines = 50
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var inserts int
+	previousValues := make(map[interface{}]int)
+
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			previous, existed := cache.Swap("shared", id)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if existed {
+				previousValues[previous]++
+			} else {
+				inserts++
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if inserts != 1 {
+		t.Fatalf("Expected exactly one swap to find no previous value, got %d", inserts)
+	}
+
+	// Every value is handed back exactly once, except the last one stored
+	final, _ := cache.Get("shared")
+	for id := 0; id < goroutines; id++ {
+		want := 1
+		if id == final {
+			want = 0
+		}
+		if previousValues[id] != want {
+			t.Errorf("Expected value %d to be returned %d times, got %d", id, want, previousValues[id])
+		}
+	}
+}
+
+func TestAdmit_RejectsOversizedValues(t *testing.T) {
+	config := Config{
+		MaxSize:        3,
+		EvictionPolicy: LRU,
+		Admit: func(key string, value interface{}, currentSize, capacity int) bool {
+			data, ok := value.([]byte)
+			return !ok || len(data) <= 16
+		},
+	}
+	def, _ := NewDefCache(config)
+	lru, _ := NewLRUCache(config)
+	lfu, _ := NewLFUCache(config)
+	ring, _ := NewRingCache(config)
+
+	caches := map[string]LittleCache{
+		"def":  def,
+		"lru":  lru,
+		"lfu":  lfu,
+		"ring": ring,
+	}
+
+	for name, cache := range caches {
+		hot := []string{"a", "b", "c"}
+		for _, key := range hot {
+			cache.Set(key, []byte(key))
+		}
+
+		cache.Set("huge", make([]byte, 1024))
+		cache.Set("a", make([]byte, 1024))
+
+		if _, exists := cache.Get("huge"); exists {
+			t.Errorf("%s: expected the oversized value to be rejected", name)
+		}
+		for _, key := range hot {
+			value, exists := cache.Get(key)
+			if !exists || string(value.([]byte)) != key {
+				t.Errorf("%s: expected hot key %s to be undisturbed, got %v (exists=%v)", name, key, value, exists)
+			}
+		}
+	}
+}
+
+func TestMaxValueBytes(t *testing.T) {
+	config := Config{MaxSize: 3, EvictionPolicy: LRU, MaxValueBytes: 16}
+	def, _ := NewDefCache(config)
+	lru, _ := NewLRUCache(config)
+	lfu, _ := NewLFUCache(config)
+	ring, _ := NewRingCache(config)
+	secondChance, _ := NewSecondChanceCache(config)
+	clock := newManualClock()
+	ttlConfig := config
+	ttlConfig.EvictionPolicy, ttlConfig.Clock = TTL, clock
+	ttlAny, _ := NewLittleCache(ttlConfig)
+	ttl := ttlAny.(*TTLCache)
+	defer ttl.Stop()
+
+	caches := map[string]LittleCache{
+		"def":          def,
+		"lru":          lru,
+		"lfu":          lfu,
+		"ring":         ring,
+		"secondChance": secondChance,
+		"ttl":          ttl,
+	}
+
+	for name, cache := range caches {
+		cache.Set("a", "small")
+		cache.Set("b", make([]byte, 16))
+		cache.Set("c", 12345) // not measured by default
+
+		cache.Set("huge", make([]byte, 17))
+		cache.Set("a", strings.Repeat("x", 100))
+
+		if _, exists := cache.Get("huge"); exists {
+			t.Errorf("%s: expected the oversized value to be rejected", name)
+		}
+		if value, _ := cache.Get("a"); value != "small" {
+			t.Errorf("%s: expected a to keep its value, got %v", name, value)
+		}
+		if cache.Size() != 3 {
+			t.Errorf("%s: expected nothing evicted, got size %d", name, cache.Size())
+		}
+		for _, key := range []string{"b", "c"} {
+			if _, exists := cache.Get(key); !exists {
+				t.Errorf("%s: expected %s, within the limit, to be stored", name, key)
+			}
+		}
+	}
+
+	// The TTL layer keeps no record for a rejected value, and a rejected
+	// overwrite leaves the old value's expiry alone
+	if _, ok := ttl.GetTTL("huge"); ok {
+		t.Errorf("ttl: expected no TTL for the rejected value")
+	}
+	if keys := ttl.KeysByExpiry(); len(keys) != 3 {
+		t.Errorf("ttl: expected 3 keys by expiry, got %v", keys)
+	}
+	clock.Advance(time.Minute)
+	ttl.SetWithTTL("a", strings.Repeat("x", 100), time.Hour)
+	if remaining, _ := ttl.GetTTL("a"); remaining != 4*time.Minute {
+		t.Errorf("ttl: expected a rejected overwrite to leave 4m, got %v", remaining)
+	}
+
+	config.SizeOf = func(value interface{}) int64 { return int64(len(value.([]int))) * 8 }
+	cache, _ := NewLRUCache(config)
+	cache.Set("fits", []int{1, 2})
+	cache.Set("too big", []int{1, 2, 3})
+	if !Has(cache, "fits") || Has(cache, "too big") {
+		t.Errorf("Expected SizeOf to decide which values fit")
+	}
+
+	if _, err := NewLRUCache(Config{MaxSize: 1, MaxValueBytes: -1}); !errors.Is(err, ErrInvalidMaxValueBytes) {
+		t.Errorf("Expected ErrInvalidMaxValueBytes, got %v", err)
+	}
+}
+
+func TestAdmit_ReceivesSizeAndCapacity(t *testing.T) {
+	var gotSize, gotCapacity int
+	config := Config{
+		MaxSize:        5,
+		EvictionPolicy: LRU,
+		Admit: func(key string, value interface{}, currentSize, capacity int) bool {
+			gotSize, gotCapacity = currentSize, capacity
+			return true
+		},
+	}
+	cache, err := NewLRUCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	if gotSize != 1 || gotCapacity != 5 {
+		t.Errorf("Expected Admit to see size 1 of 5, got %d of %d", gotSize, gotCapacity)
+	}
+}
+
+func TestImmutableKeys(t *testing.T) {
+	config := Config{MaxSize: 2, EvictionPolicy: LRU, ImmutableKeys: true}
+	def, _ := NewDefCache(config)
+	lru, _ := NewLRUCache(config)
+	lfu, _ := NewLFUCache(config)
+	ring, _ := NewRingCache(config)
+
+	caches := map[string]LittleCache{
+		"def":  def,
+		"lru":  lru,
+		"lfu":  lfu,
+		"ring": ring,
+	}
+
+	for name, cache := range caches {
+		cache.Set("a", "original")
+		cache.Set("b", "other")
+		cache.Set("a", "overwrite")
+
+		if value, _ := cache.Get("a"); value != "original" {
+			t.Errorf("%s: expected original value to be kept, got %v", name, value)
+		}
+	}
+
+	// The rejected Set doesn't count as a use: a stays the LRU candidate
+	fresh, _ := NewLRUCache(config)
+	fresh.Set("a", 1)
+	fresh.Set("b", 2)
+	fresh.Set("a", 3)
+	if candidate, _ := fresh.EvictionCandidate(); candidate != "a" {
+		t.Errorf("Expected a to remain the eviction candidate, got %s", candidate)
+	}
+	if err := fresh.SetWithWeight("a", 4, 1); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if value, _ := fresh.Get("a"); value != 1 {
+		t.Errorf("Expected SetWithWeight to keep the original value, got %v", value)
+	}
+
+	// ... and doesn't bump an LFU frequency
+	lfuFresh, _ := NewLFUCache(config)
+	lfuFresh.Set("a", 1)
+	lfuFresh.Set("a", 2)
+	if freq, _ := lfuFresh.FrequencyOf("a"); freq != 1 {
+		t.Errorf("Expected frequency 1 after a rejected Set, got %d", freq)
+	}
+}
+
+func TestSkipEqualWrites(t *testing.T) {
+	var log evictionLog
+	config := Config{MaxSize: 2, EvictionPolicy: LRU, SkipEqualWrites: true, OnEvict: log.record}
+	lru, _ := NewLRUCache(config)
+	lfu, _ := NewLFUCache(config)
+
+	caches := map[string]interface {
+		LittleCache
+		EvictionCandidate() (string, bool)
+	}{
+		"lru": lru,
+		"lfu": lfu,
+	}
+
+	for name, cache := range caches {
+		log = nil
+		cache.Set("a", []string{"x", "y"})
+		cache.Set("b", 2)
+		cache.Get("b")
+
+		// An equal value leaves a as the next to go and reports nothing
+		cache.Set("a", []string{"x", "y"})
+		if candidate, _ := cache.EvictionCandidate(); candidate != "a" {
+			t.Errorf("%s: expected a to remain the eviction candidate, got %s", name, candidate)
+		}
+		if len(log) != 0 {
+			t.Errorf("%s: expected no callbacks for an equal write, got %v", name, log)
+		}
+
+		// A different value is a normal write
+		cache.Set("a", []string{"z"})
+		cache.Set("a", []string{"z"})
+		if want := []string{"a=[x y]:replaced"}; fmt.Sprint(log) != fmt.Sprint(want) {
+			t.Errorf("%s: expected %v, got %v", name, want, log)
+		}
+		if value, _ := cache.Get("a"); fmt.Sprint(value) != "[z]" {
+			t.Errorf("%s: expected a=[z], got %v", name, value)
+		}
+	}
+
+	// A custom Equal decides what counts as a no-op
+	config = Config{
+		MaxSize:         2,
+		SkipEqualWrites: true,
+		Equal: func(a, b interface{}) bool {
+			return strings.EqualFold(a.(string), b.(string))
+		},
+	}
+	def, _ := NewDefCache(config)
+	def.Set("a", "Hello")
+	def.Set("a", "HELLO")
+	if value, _ := def.Get("a"); value != "Hello" {
+		t.Errorf("Expected Equal to treat HELLO as unchanged, got %v", value)
+	}
+}
+
+func TestReplaceAll_NoEmptyWindow(t *testing.T) {
+	config := Config{MaxSize: 100, EvictionPolicy: LRU}
+	def, _ := NewDefCache(config)
+	lru, _ := NewLRUCache(config)
+	lfu, _ := NewLFUCache(config)
+	ring, _ := NewRingCache(config)
+	ttl, _ := NewTTLCacheFromConfig(config, time.Minute)
+	defer ttl.Stop()
+
+	caches := map[string]interface {
+		LittleCache
+		ReplaceAll(items map[string]interface{})
+	}{
+		"def":  def,
+		"lru":  lru,
+		"lfu":  lfu,
+		"ring": ring,
+		"ttl":  ttl,
+	}
+
+	datasets := make([]map[string]interface{}, 2)
+	for v := range datasets {
+		datasets[v] = make(map[string]interface{})
+		for i := 0; i < 50; i++ {
+			datasets[v]["key"+strconv.Itoa(i)] = v
+		}
+	}
+	// Only in the second data set
+	datasets[1]["extra"] = 1
+
+	for name, cache := range caches {
+		cache.ReplaceAll(datasets[0])
+
+		stop := make(chan struct{})
+		var wg sync.WaitGroup
+		var misses atomic.Int32
+		for r := 0; r < 4; r++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+					for i := 0; i < 50; i++ {
+						if _, exists := cache.Get("key" + strconv.Itoa(i)); !exists {
+							misses.Add(1)
+						}
+					}
+				}
+			}()
+		}
+
+		for i := 0; i < 200; i++ {
+			cache.ReplaceAll(datasets[i%2])
+		}
+		close(stop)
+		wg.Wait()
+
+		if misses.Load() != 0 {
+			t.Errorf("%s: readers missed keys present in both data sets %d times", name, misses.Load())
+		}
+		if value, _ := cache.Get("key0"); value != 1 {
+			t.Errorf("%s: expected the last data set to win, got %v", name, value)
+		}
+		if _, exists := cache.Get("extra"); !exists {
+			t.Errorf("%s: expected extra from the last data set", name)
+		}
+	}
+}
+
+func TestReplaceAll_RespectsCapacity(t *testing.T) {
+	cache, err := NewLRUCache(Config{MaxSize: 3, EvictionPolicy: LRU})
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+	cache.Set("old", 0)
+
+	items := make(map[string]interface{})
+	for i := 0; i < 10; i++ {
+		items["key"+strconv.Itoa(i)] = i
+	}
+	cache.ReplaceAll(items)
+
+	if cache.Size() != 3 {
+		t.Errorf("Expected size 3, got %d", cache.Size())
+	}
+	if _, exists := cache.Get("old"); exists {
+		t.Errorf("Expected old contents to be replaced")
+	}
+}
+
+func TestUnsynchronized(t *testing.T) {
+	config := Config{MaxSize: 3, EvictionPolicy: LRU, Unsynchronized: true, TrackLockWait: true}
+	def, _ := NewDefCache(config)
+	lru, _ := NewLRUCache(config)
+	lfu, _ := NewLFUCache(config)
+	ring, _ := NewRingCache(config)
+	sampled, _ := NewSampledLRUCache(config)
+
+	caches := map[string]interface {
+		LittleCache
+		Stats() Stats
+	}{
+		"def":     def,
+		"lru":     lru,
+		"lfu":     lfu,
+		"ring":    ring,
+		"sampled": sampled,
+	}
+
+	for name, cache := range caches {
+		cache.Set("a", 1)
+		cache.Set("b", 2)
+		cache.Set("c", 3)
+		if value, ok := cache.Get("a"); !ok || value != 1 {
+			t.Errorf("%s: expected 1, got %v (ok=%v)", name, value, ok)
+		}
+		if previous, ok := cache.Swap("b", 20); !ok || previous != 2 {
+			t.Errorf("%s: expected previous value 2, got %v (ok=%v)", name, previous, ok)
+		}
+		cache.Delete("c")
+		if _, ok := cache.Get("c"); ok {
+			t.Errorf("%s: expected c to be deleted", name)
+		}
+		if err := cache.Resize(2); err != nil {
+			t.Errorf("%s: unexpected resize error: %v", name, err)
+		}
+		if cache.Size() != 2 {
+			t.Errorf("%s: expected size 2, got %d", name, cache.Size())
+		}
+		if stats := cache.Stats(); stats.LockWaitAvg != 0 || stats.LockWaitMax != 0 {
+			t.Errorf("%s: expected no lock wait figures without locking, got %+v", name, stats)
+		}
+		cache.Clear()
+		if cache.Size() != 0 {
+			t.Errorf("%s: expected size 0 after clear, got %d", name, cache.Size())
+		}
+	}
+
+	// Eviction follows the same algorithm as the locked cache.
+	lru.Resize(2)
+	lru.Set("a", 1)
+	lru.Set("b", 2)
+	lru.Get("a")
+	lru.Set("c", 3)
+	if _, ok := lru.Get("b"); ok {
+		t.Errorf("Expected b to be evicted as least recently used")
+	}
+}
+
+func BenchmarkUnsynchronized(b *testing.B) {
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = "key" + strconv.Itoa(i)
+	}
+
+	for _, unsynchronized := range []bool{false, true} {
+		name := "locked"
+		if unsynchronized {
+			name = "unsynchronized"
+		}
+		b.Run(name, func(b *testing.B) {
+			cache, _ := NewLRUCache(Config{MaxSize: 512, Unsynchronized: unsynchronized})
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				key := keys[i%len(keys)]
+				if _, ok := cache.Get(key); !ok {
+					cache.Set(key, i)
+				}
+			}
+		})
+	}
+}
+
+func TestSetPinned(t *testing.T) {
+	config := Config{MaxSize: 3}
+	lru, _ := NewLRUCache(config)
+	lfu, _ := NewLFUCache(config)
+
+	caches := map[string]interface {
+		LittleCache
+		SetPinned(key string, value interface{}) error
+		Unpin(key string) bool
+	}{
+		"lru": lru,
+		"lfu": lfu,
+	}
+
+	for name, cache := range caches {
+		if err := cache.SetPinned("config", "cold"); err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+
+		// Flood the cache with hotter keys; the cold pinned key survives
+		for i := 0; i < 20; i++ {
+			key := "key" + strconv.Itoa(i)
+			cache.Set(key, i)
+			cache.Get(key)
+		}
+		if value, ok := cache.Get("config"); !ok || value != "cold" {
+			t.Errorf("%s: expected the pinned key to survive, got %v (ok=%v)", name, value, ok)
+		}
+		if cache.Size() != 3 {
+			t.Errorf("%s: expected size 3, got %d", name, cache.Size())
+		}
+
+		// Once every slot is pinned, new keys are rejected
+		cache.Clear()
+		for _, key := range []string{"a", "b", "c"} {
+			if err := cache.SetPinned(key, key); err != nil {
+				t.Errorf("%s: unexpected error pinning %s: %v", name, key, err)
+			}
+		}
+		if err := cache.SetPinned("d", "d"); !errors.Is(err, ErrCacheFullyPinned) {
+			t.Errorf("%s: expected ErrCacheFullyPinned, got %v", name, err)
+		}
+		cache.Set("e", "e")
+		if _, ok := cache.Get("e"); ok {
+			t.Errorf("%s: expected an unpinned Set to be rejected", name)
+		}
+		if err := cache.SetPinned("a", "updated"); err != nil {
+			t.Errorf("%s: expected updating a pinned key to succeed, got %v", name, err)
+		}
+
+		// Unpinning makes the key evictable again
+		if !cache.Unpin("b") {
+			t.Errorf("%s: expected b to have been pinned", name)
+		}
+		if cache.Unpin("b") {
+			t.Errorf("%s: expected a second Unpin to report false", name)
+		}
+		cache.Set("f", "f")
+		if _, ok := cache.Get("b"); ok {
+			t.Errorf("%s: expected b to be evicted after Unpin", name)
+		}
+		for _, key := range []string{"a", "c", "f"} {
+			if _, ok := cache.Get(key); !ok {
+				t.Errorf("%s: expected %s to be cached", name, key)
+			}
+		}
+	}
+}
+
+func TestTrim(t *testing.T) {
+	lru, _ := NewLRUCache(Config{MaxSize: 5})
+	lfu, _ := NewLFUCache(Config{MaxSize: 5})
+
+	caches := map[string]interface {
+		LittleCache
+		Trim(targetSize int) int
+		SetPinned(key string, value interface{}) error
+	}{
+		"lru": lru,
+		"lfu": lfu,
+	}
+
+	for name, cache := range caches {
+		for _, key := range []string{"a", "b", "c", "d", "e"} {
+			cache.Set(key, key)
+		}
+		// a and c become the hottest by both recency and frequency
+		cache.Get("a")
+		cache.Get("c")
+
+		if removed := cache.Trim(2); removed != 3 {
+			t.Errorf("%s: expected 3 entries removed, got %d", name, removed)
+		}
+		for _, key := range []string{"a", "c"} {
+			if _, ok := cache.Get(key); !ok {
+				t.Errorf("%s: expected hot key %s to survive", name, key)
+			}
+		}
+		if removed := cache.Trim(5); removed != 0 {
+			t.Errorf("%s: expected nothing to trim, got %d", name, removed)
+		}
+
+		// The capacity is unchanged
+		for _, key := range []string{"f", "g", "h"} {
+			cache.Set(key, key)
+		}
+		if cache.Size() != 5 {
+			t.Errorf("%s: expected capacity 5 to be kept, got size %d", name, cache.Size())
+		}
+
+		// Pinned entries stay, even below the target
+		cache.Clear()
+		cache.SetPinned("pinned", 1)
+		cache.Set("x", 2)
+		if removed := cache.Trim(0); removed != 1 {
+			t.Errorf("%s: expected 1 entry removed, got %d", name, removed)
+		}
+		if _, ok := cache.Get("pinned"); !ok {
+			t.Errorf("%s: expected the pinned key to survive Trim", name)
+		}
+	}
+}
+
+func TestOnClear(t *testing.T) {
+	var calls int
+	var flushed map[string]interface{}
+	config := Config{MaxSize: 10, OnClear: func(snapshot map[string]interface{}) {
+		calls++
+		flushed = snapshot
+	}}
+
+	def, _ := NewDefCache(config)
+	lru, _ := NewLRUCache(config)
+	lfu, _ := NewLFUCache(config)
+	ring, _ := NewRingCache(config)
+
+	caches := map[string]LittleCache{
+		"def":  def,
+		"lru":  lru,
+		"lfu":  lfu,
+		"ring": ring,
+	}
+
+	for name, cache := range caches {
+		calls, flushed = 0, nil
+		cache.Set("a", 1)
+		cache.Set("b", 2)
+		cache.Set("c", 3)
+
+		cache.Clear()
+		if calls != 1 {
+			t.Errorf("%s: expected OnClear to run once, got %d", name, calls)
+		}
+		if len(flushed) != 3 || flushed["a"] != 1 || flushed["b"] != 2 || flushed["c"] != 3 {
+			t.Errorf("%s: expected every entry in the snapshot, got %v", name, flushed)
+		}
+		if cache.Size() != 0 {
+			t.Errorf("%s: expected size 0 after clear, got %d", name, cache.Size())
+		}
+
+		// Clearing an empty cache still reports the (empty) contents
+		cache.Clear()
+		if calls != 2 || len(flushed) != 0 {
+			t.Errorf("%s: expected an empty snapshot, got %v after %d calls", name, flushed, calls)
+		}
+	}
+}
+
+func TestResize_GrowKeepsEntries(t *testing.T) {
+	lru, _ := NewLRUCache(Config{MaxSize: 100})
+	lfu, _ := NewLFUCache(Config{MaxSize: 100})
+
+	caches := map[string]interface {
+		LittleCache
+		EvictionCandidate() (string, bool)
+	}{
+		"lru": lru,
+		"lfu": lfu,
+	}
+
+	for name, cache := range caches {
+		for i := 0; i < 100; i++ {
+			cache.Set("key"+strconv.Itoa(i), i)
+		}
+		cache.Get("key0")
+		before, _ := cache.EvictionCandidate()
+
+		// Large enough to rebuild the lookup map
+		if err := cache.Resize(10000); err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if cache.Size() != 100 {
+			t.Errorf("%s: expected 100 entries after growing, got %d", name, cache.Size())
+		}
+		if after, _ := cache.EvictionCandidate(); after != before {
+			t.Errorf("%s: expected eviction order to be kept, candidate went from %s to %s", name, before, after)
+		}
+		for i := 0; i < 100; i++ {
+			if value, ok := cache.Get("key" + strconv.Itoa(i)); !ok || value != i {
+				t.Errorf("%s: expected %d, got %v (ok=%v)", name, i, value, ok)
+			}
+		}
+
+		for i := 100; i < 10000; i++ {
+			cache.Set("key"+strconv.Itoa(i), i)
+		}
+		if cache.Size() != 10000 {
+			t.Errorf("%s: expected the new capacity to be usable, got size %d", name, cache.Size())
+		}
+	}
+}
+
+func TestResize_SameSize(t *testing.T) {
+	evictions := 0
+	config := Config{
+		MaxSize: 10,
+		OnEvict: func(key string, value interface{}, reason EvictionReason) { evictions++ },
+	}
+	def, _ := NewDefCache(config)
+	lru, _ := NewLRUCache(config)
+	lfu, _ := NewLFUCache(config)
+	ring, _ := NewRingCache(config)
+	secondChance, _ := NewSecondChanceCache(config)
+	sampled, _ := NewSampledLRUCache(config)
+
+	caches := map[string]LittleCache{
+		"def":          def,
+		"lru":          lru,
+		"lfu":          lfu,
+		"ring":         ring,
+		"secondChance": secondChance,
+		"sampled":      sampled,
+	}
+
+	for name, cache := range caches {
+		for i := 0; i < 10; i++ {
+			cache.Set("key"+strconv.Itoa(i), i)
+		}
+		evictions = 0
+
+		if err := cache.Resize(10); err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if evictions != 0 {
+			t.Errorf("%s: expected no evictions, got %d", name, evictions)
+		}
+		if cache.Size() != 10 {
+			t.Errorf("%s: expected 10 entries, got %d", name, cache.Size())
+		}
+		for i := 0; i < 10; i++ {
+			if value, ok := cache.Get("key" + strconv.Itoa(i)); !ok || value != i {
+				t.Errorf("%s: expected %d, got %v (ok=%v)", name, i, value, ok)
+			}
+		}
+	}
+}
+
+func BenchmarkResizeThenFill(b *testing.B) {
+	const size = 1 << 16
+	keys := make([]string, size)
+	for i := range keys {
+		keys[i] = "key" + strconv.Itoa(i)
+	}
+
+	fill := func(b *testing.B, newCache func() LittleCache) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			cache := newCache()
+			for j, key := range keys {
+				cache.Set(key, j)
+			}
+		}
+	}
+
+	// Without preallocation the map grows entry by entry, rehashing as it
+	// goes.
+	b.Run("no-resize", func(b *testing.B) {
+		fill(b, func() LittleCache {
+			cache, _ := NewLRUCache(Config{MaxSize: size, PreallocFraction: -1})
+			return cache
+		})
+	})
+
+	// Resize rebuilds the map at its final size before the fill.
+	b.Run("resize", func(b *testing.B) {
+		fill(b, func() LittleCache {
+			cache, _ := NewLRUCache(Config{MaxSize: 16})
+			cache.Resize(size)
+			return cache
+		})
+	})
+}
+
+func TestPreallocFraction_BehavesIdentically(t *testing.T) {
+	stream := ZipfKeys(BenchmarkConfig{Keys: 5000, Skew: 1.1, Length: 20000, Seed: 1})
+	policies := map[string]EvictionPolicy{
+		"Def": NoEviction, "LRU": LRU, "LFU": LFU, "SecondChance": SecondChance, "WeightedRandom": WeightedRandom,
+	}
+
+	for name, policy := range policies {
+		var wantRate float64
+		var wantDump []Entry
+		for i, fraction := range []float64{-1, 0, 0.25, 1} {
+			cache, err := NewLittleCache(Config{MaxSize: 500, EvictionPolicy: policy, PreallocFraction: fraction, RandomSeed: 7})
+			if err != nil {
+				t.Fatalf("%s: Failed to create cache: %v", name, err)
+			}
+			rate := replay(cache, stream)
+			var dump []Entry
+			if dumper, ok := cache.(interface{ Dump() []Entry }); ok {
+				dump = dumper.Dump()
+				sort.Slice(dump, func(i, j int) bool { return dump[i].Key < dump[j].Key })
+			}
+
+			if i == 0 {
+				wantRate, wantDump = rate, dump
+				continue
+			}
+			if rate != wantRate {
+				t.Errorf("%s: Expected PreallocFraction %v to hit %.4f of the time like no preallocation, got %.4f", name, fraction, wantRate, rate)
+			}
+			if !reflect.DeepEqual(dump, wantDump) {
+				t.Errorf("%s: Expected PreallocFraction %v to end with the same entries as no preallocation", name, fraction)
+			}
+		}
+	}
+
+	for _, fraction := range []float64{1.5, math.NaN()} {
+		if _, err := NewLRUCache(Config{MaxSize: 10, PreallocFraction: fraction}); !errors.Is(err, ErrInvalidPreallocFraction) {
+			t.Errorf("Expected ErrInvalidPreallocFraction for %v, got %v", fraction, err)
+		}
+	}
+	if size := (&Config{MaxSize: MaxCapacity}).initialMapSize(); size != maxInitialPrealloc {
+		t.Errorf("Expected a huge MaxSize to reserve only %d entries, got %d", maxInitialPrealloc, size)
+	}
+}
+
+// BenchmarkInitialFill fills a new cache to MaxSize, with and without the
+// constructor reserving map room for it.
+func BenchmarkInitialFill(b *testing.B) {
+	const size = 100000
+	keys := make([]string, size)
+	for i := range keys {
+		keys[i] = "key" + strconv.Itoa(i)
+	}
+
+	for _, fraction := range []float64{-1, 0} {
+		name := "prealloc"
+		if fraction < 0 {
+			name = "no-prealloc"
+		}
+		for _, p := range benchmarkPolicies {
+			b.Run(name+"/"+p.name, func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					cache, _ := NewLittleCache(Config{MaxSize: size, EvictionPolicy: p.policy, PreallocFraction: fraction})
+					for j, key := range keys {
+						cache.Set(key, j)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestClear_EmptiesPresizedMap(t *testing.T) {
+	lru, _ := NewLRUCache(Config{MaxSize: 1000})
+	lfu, _ := NewLFUCache(Config{MaxSize: 1000})
+	caches := map[string]LittleCache{"lru": lru, "lfu": lfu}
+
+	for name, cache := range caches {
+		for round := 0; round < 3; round++ {
+			for i := 0; i < 1000; i++ {
+				cache.Set("key"+strconv.Itoa(i), i)
+			}
+			cache.Clear()
+
+			if cache.Size() != 0 {
+				t.Errorf("%s: expected an empty cache after Clear, got size %d", name, cache.Size())
+			}
+			if _, ok := cache.Get("key0"); ok {
+				t.Errorf("%s: expected key0 to be gone after Clear", name)
+			}
+		}
+
+		// The cleared cache still evicts at capacity
+		for i := 0; i < 1500; i++ {
+			cache.Set("key"+strconv.Itoa(i), i)
+		}
+		if cache.Size() != 1000 {
+			t.Errorf("%s: expected size 1000 after refilling, got %d", name, cache.Size())
+		}
+	}
+}
+
+func BenchmarkClearThenFill(b *testing.B) {
+	const size = 1 << 16
+	keys := make([]string, size)
+	for i := range keys {
+		keys[i] = "key" + strconv.Itoa(i)
+	}
+
+	cache, _ := NewLRUCache(Config{MaxSize: size})
+	for j, key := range keys {
+		cache.Set(key, j)
+	}
+
+	// With a presized map, the refill allocates only the nodes, not the
+	// repeated map growth.
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cache.Clear()
+		for j, key := range keys {
+			cache.Set(key, j)
+		}
+	}
+}
+
+// evictionLog records OnEvict calls as "key=value:reason".
+type evictionLog []string
+
+func (l *evictionLog) record(key string, value interface{}, reason EvictionReason) {
+	*l = append(*l, fmt.Sprintf("%s=%v:%s", key, value, reason))
+}
+
+func TestOnEvict(t *testing.T) {
+	var log evictionLog
+	config := Config{MaxSize: 2, OnEvict: log.record}
+
+	lru, _ := NewLRUCache(config)
+	lfu, _ := NewLFUCache(config)
+	ring, _ := NewRingCache(config)
+	secondChance, _ := NewSecondChanceCache(config)
+
+	// Each cache evicts b: LRU and LFU because a was touched again, the
+	// ring because b is older than c, and CLOCK because overwriting a set
+	// its reference bit.
+	caches := map[string]LittleCache{
+		"lru":          lru,
+		"lfu":          lfu,
+		"ring":         ring,
+		"secondChance": secondChance,
+	}
+	for name, cache := range caches {
+		log = nil
+		if name == "ring" {
+			cache.Set("b", 2)
+			cache.Set("a", 1)
+		} else {
+			cache.Set("a", 1)
+			cache.Set("b", 2)
+		}
+		cache.Set("a", 10)
+		cache.Set("c", 3)
+		cache.Delete("c")
+		cache.Clear()
+
+		want := []string{"a=1:replaced", "b=2:capacity", "c=3:deleted", "a=10:cleared"}
+		if fmt.Sprint(log) != fmt.Sprint(want) {
+			t.Errorf("%s: expected %v, got %v", name, want, log)
+		}
+	}
+
+	log = nil
+	def, _ := NewDefCache(config)
+	def.Set("a", 1)
+	def.Swap("a", 10)
+	def.Set("b", 2)
+	if err := def.ResizeStrict(1); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	def.ReplaceAll(map[string]interface{}{"c": 3})
+	def.Delete("c")
+	def.Delete("missing")
+	if len(log) != 4 || log[0] != "a=1:replaced" || !strings.HasSuffix(log[1], ":capacity") ||
+		!strings.HasSuffix(log[2], ":cleared") || log[3] != "c=3:deleted" {
+		t.Errorf("def: unexpected evictions %v", log)
+	}
+
+	// Drain hands the entries to the caller instead
+	log = nil
+	lru.Set("a", 1)
+	lru.Drain()
+	if len(log) != 0 {
+		t.Errorf("Expected Drain not to call OnEvict, got %v", log)
+	}
+}
+
+func TestEvictionReason_String(t *testing.T) {
+	if got := Expired.String(); got != "expired" {
+		t.Errorf("Expected expired, got %s", got)
+	}
+	if got := EvictionReason(99).String(); got != "unknown" {
+		t.Errorf("Expected unknown, got %s", got)
+	}
+}
+
+func TestSetWithTTL(t *testing.T) {
+	clock := newManualClock()
+	config := Config{MaxSize: 10, EvictionPolicy: LRU, Clock: clock}
+
+	ttlCache, _ := NewTTLCacheFromConfig(config, time.Hour)
+	defer ttlCache.Stop()
+	lruTTL, _ := NewLRUTTLCache(config, time.Hour)
+	shardedConfig := config
+	shardedConfig.EvictionPolicy = TTL
+	sharded, _ := NewShardedCache(shardedConfig, 2)
+	defer sharded.Stop()
+
+	expiring := map[string]LittleCache{"ttl": ttlCache, "lruTTL": lruTTL, "sharded": sharded}
+	for _, cache := range expiring {
+		cache.SetWithTTL("short", 1, time.Minute)
+		cache.SetWithTTL("long", 2, 10*time.Minute)
+	}
+	clock.Advance(5 * time.Minute)
+	for name, cache := range expiring {
+		if _, ok := cache.Get("short"); ok {
+			t.Errorf("%s: expected short to have expired", name)
+		}
+		if value, ok := cache.Get("long"); !ok || value != 2 {
+			t.Errorf("%s: expected long=2, got %v, %v", name, value, ok)
+		}
+	}
+
+	// Caches without expiry store the value as Set would
+	def, _ := NewDefCache(config)
+	lru, _ := NewLRUCache(config)
+	lfu, _ := NewLFUCache(config)
+	ring, _ := NewRingCache(config)
+	secondChance, _ := NewSecondChanceCache(config)
+	sampled, _ := NewSampledLRUCache(config)
+	plain := map[string]LittleCache{
+		"def": def, "lru": lru, "lfu": lfu, "ring": ring,
+		"secondChance": secondChance, "sampled": sampled,
+	}
+	for name, cache := range plain {
+		cache.SetWithTTL("key", 1, time.Minute)
+		clock.Advance(time.Hour)
+		if value, ok := cache.Get("key"); !ok || value != 1 {
+			t.Errorf("%s: expected key=1 to be kept, got %v, %v", name, value, ok)
+		}
+	}
+
+	null := NewNullCache()
+	null.SetWithTTL("key", 1, time.Minute)
+	if _, ok := null.Get("key"); ok {
+		t.Error("Expected NullCache to store nothing")
+	}
+}
@@ -0,0 +1,118 @@
+package littlecache
+
+import (
+	"errors"
+	"sync"
+)
+
+// LoadingCache is a LittleCache that fills misses by calling a compute
+// function. Concurrent GetOrCompute calls for the same key share a single
+// computation.
+type LoadingCache struct {
+	LittleCache
+
+	mu        sync.Mutex
+	calls     map[string]*loadCall
+	sem       chan struct{}
+	breaker   *circuitBreaker
+	callbacks *callbackGuard
+}
+
+// loadCall is a computation in flight for one key.
+type loadCall struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// NewLoadingCache creates a LoadingCache backed by a cache built from config
+// with NewLittleCache.
+func NewLoadingCache(config Config) (*LoadingCache, error) {
+	// Loads share the cache's guard, so the cache's Stats count slow
+	// computes along with slow OnEvict calls.
+	config.callbacks = newCallbackGuard(config.CallbackTimeout, config.OnCallbackPanic)
+	cache, err := NewLittleCache(config)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &LoadingCache{
+		LittleCache: cache,
+		calls:       make(map[string]*loadCall),
+		callbacks:   config.callbacks,
+	}
+	if config.MaxConcurrentLoads > 0 {
+		l.sem = make(chan struct{}, config.MaxConcurrentLoads)
+	}
+	if config.BreakerThreshold > 0 {
+		l.breaker = newCircuitBreaker(config)
+	}
+	return l, nil
+}
+
+// GetOrCompute returns the cached value for key. On a miss it calls compute,
+// stores the result and returns it. Errors from compute are returned to every
+// waiting caller and nothing is cached. Cached values are still served while
+// the circuit breaker is open; misses fail with ErrCircuitOpen. With a
+// CallbackTimeout, a compute that overruns it fails with ErrCallbackTimeout
+// and counts toward the breaker; it keeps its MaxConcurrentLoads slot until
+// it really returns.
+func (l *LoadingCache) GetOrCompute(key string, compute func() (interface{}, error)) (interface{}, error) {
+	if value, exists := l.Get(key); exists {
+		return value, nil
+	}
+
+	l.mu.Lock()
+	if call, exists := l.calls[key]; exists {
+		l.mu.Unlock()
+		<-call.done
+		return call.value, call.err
+	}
+	call := &loadCall{done: make(chan struct{})}
+	l.calls[key] = call
+	l.mu.Unlock()
+
+	call.value, call.err = l.compute(key, compute)
+
+	l.mu.Lock()
+	delete(l.calls, key)
+	l.mu.Unlock()
+	close(call.done)
+
+	return call.value, call.err
+}
+
+func (l *LoadingCache) compute(key string, compute func() (interface{}, error)) (interface{}, error) {
+	release := func() {}
+	if l.sem != nil {
+		l.sem <- struct{}{}
+		release = func() { <-l.sem }
+	}
+
+	// Another caller may have filled the key while we waited for a slot.
+	if value, exists := l.Get(key); exists {
+		release()
+		return value, nil
+	}
+
+	if l.breaker != nil && !l.breaker.allow() {
+		release()
+		return nil, &LittleCacheError{Cache: l.Name(), Op: "load", Err: ErrCircuitOpen}
+	}
+
+	value, err := l.callbacks.load(func() (interface{}, error) {
+		defer release()
+		return compute()
+	})
+	if errors.Is(err, ErrCallbackTimeout) || errors.Is(err, ErrCallbackPanic) {
+		err = &LittleCacheError{Cache: l.Name(), Op: "load", Err: err}
+	}
+	if l.breaker != nil {
+		l.breaker.record(err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	l.Set(key, value)
+	return value, nil
+}
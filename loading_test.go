@@ -0,0 +1,91 @@
+package littlecache
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoadingCache_GetOrCompute(t *testing.T) {
+	config := Config{MaxSize: 10, EvictionPolicy: LRU}
+	cache, err := NewLoadingCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create loading cache: %v", err)
+	}
+
+	var calls int32
+	compute := func() (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return "computed", nil
+	}
+
+	for i := 0; i < 3; i++ {
+		value, err := cache.GetOrCompute("key", compute)
+		if err != nil || value != "computed" {
+			t.Errorf("Expected computed, got %v (err=%v)", value, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected compute to run once, ran %d times", calls)
+	}
+
+	errLoad := errors.New("backend down")
+	if _, err := cache.GetOrCompute("bad", func() (interface{}, error) { return nil, errLoad }); err != errLoad {
+		t.Errorf("Expected compute error, got %v", err)
+	}
+	if _, exists := cache.Get("bad"); exists {
+		t.Errorf("Expected failed computation not to be cached")
+	}
+}
+
+func TestLoadingCache_MaxConcurrentLoads(t *testing.T) {
+	const limit = 3
+	config := Config{MaxSize: 100, EvictionPolicy: LRU, MaxConcurrentLoads: limit}
+	cache, err := NewLoadingCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create loading cache: %v", err)
+	}
+
+	var running, peak int32
+	compute := func() (interface{}, error) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return "value", nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := cache.GetOrCompute("key"+strconv.Itoa(i), compute); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if peak > limit {
+		t.Errorf("Expected at most %d concurrent loads, got %d", limit, peak)
+	}
+	if cache.Size() != 20 {
+		t.Errorf("Expected size 20, got %d", cache.Size())
+	}
+}
+
+func TestLoadingCache_InvalidMaxConcurrentLoads(t *testing.T) {
+	_, err := NewLoadingCache(Config{MaxSize: 10, MaxConcurrentLoads: -1})
+	if !errors.Is(err, ErrInvalidMaxConcurrentLoads) {
+		t.Errorf("Expected ErrInvalidMaxConcurrentLoads, got %v", err)
+	}
+}
@@ -1,42 +1,84 @@
 package littlecache
 
 import (
-	"sync"
+	"fmt"
+	"math"
+	"strings"
+	"sync/atomic"
+	"time"
 )
 
 type LRUNode struct {
-	key   string
-	value interface{}
-	prev  *LRUNode
-	next  *LRUNode
+	key    string
+	value  interface{}
+	weight int
+	// reads is only kept when Config.TrackAccessCounts is set. Get may
+	// hold just the read lock, hence the atomic.
+	reads  atomic.Uint64
+	pinned bool
+	prev   *LRUNode
+	next   *LRUNode
 }
 
 type LRUCache struct {
-	config Config
-	size   int
-	cache  map[string]*LRUNode
-	head   *LRUNode
-	tail   *LRUNode
-	mu     sync.RWMutex
+	config  Config
+	initial int // MaxSize as constructed, for Reset
+	size    int
+	weight  int64
+	pinned  int
+	cache   map[string]*LRUNode
+	tags    tagIndex
+	head    *LRUNode
+	tail    *LRUNode
+	mu      rwMutex
+	cacheStats
+	saver autoSaver
+	tuner *autoTuner
+	// accessed and expiries keep per-node data off LRUNode, so nodes only
+	// pay for it when it's used. accessed is nil unless
+	// Config.TrackAccessTime is set, and only an LRUTTLCache sets expiries.
+	accessed map[*LRUNode]time.Time
+	expiries *nodeExpiries
 }
 
 func NewLRUCache(config Config) (*LRUCache, error) {
 	if err := config.Validate(); err != nil {
-		return nil, err
+		return nil, config.error("new", err)
 	}
+	config.initShared()
 
 	head := &LRUNode{}
 	tail := &LRUNode{}
 	head.next = tail
 	tail.prev = head
 
-	return &LRUCache{
-		config: config,
-		size:   0,
-		cache:  make(map[string]*LRUNode),
-		head:   head,
-		tail:   tail,
-	}, nil
+	lru := &LRUCache{
+		config:  config,
+		initial: config.MaxSize,
+		size:    0,
+		cache:   make(map[string]*LRUNode, config.initialMapSize()),
+		head:    head,
+		tail:    tail,
+		mu:      newRWMutex(config),
+	}
+	lru.bind(&lru.config, &lru.mu, func() int { return lru.size })
+	if config.TrackAccessTime {
+		lru.accessed = make(map[*LRUNode]time.Time)
+	}
+	lru.tuner = startAutoTune(config, lru)
+	return lru, nil
+}
+
+// Stop stops the auto-tuner, if Config.AutoTune started one, and waits for
+// it to exit.
+func (lru *LRUCache) Stop() {
+	lru.tuner.halt()
+}
+
+func (lru *LRUCache) capacity() int {
+	lru.mu.RLock()
+	defer lru.mu.RUnlock()
+	return lru.config.MaxSize
 }
 
 func (lru *LRUCache) addNode(node *LRUNode) {
@@ -54,49 +96,241 @@ func (lru *LRUCache) removeNode(node *LRUNode) {
 func (lru *LRUCache) moveToHead(node *LRUNode) {
 	lru.removeNode(node)
 	lru.addNode(node)
+	lru.stamp(node)
 }
 
-func (lru *LRUCache) popTail() *LRUNode {
-	lastNode := lru.tail.prev
-	lru.removeNode(lastNode)
-	return lastNode
+func (lru *LRUCache) stamp(node *LRUNode) {
+	if lru.accessed != nil {
+		lru.accessed[node] = clockOrDefault(lru.config.Clock).Now()
+	}
 }
 
-func (lru *LRUCache) Set(key string, value interface{}) {
-	lru.mu.Lock()
-	defer lru.mu.Unlock()
+// forget drops the data kept on the side for a node leaving the cache.
+func (lru *LRUCache) forget(node *LRUNode) {
+	if lru.accessed != nil {
+		delete(lru.accessed, node)
+	}
+	lru.expiries.drop(node)
+}
+
+// forgetAll drops the side data for every node, when the cache empties.
+func (lru *LRUCache) forgetAll() {
+	if lru.accessed != nil {
+		lru.accessed = make(map[*LRUNode]time.Time)
+	}
+	lru.expiries.reset()
+}
+
+// evictable returns the least recently used unpinned node, or nil if every
+// entry is pinned.
+func (lru *LRUCache) evictable() *LRUNode {
+	for node := lru.tail.prev; node != lru.head; node = node.prev {
+		if !node.pinned {
+			return node
+		}
+	}
+	return nil
+}
+
+func (lru *LRUCache) overCapacity() bool {
+	if lru.size > lru.config.MaxSize {
+		return true
+	}
+	return lru.config.MaxWeight > 0 && lru.weight > int64(lru.config.MaxWeight)
+}
+
+// evict removes the least recently used unpinned entry. It returns false
+// if there is none.
+func (lru *LRUCache) evict() bool {
+	victim := lru.evictable()
+	if victim == nil {
+		return false
+	}
+
+	lru.removeNode(victim)
+	delete(lru.cache, victim.key)
+	lru.forget(victim)
+	lru.tags.untag(victim.key)
+	lru.size--
+	lru.weight -= int64(victim.weight)
+	lru.evictions.record(clockOrDefault(lru.config.Clock).Now(), 1)
+	lru.config.evicted(victim.key, victim.value, CapacityEviction)
+	return true
+}
 
+// set stores key and evicts down to capacity. It returns false, storing
+// nothing, for a new key when pinned entries fill the cache.
+func (lru *LRUCache) set(key string, value interface{}, weight int) bool {
 	node, exists := lru.cache[key]
 
 	if !exists {
-		newNode := &LRUNode{key: key, value: value}
+		if lru.pinned >= lru.config.MaxSize {
+			return false
+		}
+
+		newNode := &LRUNode{key: key, value: value, weight: weight}
 		lru.cache[key] = newNode
 		lru.addNode(newNode)
+		lru.stamp(newNode)
 		lru.size++
-
-		if lru.size > lru.config.MaxSize {
-			tail := lru.popTail()
-			delete(lru.cache, tail.key)
-			lru.size--
-		}
+		lru.weight += int64(weight)
 	} else {
+		lru.weight += int64(weight - node.weight)
+		lru.config.evicted(key, node.value, Replaced)
 		node.value = value
+		node.weight = weight
 		lru.moveToHead(node)
 	}
+
+	for lru.overCapacity() && lru.evict() {
+	}
+	lru.counters.observeSize(lru.size)
+	return true
+}
+
+func (lru *LRUCache) Set(key string, value interface{}) {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	if node, exists := lru.cache[key]; exists && (lru.config.ImmutableKeys || lru.config.unchanged(node.value, value)) {
+		return
+	}
+	if !lru.config.admits(key, value, lru.size) {
+		return
+	}
+	lru.set(key, value, 1)
+}
+
+// SetWithTTL stores key like Set, ignoring ttl. For per-entry expiry on an
+// LRU, use an LRUTTLCache.
+func (lru *LRUCache) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	lru.Set(key, value)
+}
+
+// SetWithWeight adds or updates a key with an explicit weight. When
+// MaxWeight is set, least recently used entries are evicted until the total
+// weight fits again. An entry heavier than MaxWeight on its own is rejected
+// with ErrWeightTooLarge and nothing is evicted. Without MaxWeight, a write
+// that would overflow the total is rejected with ErrWeightOverflow.
+func (lru *LRUCache) SetWithWeight(key string, value interface{}, weight int) error {
+	if weight <= 0 {
+		return lru.config.error("set", ErrInvalidWeight)
+	}
+
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	if lru.config.MaxWeight > 0 && weight > lru.config.MaxWeight {
+		return lru.config.error("set", ErrWeightTooLarge)
+	}
+	node, exists := lru.cache[key]
+	if exists && lru.config.ImmutableKeys {
+		return nil
+	}
+	added := int64(weight)
+	if exists {
+		added -= int64(node.weight)
+	}
+	if added > 0 && lru.weight > math.MaxInt64-added {
+		return lru.config.error("set", ErrWeightOverflow)
+	}
+	if !lru.config.admits(key, value, lru.size) {
+		return nil
+	}
+
+	lru.set(key, value, weight)
+	return nil
 }
 
 func (lru *LRUCache) Get(key string) (interface{}, bool) {
+	if lru.config.NoPromoteOnGet {
+		return lru.getWithoutPromotion(key)
+	}
+
+	// A hit moves the node to the front, so the lookup and the move must
+	// happen under one write lock. Upgrading from a read lock would let a
+	// concurrent Delete or eviction unlink the node in between.
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	node, exists := lru.cache[key]
+	lru.counters.record(exists)
+	if !exists {
+		return nil, false
+	}
+
+	lru.moveToHead(node)
+	lru.countRead(node)
+	return node.value, true
+}
+
+func (lru *LRUCache) getWithoutPromotion(key string) (interface{}, bool) {
 	lru.mu.RLock()
 	defer lru.mu.RUnlock()
 
-	if node, exists := lru.cache[key]; exists {
-		lru.mu.RUnlock()
-		lru.mu.Lock()
-		lru.moveToHead(node)
-		lru.mu.Unlock()
-		lru.mu.RLock()
+	node, exists := lru.cache[key]
+	lru.counters.record(exists)
+	if !exists {
+		return nil, false
+	}
+	lru.countRead(node)
+	return node.value, true
+}
+
+func (lru *LRUCache) countRead(node *LRUNode) {
+	if lru.config.TrackAccessCounts {
+		node.reads.Add(1)
+	}
+}
+
+// Peek returns the value for key without moving it in the recency list.
+func (lru *LRUCache) Peek(key string) (interface{}, bool) {
+	lru.mu.RLock()
+	defer lru.mu.RUnlock()
+
+	node, exists := lru.cache[key]
+	if !exists {
+		return nil, false
+	}
+	return node.value, true
+}
+
+// LoadOrStore mirrors sync.Map.LoadOrStore. A hit moves the key to the
+// front of the recency list and is counted, just like Get; a miss stores
+// value only if Set would admit it.
+func (lru *LRUCache) LoadOrStore(key string, value interface{}) (interface{}, bool) {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	node, exists := lru.cache[key]
+	lru.counters.record(exists)
+	if exists {
+		if !lru.config.NoPromoteOnGet {
+			lru.moveToHead(node)
+		}
+		lru.countRead(node)
 		return node.value, true
 	}
+
+	if lru.config.admits(key, value, lru.size) {
+		lru.set(key, value, 1)
+	}
+	return value, false
+}
+
+// Swap stores value and returns the previous value, if any. The key moves
+// to the front of the recency list and keeps its weight.
+func (lru *LRUCache) Swap(key string, value interface{}) (interface{}, bool) {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	if node, exists := lru.cache[key]; exists {
+		previous := node.value
+		lru.set(key, value, node.weight)
+		return previous, true
+	}
+
+	lru.set(key, value, 1)
 	return nil, false
 }
 
@@ -105,22 +339,167 @@ func (lru *LRUCache) Delete(key string) {
 	defer lru.mu.Unlock()
 
 	if node, exists := lru.cache[key]; exists {
-		lru.removeNode(node)
-		delete(lru.cache, key)
-		lru.size--
+		lru.remove(node)
 	}
 }
 
+// remove drops node from the cache and reports it as Deleted.
+func (lru *LRUCache) remove(node *LRUNode) {
+	lru.removeNode(node)
+	delete(lru.cache, node.key)
+	lru.forget(node)
+	lru.tags.untag(node.key)
+	lru.size--
+	lru.weight -= int64(node.weight)
+	if node.pinned {
+		lru.pinned--
+	}
+	lru.config.evicted(node.key, node.value, Deleted)
+}
+
 func (lru *LRUCache) Clear() {
 	lru.mu.Lock()
 	defer lru.mu.Unlock()
 
-	lru.cache = make(map[string]*LRUNode)
+	if lru.config.OnClear != nil {
+		lru.config.cleared(lru.snapshot())
+	}
+	lru.clearAll()
+}
+
+// clearAll empties the cache, reporting each entry as Cleared.
+func (lru *LRUCache) clearAll() {
+	if lru.config.OnEvict != nil {
+		for key, node := range lru.cache {
+			lru.config.notify(key, node.value, Cleared)
+		}
+	}
+	lru.reset()
+}
+
+// snapshot copies every entry into a map.
+func (lru *LRUCache) snapshot() map[string]interface{} {
+	entries := make(map[string]interface{}, lru.size)
+	for key, node := range lru.cache {
+		entries[key] = node.value
+	}
+	return entries
+}
+
+// reset empties the cache. The new map is sized for as many entries as the
+// old one held, so refilling a cleared cache doesn't regrow it step by step.
+func (lru *LRUCache) reset() {
+	lru.cache = make(map[string]*LRUNode, min(lru.size, maxPrealloc))
+	lru.forgetAll()
+	lru.tags.reset()
 	lru.size = 0
+	lru.weight = 0
+	lru.pinned = 0
 	lru.head.next = lru.tail
 	lru.tail.prev = lru.head
 }
 
+// ReplaceAll swaps in items as the entire contents under one write lock, so
+// readers see either the old or the new data set, never an empty cache.
+// Items are inserted in map order, so if they overflow the cache which ones
+// are evicted is arbitrary.
+func (lru *LRUCache) ReplaceAll(items map[string]interface{}) {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	lru.clearAll()
+	for key, value := range items {
+		lru.set(key, value, 1)
+	}
+}
+
+// EvictionCandidate returns the key that the next overflowing Set would
+// evict, without evicting it.
+func (lru *LRUCache) EvictionCandidate() (string, bool) {
+	lru.mu.RLock()
+	defer lru.mu.RUnlock()
+
+	victim := lru.evictable()
+	if victim == nil {
+		return "", false
+	}
+	return victim.key, true
+}
+
+// RecencyRank returns the position of key in the recency list, 0 being the
+// most recently used, without moving it.
+func (lru *LRUCache) RecencyRank(key string) (int, bool) {
+	lru.mu.RLock()
+	defer lru.mu.RUnlock()
+
+	target, exists := lru.cache[key]
+	if !exists {
+		return 0, false
+	}
+
+	rank := 0
+	for node := lru.head.next; node != target; node = node.next {
+		rank++
+	}
+	return rank, true
+}
+
+// LastAccess returns when key was last set or read. It requires
+// Config.TrackAccessTime; without it, the time is always zero.
+func (lru *LRUCache) LastAccess(key string) (time.Time, bool) {
+	lru.mu.RLock()
+	defer lru.mu.RUnlock()
+
+	node, exists := lru.cache[key]
+	if !exists {
+		return time.Time{}, false
+	}
+	return lru.accessed[node], true
+}
+
+// DebugString describes the recency list from most to least recently used,
+// for example "LRU size=3/4: c -> a -> b".
+func (lru *LRUCache) DebugString() string {
+	lru.mu.RLock()
+	defer lru.mu.RUnlock()
+
+	keys := make([]string, 0, lru.size)
+	for node := lru.head.next; node != lru.tail; node = node.next {
+		keys = append(keys, node.key)
+	}
+
+	list := "(empty)"
+	if len(keys) > 0 {
+		list = strings.Join(keys, " -> ")
+	}
+	return fmt.Sprintf("LRU size=%d/%d: %s", lru.size, lru.config.MaxSize, list)
+}
+
+// Drain atomically removes and returns every entry.
+func (lru *LRUCache) Drain() map[string]interface{} {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	entries := lru.snapshot()
+	lru.reset()
+	return entries
+}
+
+// Dump returns a consistent snapshot of every entry, ordered from most to
+// least recently used, without changing the recency order.
+func (lru *LRUCache) Dump() []Entry {
+	lru.mu.RLock()
+	defer lru.mu.RUnlock()
+
+	entries := make([]Entry, 0, lru.size)
+	rank := 0
+	for node := lru.head.next; node != lru.tail; node = node.next {
+		entries = append(entries, Entry{Key: node.key, Value: node.value, Rank: rank})
+		rank++
+	}
+	return entries
+}
+
 func (lru *LRUCache) Size() int {
 	lru.mu.RLock()
 	defer lru.mu.RUnlock()
@@ -131,15 +510,97 @@ func (lru *LRUCache) Resize(newSize int) error {
 	lru.mu.Lock()
 	defer lru.mu.Unlock()
 
-	if newSize <= 0 {
-		return ErrInvalidMaxSize
+	if err := checkSize(newSize); err != nil {
+		return lru.config.error("resize", err)
+	}
+
+	// The same capacity needs no eviction pass and no new map.
+	if newSize == lru.config.MaxSize {
+		return nil
+	}
+
+	if hint, ok := preallocSize(lru.config.MaxSize, newSize); ok {
+		cache := make(map[string]*LRUNode, hint)
+		for key, node := range lru.cache {
+			cache[key] = node
+		}
+		lru.cache = cache
 	}
 
 	lru.config.MaxSize = newSize
-	for lru.size > lru.config.MaxSize {
-		tail := lru.popTail()
-		delete(lru.cache, tail.key)
-		lru.size--
+	for lru.overCapacity() && lru.evict() {
 	}
 	return nil
 }
+
+// Trim evicts least recently used entries until at most targetSize remain,
+// and returns how many it removed. Unlike Resize, the capacity stays the
+// same. Pinned entries are never trimmed.
+func (lru *LRUCache) Trim(targetSize int) int {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	removed := 0
+	for lru.size > targetSize && lru.evict() {
+		removed++
+	}
+	return removed
+}
+
+// SetPinned stores key and pins it, so eviction skips it until Unpin. Like
+// Swap, it ignores ImmutableKeys and Admit. A new key is rejected with
+// ErrCacheFullyPinned when pinned entries already fill the cache. Pinned
+// entries can keep the cache above MaxSize after a Resize.
+func (lru *LRUCache) SetPinned(key string, value interface{}) error {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	weight := 1
+	if node, exists := lru.cache[key]; exists {
+		weight = node.weight
+	}
+	if !lru.set(key, value, weight) {
+		return lru.config.error("set", ErrCacheFullyPinned)
+	}
+
+	// With MaxWeight, the new key itself may have been evicted to make room.
+	node, exists := lru.cache[key]
+	if !exists {
+		return lru.config.error("set", ErrCacheFullyPinned)
+	}
+	if !node.pinned {
+		node.pinned = true
+		lru.pinned++
+	}
+	return nil
+}
+
+// Unpin makes key evictable again. It reports whether key was pinned.
+func (lru *LRUCache) Unpin(key string) bool {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	node, exists := lru.cache[key]
+	if !exists || !node.pinned {
+		return false
+	}
+	node.pinned = false
+	lru.pinned--
+	for lru.overCapacity() && lru.evict() {
+	}
+	return true
+}
+
+// Weight returns the total weight of the entries in the cache.
+func (lru *LRUCache) Weight() int64 {
+	lru.mu.RLock()
+	defer lru.mu.RUnlock()
+	return lru.weight
+}
+
+// chainOnEvict adds fn to the OnEvict callback, for a wrapping TTLCache.
+func (lru *LRUCache) chainOnEvict(fn func(key string, value interface{}, reason EvictionReason)) {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+	lru.config.chainOnEvict(fn)
+}
@@ -1,9 +1,11 @@
 package littlecache
 
 import (
+	"errors"
 	"strconv"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestLRUCache_BasicOperations(t *testing.T) {
@@ -295,3 +297,398 @@ func TestLRUCache_LRUOrder(t *testing.T) {
 		t.Errorf("Expected 'fourth' to exist")
 	}
 }
+
+func TestLRUCache_EvictionCandidate(t *testing.T) {
+	config := Config{MaxSize: 3, EvictionPolicy: LRU}
+	cache, err := NewLRUCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+
+	if _, ok := cache.EvictionCandidate(); ok {
+		t.Errorf("Expected no eviction candidate for empty cache")
+	}
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.Set("c", 3)
+	cache.Get("a")
+
+	// Order from most to least recent: a, c, b
+	candidate, ok := cache.EvictionCandidate()
+	if !ok || candidate != "b" {
+		t.Fatalf("Expected eviction candidate b, got %q (ok=%v)", candidate, ok)
+	}
+
+	// Inspecting must not evict
+	if cache.Size() != 3 {
+		t.Errorf("Expected size 3, got %d", cache.Size())
+	}
+
+	cache.Set("d", 4)
+	if _, exists := cache.Get(candidate); exists {
+		t.Errorf("Expected %s to be evicted", candidate)
+	}
+
+	// Order is now: a, d, c
+	candidate, ok = cache.EvictionCandidate()
+	if !ok || candidate != "c" {
+		t.Fatalf("Expected eviction candidate c, got %q (ok=%v)", candidate, ok)
+	}
+
+	cache.Set("e", 5)
+	if _, exists := cache.Get(candidate); exists {
+		t.Errorf("Expected %s to be evicted", candidate)
+	}
+}
+
+func TestLRUCache_SetWithWeight(t *testing.T) {
+	config := Config{MaxSize: 100, EvictionPolicy: LRU, MaxWeight: 10}
+	cache, err := NewLRUCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+
+	weights := []struct {
+		key    string
+		weight int
+	}{
+		{"a", 3},
+		{"b", 4},
+		{"c", 2},
+	}
+	for _, w := range weights {
+		if err := cache.SetWithWeight(w.key, w.key, w.weight); err != nil {
+			t.Fatalf("Unexpected error setting %s: %v", w.key, err)
+		}
+	}
+	if cache.Weight() != 9 {
+		t.Errorf("Expected weight 9, got %d", cache.Weight())
+	}
+
+	// Plain Set counts as weight 1 and still fits
+	cache.Set("d", "d")
+	if cache.Weight() != 10 || cache.Size() != 4 {
+		t.Errorf("Expected weight 10 and size 4, got %d and %d", cache.Weight(), cache.Size())
+	}
+
+	// Adding weight 5 must evict from the tail until the total fits: a (3) and b (4)
+	if err := cache.SetWithWeight("e", "e", 5); err != nil {
+		t.Fatalf("Unexpected error setting e: %v", err)
+	}
+	if cache.Weight() > 10 {
+		t.Errorf("Weight invariant violated: %d > 10", cache.Weight())
+	}
+	for _, key := range []string{"a", "b"} {
+		if _, exists := cache.Get(key); exists {
+			t.Errorf("Expected %s to be evicted", key)
+		}
+	}
+	for _, key := range []string{"c", "d", "e"} {
+		if _, exists := cache.Get(key); !exists {
+			t.Errorf("Expected %s to exist", key)
+		}
+	}
+
+	// Updating an entry's weight is reflected in the total
+	if err := cache.SetWithWeight("c", "c", 1); err != nil {
+		t.Fatalf("Unexpected error updating c: %v", err)
+	}
+	if cache.Weight() != 7 {
+		t.Errorf("Expected weight 7 after update, got %d", cache.Weight())
+	}
+
+	cache.Delete("e")
+	if cache.Weight() != 2 {
+		t.Errorf("Expected weight 2 after delete, got %d", cache.Weight())
+	}
+
+	if err := cache.SetWithWeight("bad", "bad", 0); !errors.Is(err, ErrInvalidWeight) {
+		t.Errorf("Expected ErrInvalidWeight, got %v", err)
+	}
+}
+
+func TestLRUCache_SetWithWeightRejectsOversized(t *testing.T) {
+	config := Config{MaxSize: 100, EvictionPolicy: LRU, MaxWeight: 10}
+	cache, err := NewLRUCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+
+	cache.SetWithWeight("a", "a", 5)
+	cache.SetWithWeight("b", "b", 5)
+
+	if err := cache.SetWithWeight("huge", "huge", 11); !errors.Is(err, ErrWeightTooLarge) {
+		t.Errorf("Expected ErrWeightTooLarge, got %v", err)
+	}
+	if _, exists := cache.Get("huge"); exists {
+		t.Errorf("Expected oversized entry to be rejected")
+	}
+	if cache.Size() != 2 || cache.Weight() != 10 {
+		t.Errorf("Expected existing entries to be untouched, got size %d weight %d", cache.Size(), cache.Weight())
+	}
+}
+
+func TestLRUCache_Drain(t *testing.T) {
+	config := Config{MaxSize: 3, EvictionPolicy: LRU}
+	cache, err := NewLRUCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+	cache.Set("key3", "value3")
+
+	entries := cache.Drain()
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 drained entries, got %d", len(entries))
+	}
+	for i := 1; i <= 3; i++ {
+		key := "key" + strconv.Itoa(i)
+		if entries[key] != "value"+strconv.Itoa(i) {
+			t.Errorf("Expected drained %s to be value%d, got %v", key, i, entries[key])
+		}
+	}
+	if cache.Size() != 0 {
+		t.Errorf("Expected size 0 after drain, got %d", cache.Size())
+	}
+	if _, ok := cache.EvictionCandidate(); ok {
+		t.Errorf("Expected empty list after drain")
+	}
+
+	cache.Set("key4", "value4")
+	if value, exists := cache.Get("key4"); !exists || value != "value4" {
+		t.Errorf("Expected cache to be usable after drain")
+	}
+}
+
+func TestLRUCache_Dump(t *testing.T) {
+	config := Config{MaxSize: 3, EvictionPolicy: LRU}
+	cache, err := NewLRUCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.Set("c", 3)
+	cache.Get("a")
+
+	entries := cache.Dump()
+	expected := []string{"a", "c", "b"}
+	if len(entries) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d", len(expected), len(entries))
+	}
+	for i, key := range expected {
+		if entries[i].Key != key || entries[i].Rank != i {
+			t.Errorf("Expected %s at rank %d, got %s at rank %d", key, i, entries[i].Key, entries[i].Rank)
+		}
+		if entries[i].TTL != 0 {
+			t.Errorf("Expected zero TTL for non-TTL cache, got %v", entries[i].TTL)
+		}
+	}
+
+	// Dumping doesn't reorder
+	if candidate, _ := cache.EvictionCandidate(); candidate != "b" {
+		t.Errorf("Expected eviction candidate b after dump, got %s", candidate)
+	}
+}
+
+func TestLRUCache_DumpConsistentUnderWrites(t *testing.T) {
+	config := Config{MaxSize: 50, EvictionPolicy: LRU}
+	cache, err := NewLRUCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+	for i := 0; i < 50; i++ {
+		cache.Set("key"+strconv.Itoa(i), i)
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(goroutineID int) {
+			defer wg.Done()
+			for j := 0; ; j++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				n := (goroutineID*31 + j) % 100
+				cache.Set("key"+strconv.Itoa(n), n)
+			}
+		}(i)
+	}
+
+	for i := 0; i < 100; i++ {
+		entries := cache.Dump()
+		if len(entries) != 50 {
+			t.Fatalf("Expected a full snapshot of 50 entries, got %d", len(entries))
+		}
+		seen := make(map[string]bool, len(entries))
+		for rank, e := range entries {
+			if e.Rank != rank {
+				t.Fatalf("Expected contiguous ranks, got %d at position %d", e.Rank, rank)
+			}
+			if seen[e.Key] {
+				t.Fatalf("Key %s appears twice in one snapshot", e.Key)
+			}
+			seen[e.Key] = true
+			if e.Key != "key"+strconv.Itoa(e.Value.(int)) {
+				t.Fatalf("Snapshot pairs %s with value %v", e.Key, e.Value)
+			}
+		}
+	}
+
+	close(stop)
+	wg.Wait()
+}
+
+func TestLRUCache_RecencyRank(t *testing.T) {
+	config := Config{MaxSize: 4, EvictionPolicy: LRU}
+	cache, err := NewLRUCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.Set("c", 3)
+	cache.Get("a")
+
+	// Recency order is now a, c, b
+	expected := map[string]int{"a": 0, "c": 1, "b": 2}
+	for key, want := range expected {
+		rank, exists := cache.RecencyRank(key)
+		if !exists || rank != want {
+			t.Errorf("Expected rank %d for %s, got %d (exists=%v)", want, key, rank, exists)
+		}
+	}
+
+	// Querying doesn't promote: b is still the eviction candidate
+	if candidate, _ := cache.EvictionCandidate(); candidate != "b" {
+		t.Errorf("Expected RecencyRank to leave order untouched, candidate is %s", candidate)
+	}
+
+	if _, exists := cache.RecencyRank("missing"); exists {
+		t.Errorf("Expected no rank for a missing key")
+	}
+}
+
+func TestLRUCache_DebugString(t *testing.T) {
+	config := Config{MaxSize: 4, EvictionPolicy: LRU}
+	cache, err := NewLRUCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+
+	if got := cache.DebugString(); got != "LRU size=0/4: (empty)" {
+		t.Errorf("Unexpected empty dump: %q", got)
+	}
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.Set("c", 3)
+	cache.Get("a")
+
+	want := "LRU size=3/4: a -> c -> b"
+	if got := cache.DebugString(); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	// Dumping twice gives the same answer: the order isn't touched
+	if got := cache.DebugString(); got != want {
+		t.Errorf("Expected %q on second call, got %q", want, got)
+	}
+}
+
+func TestLRUCache_LastAccess(t *testing.T) {
+	clock := newManualClock()
+	config := Config{MaxSize: 3, EvictionPolicy: LRU, TrackAccessTime: true, Clock: clock}
+	cache, err := NewLRUCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+
+	start := clock.Now()
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+
+	clock.Advance(time.Minute)
+	cache.Get("a")
+
+	if accessed, exists := cache.LastAccess("a"); !exists || !accessed.Equal(start.Add(time.Minute)) {
+		t.Errorf("Expected a to be stamped by Get at %v, got %v", start.Add(time.Minute), accessed)
+	}
+	if accessed, _ := cache.LastAccess("b"); !accessed.Equal(start) {
+		t.Errorf("Expected b to keep its Set time %v, got %v", start, accessed)
+	}
+
+	clock.Advance(time.Minute)
+	cache.Set("b", 20)
+	if accessed, _ := cache.LastAccess("b"); !accessed.Equal(start.Add(2 * time.Minute)) {
+		t.Errorf("Expected an update to restamp b, got %v", accessed)
+	}
+
+	// LastAccess itself isn't an access
+	clock.Advance(time.Minute)
+	cache.LastAccess("a")
+	if accessed, _ := cache.LastAccess("a"); !accessed.Equal(start.Add(time.Minute)) {
+		t.Errorf("Expected LastAccess not to restamp a, got %v", accessed)
+	}
+
+	if _, exists := cache.LastAccess("missing"); exists {
+		t.Errorf("Expected no access time for a missing key")
+	}
+}
+
+func TestLRUCache_PeekKeepsOrder(t *testing.T) {
+	cache, err := NewLRUCache(Config{MaxSize: 2, EvictionPolicy: LRU})
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	if value, exists := cache.Peek("a"); !exists || value != 1 {
+		t.Errorf("Expected 1, got %v (exists=%v)", value, exists)
+	}
+
+	cache.Set("c", 3)
+	if _, exists := cache.Peek("a"); exists {
+		t.Errorf("Expected a to be evicted despite the peek")
+	}
+}
+
+func TestLRUCache_NoPromoteOnGet(t *testing.T) {
+	for _, noPromote := range []bool{false, true} {
+		config := Config{MaxSize: 3, EvictionPolicy: LRU, NoPromoteOnGet: noPromote}
+		cache, err := NewLRUCache(config)
+		if err != nil {
+			t.Fatalf("Failed to create LRU cache: %v", err)
+		}
+
+		cache.Set("a", 1)
+		cache.Set("b", 2)
+		cache.Set("c", 3)
+		for i := 0; i < 5; i++ {
+			if value, ok := cache.Get("a"); !ok || value != 1 {
+				t.Fatalf("Expected 1, got %v (ok=%v)", value, ok)
+			}
+		}
+		cache.Set("d", 4)
+
+		_, survived := cache.Get("a")
+		if noPromote && survived {
+			t.Errorf("Expected repeated Gets not to save a from eviction with NoPromoteOnGet")
+		}
+		if !noPromote && !survived {
+			t.Errorf("Expected Gets to keep a alive by default")
+		}
+		if stats := cache.Stats(); stats.Hits < 5 {
+			t.Errorf("Expected hits to be counted either way, got %d", stats.Hits)
+		}
+	}
+}
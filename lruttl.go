@@ -0,0 +1,248 @@
+package littlecache
+
+import "time"
+
+// LRUTTLCache is an LRU cache whose entries also expire. Unlike wrapping an
+// LRUCache in a TTLCache, there is one lock and no per-key record for
+// entries that never expire: the deadlines of those that do sit in a heap
+// beside the LRU list. There is no cleanup goroutine: expired entries are
+// dropped when Get finds them, when a new key needs their room, or by
+// RemoveExpired.
+type LRUTTLCache struct {
+	lru        *LRUCache
+	defaultTTL time.Duration
+	clock      Clock
+}
+
+// NewLRUTTLCache creates an LRUTTLCache. A zero defaultTTL picks five
+// minutes, as NewTTLCache does; a negative one is rejected.
+func NewLRUTTLCache(config Config, defaultTTL time.Duration) (*LRUTTLCache, error) {
+	if defaultTTL < 0 {
+		return nil, config.error("new", ErrInvalidDefaultTTL)
+	}
+	if defaultTTL == 0 {
+		defaultTTL = 5 * time.Minute
+	}
+
+	lru, err := NewLRUCache(config)
+	if err != nil {
+		return nil, err
+	}
+	lru.expiries = newNodeExpiries()
+	return &LRUTTLCache{
+		lru:        lru,
+		defaultTTL: defaultTTL,
+		clock:      clockOrDefault(config.Clock),
+	}, nil
+}
+
+func (c *LRUTTLCache) Set(key string, value interface{}) {
+	c.SetWithTTL(key, value, c.defaultTTL)
+}
+
+// SetWithTTL stores key for ttl. As with TTLCache, a ttl <= 0 (or
+// NoExpiration) stores it without expiry, and DoNotStore skips the write.
+// ImmutableKeys and SkipEqualWrites apply as in LRUCache.Set, but only to
+// a live entry: an expired one is replaced. A skipped write leaves the
+// entry's expiry alone.
+func (c *LRUTTLCache) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	if ttl == DoNotStore {
+		return
+	}
+
+	c.lru.mu.Lock()
+	defer c.lru.mu.Unlock()
+
+	if node, exists := c.lru.cache[key]; exists && !c.expired(node, c.now()) &&
+		(c.lru.config.ImmutableKeys || c.lru.config.unchanged(node.value, value)) {
+		return
+	}
+	if !c.lru.config.admits(key, value, c.lru.size) {
+		return
+	}
+	c.set(key, value, ttl)
+}
+
+func (c *LRUTTLCache) set(key string, value interface{}, ttl time.Duration) {
+	// An expired value isn't replaced so much as gone already.
+	now := c.now()
+	if node, exists := c.lru.cache[key]; exists && c.expired(node, now) {
+		c.remove(node)
+	}
+	if _, exists := c.lru.cache[key]; !exists {
+		c.makeRoom(now)
+	}
+	c.lru.set(key, value, 1)
+	if node, exists := c.lru.cache[key]; exists {
+		if ttl > 0 && ttl != NoExpiration {
+			c.lru.expiries.set(node, now.mono+ttl)
+		} else {
+			c.lru.expiries.drop(node)
+		}
+	}
+}
+
+// makeRoom removes expired entries, soonest first, while the cache is full,
+// so a new key takes the place of an entry that is gone already rather
+// than evicting a live one.
+func (c *LRUTTLCache) makeRoom(now instant) {
+	for c.lru.size >= c.lru.config.MaxSize {
+		node := c.lru.expiries.soonest()
+		if node == nil || !c.expired(node, now) {
+			return
+		}
+		c.remove(node)
+	}
+}
+
+// Get returns the value for key and moves it to the front of the recency
+// list. An expired entry is removed and reported as a miss.
+func (c *LRUTTLCache) Get(key string) (interface{}, bool) {
+	c.lru.mu.Lock()
+	defer c.lru.mu.Unlock()
+
+	node, exists := c.lru.cache[key]
+	if exists && c.expired(node, c.now()) {
+		c.remove(node)
+		exists = false
+	}
+	c.lru.counters.record(exists)
+	if !exists {
+		return nil, false
+	}
+
+	if !c.lru.config.NoPromoteOnGet {
+		c.lru.moveToHead(node)
+	}
+	c.lru.countRead(node)
+	return node.value, true
+}
+
+// LoadOrStore returns the value for key if it is cached and not expired,
+// treating the hit like Get. Otherwise it stores value with the default TTL,
+// if Set would admit it, and returns it with false.
+func (c *LRUTTLCache) LoadOrStore(key string, value interface{}) (interface{}, bool) {
+	c.lru.mu.Lock()
+	defer c.lru.mu.Unlock()
+
+	node, exists := c.lru.cache[key]
+	if exists && c.expired(node, c.now()) {
+		c.remove(node)
+		exists = false
+	}
+	c.lru.counters.record(exists)
+	if exists {
+		if !c.lru.config.NoPromoteOnGet {
+			c.lru.moveToHead(node)
+		}
+		c.lru.countRead(node)
+		return node.value, true
+	}
+
+	if c.lru.config.admits(key, value, c.lru.size) {
+		c.set(key, value, c.defaultTTL)
+	}
+	return value, false
+}
+
+// Swap stores value with the default TTL and returns the previous value, if
+// it had not expired.
+func (c *LRUTTLCache) Swap(key string, value interface{}) (interface{}, bool) {
+	c.lru.mu.Lock()
+	defer c.lru.mu.Unlock()
+
+	var previous interface{}
+	node, exists := c.lru.cache[key]
+	if exists {
+		exists = !c.expired(node, c.now())
+		previous = node.value
+	}
+	c.set(key, value, c.defaultTTL)
+	if !exists {
+		return nil, false
+	}
+	return previous, true
+}
+
+// GetTTL returns the time left before key expires, or NoExpiration if it
+// never does.
+func (c *LRUTTLCache) GetTTL(key string) (time.Duration, bool) {
+	c.lru.mu.RLock()
+	defer c.lru.mu.RUnlock()
+
+	node, exists := c.lru.cache[key]
+	now := c.now()
+	if !exists || c.expired(node, now) {
+		return 0, false
+	}
+	deadline, expires := c.lru.expiries.deadline(node)
+	if !expires {
+		return NoExpiration, true
+	}
+	return deadline - now.mono, true
+}
+
+// RemoveExpired deletes every expired entry and returns how many there were.
+func (c *LRUTTLCache) RemoveExpired() int {
+	c.lru.mu.Lock()
+	defer c.lru.mu.Unlock()
+
+	now := c.now()
+	removed := 0
+	for node := c.lru.expiries.soonest(); node != nil && c.expired(node, now); node = c.lru.expiries.soonest() {
+		c.remove(node)
+		removed++
+	}
+	return removed
+}
+
+// now reads the clock as TTLCache does, so expiry follows the monotonic
+// reading and a wall clock jump can't expire or revive entries.
+func (c *LRUTTLCache) now() instant {
+	return now(c.clock)
+}
+
+func (c *LRUTTLCache) expired(node *LRUNode, now instant) bool {
+	deadline, expires := c.lru.expiries.deadline(node)
+	return expires && now.mono > deadline
+}
+
+// remove drops an expired node, reporting it to OnEvict as Expired.
+func (c *LRUTTLCache) remove(node *LRUNode) {
+	c.lru.removeNode(node)
+	delete(c.lru.cache, node.key)
+	c.lru.forget(node)
+	c.lru.tags.untag(node.key)
+	c.lru.size--
+	c.lru.weight -= int64(node.weight)
+	if node.pinned {
+		c.lru.pinned--
+	}
+	c.lru.config.evicted(node.key, node.value, Expired)
+}
+
+func (c *LRUTTLCache) Delete(key string) {
+	c.lru.Delete(key)
+}
+
+func (c *LRUTTLCache) Clear() {
+	c.lru.Clear()
+}
+
+// Size counts live entries only; expired ones that have not been removed
+// yet are left out.
+func (c *LRUTTLCache) Size() int {
+	c.lru.mu.RLock()
+	defer c.lru.mu.RUnlock()
+
+	return c.lru.size - c.lru.expiries.expiredCount(c.now().mono)
+}
+
+// Resize changes the capacity. Shrinking removes expired entries before it
+// evicts any live ones.
+func (c *LRUTTLCache) Resize(newSize int) error {
+	if newSize < c.lru.capacity() {
+		c.RemoveExpired()
+	}
+	return c.lru.Resize(newSize)
+}
@@ -0,0 +1,253 @@
+package littlecache
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestLRUTTLCache_Expiry(t *testing.T) {
+	clock := newManualClock()
+	cache, err := NewLRUTTLCache(Config{MaxSize: 10, Clock: clock}, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create LRU TTL cache: %v", err)
+	}
+
+	cache.Set("a", 1)
+	cache.SetWithTTL("b", 2, time.Hour)
+	cache.SetWithTTL("forever", 3, NoExpiration)
+	cache.SetWithTTL("skipped", 4, DoNotStore)
+
+	if _, ok := cache.Get("skipped"); ok {
+		t.Errorf("Expected DoNotStore to skip the write")
+	}
+	if ttl, ok := cache.GetTTL("a"); !ok || ttl != time.Minute {
+		t.Errorf("Expected a TTL of 1m, got %v (ok=%v)", ttl, ok)
+	}
+	if ttl, _ := cache.GetTTL("forever"); ttl != NoExpiration {
+		t.Errorf("Expected NoExpiration, got %v", ttl)
+	}
+
+	clock.Advance(2 * time.Minute)
+
+	if _, ok := cache.Get("a"); ok {
+		t.Errorf("Expected a to have expired")
+	}
+	if value, ok := cache.Get("b"); !ok || value != 2 {
+		t.Errorf("Expected 2, got %v (ok=%v)", value, ok)
+	}
+	if cache.Size() != 2 {
+		t.Errorf("Expected the expired entry to be removed by Get, got size %d", cache.Size())
+	}
+
+	// Setting again resets the expiry
+	cache.SetWithTTL("b", 20, time.Hour)
+	clock.Advance(59 * time.Minute)
+	if value, ok := cache.Get("b"); !ok || value != 20 {
+		t.Errorf("Expected 20, got %v (ok=%v)", value, ok)
+	}
+	clock.Advance(2 * time.Minute)
+	if removed := cache.RemoveExpired(); removed != 1 {
+		t.Errorf("Expected RemoveExpired to remove 1 entry, got %d", removed)
+	}
+	if cache.Size() != 1 {
+		t.Errorf("Expected size 1, got %d", cache.Size())
+	}
+}
+
+func TestLRUTTLCache_EvictionAndExpiry(t *testing.T) {
+	clock := newManualClock()
+	cache, err := NewLRUTTLCache(Config{MaxSize: 3, Clock: clock}, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create LRU TTL cache: %v", err)
+	}
+
+	cache.SetWithTTL("a", 1, time.Hour)
+	cache.Set("b", 2)
+	cache.SetWithTTL("c", 3, time.Hour)
+	cache.Get("a")
+
+	// b is least recently used, so capacity evicts it before it expires
+	cache.Set("d", 4)
+	if _, ok := cache.Get("b"); ok {
+		t.Errorf("Expected b to be evicted as least recently used")
+	}
+
+	// d was the most recently written, yet it expires first
+	clock.Advance(2 * time.Minute)
+	if _, ok := cache.Get("d"); ok {
+		t.Errorf("Expected d to have expired")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("Expected %s to survive", key)
+		}
+	}
+
+	// Swap doesn't report a previous value for an expired entry
+	cache.Set("e", 5)
+	clock.Advance(2 * time.Minute)
+	if previous, ok := cache.Swap("e", 50); ok {
+		t.Errorf("Expected no previous value for an expired key, got %v", previous)
+	}
+	if previous, ok := cache.Swap("e", 500); !ok || previous != 50 {
+		t.Errorf("Expected previous value 50, got %v (ok=%v)", previous, ok)
+	}
+}
+
+func TestLRUTTLCache_ExpiredEntriesGoFirst(t *testing.T) {
+	clock := newManualClock()
+	var log evictionLog
+	cache, err := NewLRUTTLCache(Config{MaxSize: 3, Clock: clock, OnEvict: log.record}, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create LRU TTL cache: %v", err)
+	}
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.SetWithTTL("c", 3, time.Minute)
+	clock.Advance(2 * time.Minute)
+
+	// c is the most recently used, but it has expired
+	if cache.Size() != 2 {
+		t.Errorf("Expected the expired entry to be left out of Size, got %d", cache.Size())
+	}
+	cache.Set("d", 4)
+	if want := "[c=3:expired]"; fmt.Sprint(log) != want {
+		t.Errorf("Expected %s, got %v", want, log)
+	}
+	for _, key := range []string{"a", "b", "d"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("Expected %s to be kept", key)
+		}
+	}
+
+	// A shrinking Resize also drops expired entries before live ones
+	log = nil
+	cache.SetWithTTL("a", 10, time.Minute)
+	clock.Advance(2 * time.Minute)
+	if err := cache.Resize(2); err != nil {
+		t.Fatalf("Unexpected error during resize: %v", err)
+	}
+	if want := "[a=1:replaced a=10:expired]"; fmt.Sprint(log) != want {
+		t.Errorf("Expected %s, got %v", want, log)
+	}
+	if cache.Size() != 2 {
+		t.Errorf("Expected size 2, got %d", cache.Size())
+	}
+}
+
+func TestLRUTTLCache_SkipEqualWrites(t *testing.T) {
+	clock := newManualClock()
+	var reasons []EvictionReason
+	cache, err := NewLRUTTLCache(Config{
+		MaxSize:         10,
+		Clock:           clock,
+		SkipEqualWrites: true,
+		OnEvict: func(key string, value interface{}, reason EvictionReason) {
+			reasons = append(reasons, reason)
+		},
+	}, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create LRU TTL cache: %v", err)
+	}
+
+	cache.Set("a", 1)
+	clock.Advance(30 * time.Second)
+
+	// An equal write is a no-op, so it neither reports nor renews a
+	cache.SetWithTTL("a", 1, time.Hour)
+	if len(reasons) != 0 {
+		t.Errorf("Expected an equal write not to be reported, got %v", reasons)
+	}
+	if ttl, _ := cache.GetTTL("a"); ttl != 30*time.Second {
+		t.Errorf("Expected the equal write to leave 30s, got %v", ttl)
+	}
+
+	cache.SetWithTTL("a", 2, time.Hour)
+	if len(reasons) != 1 || reasons[0] != Replaced {
+		t.Errorf("Expected a different value to replace a, got %v", reasons)
+	}
+
+	// Once expired, an equal value is written afresh
+	clock.Advance(2 * time.Hour)
+	cache.Set("a", 2)
+	if ttl, ok := cache.GetTTL("a"); !ok || ttl != time.Minute {
+		t.Errorf("Expected a to be stored again for 1m, got %v (ok=%v)", ttl, ok)
+	}
+}
+
+func TestLRUTTLCache_WallClockJump(t *testing.T) {
+	clock := newManualClock()
+	cache, err := NewLRUTTLCache(Config{MaxSize: 10, Clock: clock}, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create LRU TTL cache: %v", err)
+	}
+
+	cache.Set("backward", 1)
+
+	// A backward jump must not keep entries alive past their TTL
+	clock.JumpWall(-time.Hour)
+	clock.Advance(30 * time.Second)
+	if _, ok := cache.Get("backward"); !ok {
+		t.Errorf("Expected backward to be live after 30s")
+	}
+	clock.Advance(31 * time.Second)
+	if _, ok := cache.Get("backward"); ok {
+		t.Errorf("Expected backward to expire after 61s despite the wall clock jump")
+	}
+
+	// A forward jump must not expire fresh entries early
+	cache.Set("forward", 2)
+	clock.JumpWall(24 * time.Hour)
+	if ttl, ok := cache.GetTTL("forward"); !ok || ttl != time.Minute {
+		t.Errorf("Expected a full minute left after a forward jump, got %v (ok=%v)", ttl, ok)
+	}
+	if removed := cache.RemoveExpired(); removed != 0 {
+		t.Errorf("Expected RemoveExpired to find nothing after a forward jump, got %d", removed)
+	}
+	clock.Advance(59 * time.Second)
+	if _, ok := cache.Get("forward"); !ok {
+		t.Errorf("Expected forward to be live after 59s")
+	}
+}
+
+func TestNewLRUTTLCache_InvalidConfig(t *testing.T) {
+	if _, err := NewLRUTTLCache(Config{MaxSize: 10}, -time.Second); !errors.Is(err, ErrInvalidDefaultTTL) {
+		t.Errorf("Expected ErrInvalidDefaultTTL, got %v", err)
+	}
+	if _, err := NewLRUTTLCache(Config{MaxSize: 0}, time.Minute); !errors.Is(err, ErrInvalidMaxSize) {
+		t.Errorf("Expected ErrInvalidMaxSize, got %v", err)
+	}
+}
+
+func BenchmarkLRUTTL(b *testing.B) {
+	keys := make([]string, 4096)
+	for i := range keys {
+		keys[i] = "key" + strconv.Itoa(i)
+	}
+	config := Config{MaxSize: 1024}
+
+	b.Run("native", func(b *testing.B) {
+		cache, _ := NewLRUTTLCache(config, time.Minute)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			key := keys[i%len(keys)]
+			cache.Set(key, i)
+			cache.Get(key)
+		}
+	})
+
+	b.Run("wrapped", func(b *testing.B) {
+		lru, _ := NewLRUCache(config)
+		cache, _ := NewTTLCache(TTLConfig{UnderlyingCache: lru, DefaultTTL: time.Minute, ExpirationStrategy: ExpireLazy})
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			key := keys[i%len(keys)]
+			cache.Set(key, i)
+			cache.Get(key)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package littlecache
+
+import (
+	"sort"
+	"sync"
+)
+
+// Manager keeps named caches, such as one per tenant, and shuts them down
+// together. It is safe for concurrent use.
+type Manager struct {
+	mu     sync.Mutex
+	caches map[string]LittleCache
+}
+
+func NewManager() *Manager {
+	return &Manager{caches: make(map[string]LittleCache)}
+}
+
+// GetOrCreate returns the cache registered under name, creating it with
+// NewLittleCache(config) if there is none. A config without a Name is
+// given name. The config is ignored when the cache already exists.
+func (m *Manager) GetOrCreate(name string, config Config) (LittleCache, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if cache, exists := m.caches[name]; exists {
+		return cache, nil
+	}
+
+	if config.Name == "" {
+		config.Name = name
+	}
+	cache, err := NewLittleCache(config)
+	if err != nil {
+		return nil, err
+	}
+	m.caches[name] = cache
+	return cache, nil
+}
+
+// Delete closes the cache registered under name and forgets it.
+func (m *Manager) Delete(name string) {
+	m.mu.Lock()
+	cache, exists := m.caches[name]
+	delete(m.caches, name)
+	m.mu.Unlock()
+
+	if exists {
+		closeCache(cache)
+	}
+}
+
+// List returns the registered names in sorted order.
+func (m *Manager) List() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.caches))
+	for name := range m.caches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CloseAll closes every cache and empties the manager. Closing stops TTL
+// cleanup goroutines and auto-saves; the caches stay usable otherwise.
+func (m *Manager) CloseAll() {
+	m.mu.Lock()
+	caches := m.caches
+	m.caches = make(map[string]LittleCache)
+	m.mu.Unlock()
+
+	for _, cache := range caches {
+		closeCache(cache)
+	}
+}
+
+// closeCache stops whatever background work cache runs.
+func closeCache(cache LittleCache) {
+	if stopper, ok := cache.(interface{ Stop() }); ok {
+		stopper.Stop()
+	}
+	if saver, ok := cache.(interface{ StopAutoSave() }); ok {
+		saver.StopAutoSave()
+	}
+}
@@ -0,0 +1,98 @@
+package littlecache
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestManager(t *testing.T) {
+	manager := NewManager()
+
+	configs := map[string]Config{
+		"tenant-b": {MaxSize: 10, EvictionPolicy: LRU},
+		"tenant-a": {MaxSize: 10, EvictionPolicy: LFU},
+		"tenant-c": {MaxSize: 10, EvictionPolicy: TTL},
+		"tenant-d": {MaxSize: 10, EvictionPolicy: TTL},
+	}
+	for name, config := range configs {
+		if _, err := manager.GetOrCreate(name, config); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	// The same name returns the same cache, whatever the config
+	first, _ := manager.GetOrCreate("tenant-a", Config{MaxSize: 10})
+	first.Set("key", "value")
+	second, _ := manager.GetOrCreate("tenant-a", Config{MaxSize: 99, EvictionPolicy: LRU})
+	if value, ok := second.Get("key"); !ok || value != "value" {
+		t.Errorf("Expected the existing cache, got %v (ok=%v)", value, ok)
+	}
+
+	if _, err := manager.GetOrCreate("broken", Config{MaxSize: 0}); err == nil {
+		t.Errorf("Expected an error for an invalid config")
+	}
+
+	names := manager.List()
+	expected := []string{"tenant-a", "tenant-b", "tenant-c", "tenant-d"}
+	if len(names) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, names)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, names)
+		}
+	}
+
+	var ttlCaches []*TTLCache
+	for _, name := range []string{"tenant-c", "tenant-d"} {
+		cache, _ := manager.GetOrCreate(name, Config{})
+		ttlCaches = append(ttlCaches, cache.(*TTLCache))
+	}
+
+	manager.Delete("tenant-b")
+	manager.Delete("missing")
+	if names := manager.List(); len(names) != 3 {
+		t.Errorf("Expected 3 caches after delete, got %v", names)
+	}
+
+	manager.CloseAll()
+	if names := manager.List(); len(names) != 0 {
+		t.Errorf("Expected no caches after CloseAll, got %v", names)
+	}
+	for i, cache := range ttlCaches {
+		select {
+		case <-cache.cleanupDone:
+		case <-time.After(time.Second):
+			t.Errorf("Expected TTL cache %d's cleanup goroutine to stop", i)
+		}
+	}
+}
+
+func TestManager_Concurrency(t *testing.T) {
+	manager := NewManager()
+	defer manager.CloseAll()
+
+	var wg sync.WaitGroup
+	caches := make([]LittleCache, 20)
+	for i := range caches {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cache, err := manager.GetOrCreate("shared", Config{MaxSize: 10, EvictionPolicy: TTL})
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+				return
+			}
+			caches[i] = cache
+			manager.List()
+		}(i)
+	}
+	wg.Wait()
+
+	for i, cache := range caches {
+		if cache != caches[0] {
+			t.Errorf("Expected goroutine %d to get the same cache", i)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package littlecache
+
+import "unsafe"
+
+// Per-entry bookkeeping, in bytes, that MemoryUsage adds to each key's and
+// value's own size. A map entry costs its key and element plus mapSlack,
+// an allowance for the control byte and the empty slots a map keeps to
+// stay under its load factor. The figures are for the running platform.
+const (
+	mapSlack     = 8
+	stringHeader = int64(unsafe.Sizeof(""))
+	pointerSize  = int64(unsafe.Sizeof(uintptr(0)))
+	intSize      = int64(unsafe.Sizeof(int(0)))
+	ifaceSize    = int64(unsafe.Sizeof(interface{}(nil)))
+
+	defEntryOverhead          = stringHeader + ifaceSize + mapSlack
+	lruEntryOverhead          = stringHeader + pointerSize + mapSlack + int64(unsafe.Sizeof(LRUNode{}))
+	lfuEntryOverhead          = stringHeader + pointerSize + mapSlack + int64(unsafe.Sizeof(LFUNode{}))
+	ringEntryOverhead         = stringHeader + intSize + mapSlack + int64(unsafe.Sizeof(ringSlot{}))
+	secondChanceEntryOverhead = stringHeader + intSize + mapSlack + pointerSize + int64(unsafe.Sizeof(secondChanceEntry{}))
+	// A weighted entry also has its slot pointer and its weight in the tree.
+	weightedEntryOverhead = stringHeader + pointerSize + mapSlack + pointerSize + 8 + int64(unsafe.Sizeof(weightedEntry{}))
+	// A TTL record sits in a map and, if it expires, in the expiry heap.
+	ttlEntryOverhead = stringHeader + pointerSize + mapSlack + pointerSize + int64(unsafe.Sizeof(TTLEntry{}))
+)
+
+// MemoryUsage estimates the bytes the cache holds: for each entry, the key,
+// the value as Config.SizeOf measures it, and a fixed overhead for the map
+// slot and any node. It leaves out the cache's fixed-size fields, and the
+// default SizeOf counts only []byte and string values, so treat it as a
+// trend to monitor rather than an exact figure.
+func (d *DefCache) MemoryUsage() int64 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	usage := int64(len(d.data)) * defEntryOverhead
+	for key, value := range d.data {
+		usage += int64(len(key)) + d.config.sizeOf(value)
+	}
+	return usage
+}
+
+// MemoryUsage estimates the bytes held by the entries, their list nodes
+// and the map. See DefCache.MemoryUsage for what the estimate leaves out.
+func (lru *LRUCache) MemoryUsage() int64 {
+	lru.mu.RLock()
+	defer lru.mu.RUnlock()
+
+	usage := int64(lru.size) * lruEntryOverhead
+	for key, node := range lru.cache {
+		usage += int64(len(key)) + lru.config.sizeOf(node.value)
+	}
+	return usage
+}
+
+// MemoryUsage estimates the bytes held by the entries, their frequency
+// list nodes and the map. See DefCache.MemoryUsage for its limits.
+func (lfu *LFUCache) MemoryUsage() int64 {
+	lfu.mu.RLock()
+	defer lfu.mu.RUnlock()
+
+	usage := int64(lfu.size) * lfuEntryOverhead
+	for key, node := range lfu.cache {
+		usage += int64(len(key)) + lfu.config.sizeOf(node.value)
+	}
+	return usage
+}
+
+// MemoryUsage estimates the bytes held by the live entries and their
+// index. Free slots of the preallocated buffer aren't counted.
+func (r *RingCache) MemoryUsage() int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	usage := int64(r.size) * ringEntryOverhead
+	for key, pos := range r.index {
+		usage += int64(len(key)) + r.config.sizeOf(r.slots[pos].value)
+	}
+	return usage
+}
+
+// MemoryUsage estimates the bytes held by the entries, their slots and the
+// index. See DefCache.MemoryUsage for its limits.
+func (s *SecondChanceCache) MemoryUsage() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	usage := int64(s.size) * secondChanceEntryOverhead
+	for key, pos := range s.index {
+		usage += int64(len(key)) + s.config.sizeOf(s.slots[pos].value)
+	}
+	return usage
+}
+
+// MemoryUsage estimates the bytes held by the entries, the index and the
+// weight tree. See DefCache.MemoryUsage for its limits.
+func (w *WeightedRandomCache) MemoryUsage() int64 {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	usage := int64(len(w.slots)) * weightedEntryOverhead
+	for _, entry := range w.slots {
+		usage += int64(len(entry.key)) + w.config.sizeOf(entry.value)
+	}
+	return usage
+}
+
+// MemoryUsage sums the shards' estimates.
+func (s *ShardedCache) MemoryUsage() int64 {
+	var usage int64
+	for _, shard := range s.shards {
+		if meter, ok := shard.(interface{ MemoryUsage() int64 }); ok {
+			usage += meter.MemoryUsage()
+		}
+	}
+	return usage
+}
+
+// MemoryUsage adds the TTL records to the underlying cache's estimate, if
+// it makes one. The values are counted there, not again here.
+func (t *TTLCache) MemoryUsage() int64 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	usage := int64(len(t.ttlEntries)) * ttlEntryOverhead
+	if meter, ok := t.cache.(interface{ MemoryUsage() int64 }); ok {
+		usage += meter.MemoryUsage()
+	}
+	return usage
+}
+
+// MemoryUsage returns the wrapped cache's estimate, which measures the
+// values as encoded.
+func (c *codecCache) MemoryUsage() int64 {
+	if meter, ok := c.cache.(interface{ MemoryUsage() int64 }); ok {
+		return meter.MemoryUsage()
+	}
+	return 0
+}
@@ -0,0 +1,91 @@
+package littlecache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestMemoryUsage_LinearInEntries(t *testing.T) {
+	ring := func(config Config) (LittleCache, error) { return NewRingCache(config) }
+	caches := map[string]struct {
+		construct func(Config) (LittleCache, error)
+		policy    EvictionPolicy
+		overhead  int64
+	}{
+		"Def":            {NewLittleCache, NoEviction, defEntryOverhead},
+		"LRU":            {NewLittleCache, LRU, lruEntryOverhead},
+		"LFU":            {NewLittleCache, LFU, lfuEntryOverhead},
+		"Ring":           {ring, NoEviction, ringEntryOverhead},
+		"SecondChance":   {NewLittleCache, SecondChance, secondChanceEntryOverhead},
+		"WeightedRandom": {NewLittleCache, WeightedRandom, weightedEntryOverhead},
+	}
+
+	value := make([]byte, 100)
+	for name, c := range caches {
+		cache, err := c.construct(Config{MaxSize: 1000, EvictionPolicy: c.policy})
+		if err != nil {
+			t.Fatalf("%s: Failed to create cache: %v", name, err)
+		}
+		meter := cache.(interface{ MemoryUsage() int64 })
+		if usage := meter.MemoryUsage(); usage != 0 {
+			t.Errorf("%s: Expected an empty cache to use 0 bytes, got %d", name, usage)
+		}
+		if c.overhead < 32 || c.overhead > 256 {
+			t.Errorf("%s: Expected a per-entry overhead of a few words, got %d", name, c.overhead)
+		}
+
+		// "key0000" is 7 bytes, the value 100
+		for i := 0; i < 100; i++ {
+			cache.Set(fmt.Sprintf("key%04d", i), value)
+		}
+		first := meter.MemoryUsage()
+		if want := 100 * (7 + 100 + c.overhead); first != want {
+			t.Errorf("%s: Expected %d bytes for 100 entries, got %d", name, want, first)
+		}
+
+		for i := 100; i < 200; i++ {
+			cache.Set(fmt.Sprintf("key%04d", i), value)
+		}
+		if second := meter.MemoryUsage(); second != 2*first {
+			t.Errorf("%s: Expected twice the entries to use twice the %d bytes, got %d", name, first, second)
+		}
+
+		cache.Clear()
+		if usage := meter.MemoryUsage(); usage != 0 {
+			t.Errorf("%s: Expected a cleared cache to use 0 bytes, got %d", name, usage)
+		}
+	}
+}
+
+func TestMemoryUsage_CustomSizeOfAndWrappers(t *testing.T) {
+	config := Config{MaxSize: 100, EvictionPolicy: LRU, SizeOf: func(value interface{}) int64 { return 1000 }}
+	underlying, err := NewLRUCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+	ttlCache, err := NewTTLCache(TTLConfig{UnderlyingCache: underlying, DefaultTTL: time.Minute, ExpirationStrategy: ExpireLazy})
+	if err != nil {
+		t.Fatalf("Failed to create TTL cache: %v", err)
+	}
+
+	ttlCache.Set("a", 1)
+	ttlCache.Set("b", struct{}{})
+	if want, got := 2*(1+1000+lruEntryOverhead), underlying.MemoryUsage(); got != want {
+		t.Errorf("Expected SizeOf to measure every value, want %d, got %d", want, got)
+	}
+	if want, got := underlying.MemoryUsage()+2*ttlEntryOverhead, ttlCache.MemoryUsage(); got != want {
+		t.Errorf("Expected the TTL records on top of the underlying usage, want %d, got %d", want, got)
+	}
+
+	sharded, err := NewShardedCache(Config{MaxSize: 100, EvictionPolicy: LRU}, 4)
+	if err != nil {
+		t.Fatalf("Failed to create sharded cache: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		sharded.Set(fmt.Sprintf("k%d", i), "12345")
+	}
+	if want, got := 10*(2+5+lruEntryOverhead), sharded.MemoryUsage(); got != want {
+		t.Errorf("Expected the shards' usage summed, want %d, got %d", want, got)
+	}
+}
@@ -0,0 +1,29 @@
+package littlecache
+
+// Merge copies every entry of src into dst. Keys present in both are
+// resolved by onConflict, which receives the value already in dst and the
+// value from src and returns the value to keep; a nil onConflict keeps the
+// incoming value. Entries go through dst.Set, so dst's capacity and
+// eviction policy apply as usual.
+//
+// src must support Dump. Entries are copied from its least to most recently
+// used end, so an LRU dst ends up with src's recency order on top.
+func Merge(dst, src LittleCache, onConflict func(existing, incoming interface{}) interface{}) error {
+	dumper, ok := src.(interface{ Dump() []Entry })
+	if !ok {
+		return newError("merge", ErrNotIterable)
+	}
+
+	entries := dumper.Dump()
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		value := e.Value
+		if onConflict != nil {
+			if existing, exists := dst.Get(e.Key); exists {
+				value = onConflict(existing, e.Value)
+			}
+		}
+		dst.Set(e.Key, value)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package littlecache
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMerge_ConflictResolution(t *testing.T) {
+	dst, err := NewLRUCache(Config{MaxSize: 10, EvictionPolicy: LRU})
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+	src, err := NewDefCache(Config{MaxSize: 10})
+	if err != nil {
+		t.Fatalf("Failed to create default cache: %v", err)
+	}
+
+	dst.Set("a", 1)
+	dst.Set("b", 10)
+	src.Set("b", 5)
+	src.Set("c", 3)
+
+	keepLarger := func(existing, incoming interface{}) interface{} {
+		if existing.(int) > incoming.(int) {
+			return existing
+		}
+		return incoming
+	}
+	if err := Merge(dst, src, keepLarger); err != nil {
+		t.Fatalf("Unexpected error during merge: %v", err)
+	}
+
+	expected := map[string]int{"a": 1, "b": 10, "c": 3}
+	for key, want := range expected {
+		if value, exists := dst.Get(key); !exists || value != want {
+			t.Errorf("Expected %d for %s, got %v (exists=%v)", want, key, value, exists)
+		}
+	}
+
+	// Without a resolver the incoming value wins
+	src.Set("a", 100)
+	if err := Merge(dst, src, nil); err != nil {
+		t.Fatalf("Unexpected error during merge: %v", err)
+	}
+	if value, _ := dst.Get("a"); value != 100 {
+		t.Errorf("Expected incoming value 100 for a, got %v", value)
+	}
+}
+
+func TestMerge_RespectsCapacity(t *testing.T) {
+	dst, err := NewLRUCache(Config{MaxSize: 3, EvictionPolicy: LRU})
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+	src, err := NewLRUCache(Config{MaxSize: 3, EvictionPolicy: LRU})
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+
+	dst.Set("x", 0)
+	dst.Set("y", 0)
+	src.Set("a", 1)
+	src.Set("b", 2)
+	src.Set("c", 3)
+	src.Get("a")
+
+	if err := Merge(dst, src, nil); err != nil {
+		t.Fatalf("Unexpected error during merge: %v", err)
+	}
+
+	if dst.Size() != 3 {
+		t.Errorf("Expected size 3 after merge, got %d", dst.Size())
+	}
+	// src's recency order (a, c, b) lands on top of dst
+	for rank, key := range []string{"a", "c", "b"} {
+		if got, exists := dst.RecencyRank(key); !exists || got != rank {
+			t.Errorf("Expected rank %d for %s, got %d (exists=%v)", rank, key, got, exists)
+		}
+	}
+}
+
+func TestMerge_NotIterable(t *testing.T) {
+	dst, _ := NewDefCache(Config{MaxSize: 1})
+	err := Merge(dst, struct{ LittleCache }{dst}, nil)
+	if !errors.Is(err, ErrNotIterable) {
+		t.Errorf("Expected ErrNotIterable, got %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package littlecache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ndjsonRecord is one line of a LoadNDJSON stream. TTL is a duration
+// string such as "90s"; when empty the record is stored with Set.
+type ndjsonRecord struct {
+	Key   *string     `json:"key"`
+	Value interface{} `json:"value"`
+	TTL   string      `json:"ttl"`
+}
+
+// LoadNDJSON warms c from newline-delimited JSON records of the form
+//
+//	{"key": "user:1", "value": {"name": "Ada"}, "ttl": "10m"}
+//
+// Records with a ttl go through SetWithTTL, the rest through Set, so c's
+// capacity and eviction apply as usual. Values arrive as encoding/json
+// decodes them into an interface{}: numbers are float64, objects
+// map[string]interface{}. Blank lines are ignored.
+//
+// It returns how many records were stored: a record dropped by a full
+// NoEviction cache or by Admit isn't counted, but one evicted by a later
+// record is. Malformed records are skipped, and reported together at the
+// end as one error matching ErrInvalidRecord, with their line numbers. A
+// read error stops the load and is returned as is.
+func LoadNDJSON(c LittleCache, r io.Reader) (int, error) {
+	return loadNDJSON(c, r, false)
+}
+
+// LoadNDJSONStrict is LoadNDJSON that stops at the first malformed record.
+// Records before it stay loaded.
+func LoadNDJSONStrict(c LittleCache, r io.Reader) (int, error) {
+	return loadNDJSON(c, r, true)
+}
+
+func loadNDJSON(c LittleCache, r io.Reader, strict bool) (int, error) {
+	reader := bufio.NewReader(r)
+	var invalid []error
+	loaded := 0
+
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(data)) > 0 {
+			if parseErr := storeNDJSON(c, data, &loaded); parseErr != nil {
+				parseErr = fmt.Errorf("line %d: %w: %w", line, ErrInvalidRecord, parseErr)
+				if strict {
+					return loaded, &LittleCacheError{Cache: nameOf(c), Op: "load", Err: parseErr}
+				}
+				invalid = append(invalid, parseErr)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return loaded, err
+		}
+	}
+
+	if len(invalid) > 0 {
+		return loaded, &LittleCacheError{Cache: nameOf(c), Op: "load", Err: errors.Join(invalid...)}
+	}
+	return loaded, nil
+}
+
+// storeNDJSON decodes one record and stores it, counting it in loaded if
+// it is in the cache afterwards.
+func storeNDJSON(c LittleCache, data []byte, loaded *int) error {
+	var record ndjsonRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return err
+	}
+	if record.Key == nil {
+		return errors.New(`missing "key"`)
+	}
+
+	key := *record.Key
+	if record.TTL == "" {
+		c.Set(key, record.Value)
+	} else {
+		ttl, err := time.ParseDuration(record.TTL)
+		if err != nil {
+			return err
+		}
+		if ttl <= 0 {
+			return fmt.Errorf("ttl %s is not positive", record.TTL)
+		}
+		c.SetWithTTL(key, record.Value, ttl)
+	}
+
+	if Has(c, key) {
+		*loaded++
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package littlecache
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const ndjsonStream = `{"key": "a", "value": 1}
+{"key": "b", "value": "two", "ttl": "1m"}
+
+{"key": "c", "value": [1, 2
+{"key": "d", "value": {"name": "Ada"}}
+{"value": "no key"}
+{"key": "e", "value": 5, "ttl": "soon"}
+`
+
+func TestLoadNDJSON(t *testing.T) {
+	clock := newManualClock()
+	cache, err := NewTTLCacheFromConfig(Config{MaxSize: 10, EvictionPolicy: LRU, Clock: clock}, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create TTL cache: %v", err)
+	}
+	defer cache.Stop()
+
+	loaded, err := LoadNDJSON(cache, strings.NewReader(ndjsonStream))
+	if loaded != 3 {
+		t.Errorf("Expected 3 records loaded, got %d", loaded)
+	}
+	if !errors.Is(err, ErrInvalidRecord) {
+		t.Fatalf("Expected ErrInvalidRecord, got %v", err)
+	}
+	for _, line := range []string{"line 4:", "line 6:", "line 7:"} {
+		if !strings.Contains(err.Error(), line) {
+			t.Errorf("Expected the error to mention %q, got %v", line, err)
+		}
+	}
+
+	if value, ok := cache.Get("a"); !ok || value != 1.0 {
+		t.Errorf("Expected a=1, got %v (ok=%v)", value, ok)
+	}
+	if value, ok := cache.Get("d"); !ok || value.(map[string]interface{})["name"] != "Ada" {
+		t.Errorf("Expected d to hold an object, got %v (ok=%v)", value, ok)
+	}
+	if ttl, ok := cache.GetTTL("b"); !ok || ttl != time.Minute {
+		t.Errorf("Expected b to expire in 1m, got %v (ok=%v)", ttl, ok)
+	}
+	for _, key := range []string{"c", "e"} {
+		if Has(cache, key) {
+			t.Errorf("Expected malformed record %s to be skipped", key)
+		}
+	}
+}
+
+func TestLoadNDJSONStrict(t *testing.T) {
+	cache, err := NewLRUCache(Config{MaxSize: 10, EvictionPolicy: LRU})
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+
+	loaded, err := LoadNDJSONStrict(cache, strings.NewReader(ndjsonStream))
+	if loaded != 2 {
+		t.Errorf("Expected the 2 records before the bad line, got %d", loaded)
+	}
+	if !errors.Is(err, ErrInvalidRecord) || !strings.Contains(err.Error(), "line 4:") {
+		t.Errorf("Expected ErrInvalidRecord for line 4, got %v", err)
+	}
+	if Has(cache, "d") {
+		t.Errorf("Expected loading to stop at the bad line")
+	}
+}
+
+func TestLoadNDJSON_RespectsCapacity(t *testing.T) {
+	cache, err := NewDefCache(Config{MaxSize: 2})
+	if err != nil {
+		t.Fatalf("Failed to create def cache: %v", err)
+	}
+
+	stream := `{"key": "a", "value": 1}
+{"key": "b", "value": 2}
+{"key": "c", "value": 3}`
+	loaded, err := LoadNDJSON(cache, strings.NewReader(stream))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if loaded != 2 || cache.Size() != 2 {
+		t.Errorf("Expected 2 records loaded into a full cache, got %d (size %d)", loaded, cache.Size())
+	}
+}
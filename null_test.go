@@ -0,0 +1,51 @@
+package littlecache
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestNullCache_NeverStores(t *testing.T) {
+	cache := NewNullCache()
+
+	for i := 0; i < 10; i++ {
+		cache.Set("key"+strconv.Itoa(i), i)
+	}
+
+	if cache.Size() != 0 {
+		t.Errorf("Expected size 0, got %d", cache.Size())
+	}
+	for i := 0; i < 10; i++ {
+		if _, exists := cache.Get("key" + strconv.Itoa(i)); exists {
+			t.Errorf("Expected key%d to miss", i)
+		}
+	}
+
+	cache.Delete("key0")
+	cache.Clear()
+	if err := cache.Resize(100); err != nil {
+		t.Errorf("Expected resize to succeed, got %v", err)
+	}
+	if err := cache.Resize(0); err == nil {
+		t.Errorf("Expected error for invalid resize")
+	}
+}
+
+func TestNewLittleCache_Disabled(t *testing.T) {
+	config := Config{MaxSize: 10, EvictionPolicy: LRU, Disabled: true}
+	cache, err := NewLittleCache(config)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := cache.(*NullCache); !ok {
+		t.Fatalf("Expected NullCache when disabled, got %T", cache)
+	}
+
+	cache.Set("key", "value")
+	if _, exists := cache.Get("key"); exists {
+		t.Errorf("Expected disabled cache to miss")
+	}
+	if cache.Size() != 0 {
+		t.Errorf("Expected size 0, got %d", cache.Size())
+	}
+}
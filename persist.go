@@ -0,0 +1,320 @@
+package littlecache
+
+import (
+	"encoding/gob"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// persistedEntry is the on-disk form of one cache entry. Values are encoded
+// with encoding/gob, so custom value types must be registered with
+// gob.Register before Save or Load.
+type persistedEntry struct {
+	Key   string
+	Value interface{}
+	// Freq is the LFU access count. Snapshots from other caches, or from
+	// before it was recorded, decode it as zero.
+	Freq int
+}
+
+func encodeEntries(w io.Writer, entries []persistedEntry) error {
+	return gob.NewEncoder(w).Encode(entries)
+}
+
+func decodeEntries(r io.Reader) ([]persistedEntry, error) {
+	var entries []persistedEntry
+	if err := gob.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Save writes every entry to w.
+func (d *DefCache) Save(w io.Writer) error {
+	d.mu.RLock()
+	entries := make([]persistedEntry, 0, len(d.data))
+	for key, value := range d.data {
+		entries = append(entries, persistedEntry{Key: key, Value: value})
+	}
+	d.mu.RUnlock()
+
+	return encodeEntries(w, entries)
+}
+
+// Load replaces the cache contents with entries read from r. Entries beyond
+// MaxSize are dropped. The cache is left untouched if r can't be decoded.
+func (d *DefCache) Load(r io.Reader) error {
+	entries, err := decodeEntries(r)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.data = make(map[string]interface{}, len(entries))
+	d.reads.reset()
+	for _, e := range entries {
+		if len(d.data) >= d.config.MaxSize {
+			break
+		}
+		d.data[e.Key] = e.Value
+	}
+	d.counters.observeSize(len(d.data))
+	return nil
+}
+
+// Save writes every entry to w from least to most recently used, so Load
+// restores the same recency order.
+func (lru *LRUCache) Save(w io.Writer) error {
+	lru.mu.RLock()
+	entries := make([]persistedEntry, 0, lru.size)
+	for node := lru.tail.prev; node != lru.head; node = node.prev {
+		entries = append(entries, persistedEntry{Key: node.key, Value: node.value})
+	}
+	lru.mu.RUnlock()
+
+	return encodeEntries(w, entries)
+}
+
+// Load replaces the cache contents with entries read from r. The cache is
+// left untouched if r can't be decoded.
+func (lru *LRUCache) Load(r io.Reader) error {
+	entries, err := decodeEntries(r)
+	if err != nil {
+		return err
+	}
+
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	lru.cache = make(map[string]*LRUNode, len(entries))
+	lru.forgetAll()
+	lru.tags.reset()
+	lru.size = 0
+	lru.weight = 0
+	lru.pinned = 0
+	lru.head.next = lru.tail
+	lru.tail.prev = lru.head
+	for _, e := range entries {
+		lru.set(e.Key, e.Value, 1)
+	}
+	return nil
+}
+
+// Save writes every entry to w with its frequency, hottest buckets first
+// and oldest first within a bucket, so Load restores the same eviction
+// order and keeps the hottest entries if the snapshot doesn't fit.
+func (lfu *LFUCache) Save(w io.Writer) error {
+	lfu.mu.RLock()
+	freqs := make([]int, 0, len(lfu.freqMap))
+	for freq := range lfu.freqMap {
+		freqs = append(freqs, freq)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(freqs)))
+
+	entries := make([]persistedEntry, 0, lfu.size)
+	for _, freq := range freqs {
+		head := lfu.freqMap[freq]
+		for node := head.prev; node != head; node = node.prev {
+			entries = append(entries, persistedEntry{Key: node.key, Value: node.value, Freq: node.freq})
+		}
+	}
+	lfu.mu.RUnlock()
+
+	return encodeEntries(w, entries)
+}
+
+// Load replaces the cache contents with entries read from r, restoring
+// their frequencies. Entries saved without one start at frequency 1. The
+// cache is left untouched if r can't be decoded.
+func (lfu *LFUCache) Load(r io.Reader) error {
+	entries, err := decodeEntries(r)
+	if err != nil {
+		return err
+	}
+
+	lfu.mu.Lock()
+	defer lfu.mu.Unlock()
+
+	lfu.cache = make(map[string]*LFUNode, len(entries))
+	lfu.freqMap = make(map[int]*LFUNode)
+	lfu.tags.reset()
+	lfu.size = 0
+	lfu.pinned = 0
+	lfu.minFreq = 0
+	for _, e := range entries {
+		if lfu.size >= lfu.config.MaxSize {
+			break
+		}
+		if _, exists := lfu.cache[e.Key]; exists {
+			continue
+		}
+
+		freq := min(max(e.Freq, 1), lfu.maxFreq)
+		node := &LFUNode{key: e.Key, value: e.Value, freq: freq}
+		lfu.cache[e.Key] = node
+		lfu.addNode(node, freq)
+		lfu.size++
+		lfu.counters.observeSize(lfu.size)
+		if lfu.minFreq == 0 || freq < lfu.minFreq {
+			lfu.minFreq = freq
+		}
+	}
+	return nil
+}
+
+// writeFileAtomic writes to a temporary file next to path and renames it
+// into place, so readers never see a partially written snapshot.
+func writeFileAtomic(path string, write func(io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// autoSaver periodically writes a cache snapshot to a file.
+type autoSaver struct {
+	mu   sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+
+	// errMu guards lastErr apart from mu, which stopLocked holds while it
+	// waits for the goroutine that sets lastErr.
+	errMu   sync.Mutex
+	lastErr error
+}
+
+func (a *autoSaver) start(clock Clock, path string, interval time.Duration, save func(io.Writer) error) error {
+	if interval <= 0 {
+		return newError("autosave", ErrInvalidInterval)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.stopLocked()
+	a.stop = make(chan struct{})
+	a.done = make(chan struct{})
+	a.setErr(nil)
+
+	ticker := clockOrDefault(clock).NewTicker(interval)
+	go func(stop, done chan struct{}) {
+		defer close(done)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C():
+				// A failed write keeps the previous snapshot in place; the
+				// next tick tries again.
+				err := writeFileAtomic(path, save)
+				if err != nil {
+					err = newError("autosave", err)
+				}
+				a.setErr(err)
+			case <-stop:
+				return
+			}
+		}
+	}(a.stop, a.done)
+
+	return nil
+}
+
+func (a *autoSaver) setErr(err error) {
+	a.errMu.Lock()
+	defer a.errMu.Unlock()
+	a.lastErr = err
+}
+
+func (a *autoSaver) err() error {
+	a.errMu.Lock()
+	defer a.errMu.Unlock()
+	return a.lastErr
+}
+
+func (a *autoSaver) stopLocked() {
+	if a.stop == nil {
+		return
+	}
+	close(a.stop)
+	<-a.done
+	a.stop = nil
+	a.done = nil
+}
+
+func (a *autoSaver) halt() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.stopLocked()
+}
+
+// StartAutoSave writes a snapshot of the cache to path every interval until
+// StopAutoSave is called. Each snapshot replaces the file atomically.
+func (d *DefCache) StartAutoSave(path string, interval time.Duration) error {
+	return d.saver.start(d.config.Clock, path, interval, d.Save)
+}
+
+// StopAutoSave stops a running auto-save and waits for it to exit.
+func (d *DefCache) StopAutoSave() {
+	d.saver.halt()
+}
+
+// LastSaveError returns the error from the latest auto-save write, or nil
+// if it succeeded or none has run since StartAutoSave.
+func (d *DefCache) LastSaveError() error {
+	return d.saver.err()
+}
+
+// StartAutoSave writes a snapshot of the cache to path every interval until
+// StopAutoSave is called. Each snapshot replaces the file atomically.
+func (lru *LRUCache) StartAutoSave(path string, interval time.Duration) error {
+	return lru.saver.start(lru.config.Clock, path, interval, lru.Save)
+}
+
+// StopAutoSave stops a running auto-save and waits for it to exit.
+func (lru *LRUCache) StopAutoSave() {
+	lru.saver.halt()
+}
+
+// LastSaveError returns the error from the latest auto-save write, or nil
+// if it succeeded or none has run since StartAutoSave.
+func (lru *LRUCache) LastSaveError() error {
+	return lru.saver.err()
+}
+
+// StartAutoSave writes a snapshot of the cache to path every interval until
+// StopAutoSave is called. Each snapshot replaces the file atomically.
+func (lfu *LFUCache) StartAutoSave(path string, interval time.Duration) error {
+	return lfu.saver.start(lfu.config.Clock, path, interval, lfu.Save)
+}
+
+// StopAutoSave stops a running auto-save and waits for it to exit.
+func (lfu *LFUCache) StopAutoSave() {
+	lfu.saver.halt()
+}
+
+// LastSaveError returns the error from the latest auto-save write, or nil
+// if it succeeded or none has run since StartAutoSave.
+func (lfu *LFUCache) LastSaveError() error {
+	return lfu.saver.err()
+}
@@ -0,0 +1,261 @@
+package littlecache
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLRUCache_SaveLoad(t *testing.T) {
+	config := Config{MaxSize: 3, EvictionPolicy: LRU}
+	cache, err := NewLRUCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+
+	cache.Set("a", 1)
+	cache.Set("b", "two")
+	cache.Set("c", []byte("three"))
+	cache.Get("a")
+
+	var buf bytes.Buffer
+	if err := cache.Save(&buf); err != nil {
+		t.Fatalf("Unexpected error saving: %v", err)
+	}
+
+	restored, _ := NewLRUCache(config)
+	if err := restored.Load(&buf); err != nil {
+		t.Fatalf("Unexpected error loading: %v", err)
+	}
+
+	if restored.Size() != 3 {
+		t.Errorf("Expected size 3, got %d", restored.Size())
+	}
+
+	// Recency order survives the round trip: b is still least recently used
+	if candidate, _ := restored.EvictionCandidate(); candidate != "b" {
+		t.Errorf("Expected eviction candidate b, got %s", candidate)
+	}
+	if value, _ := restored.Get("c"); !bytes.Equal(value.([]byte), []byte("three")) {
+		t.Errorf("Expected c to round-trip, got %v", value)
+	}
+}
+
+func TestDefCache_SaveLoad(t *testing.T) {
+	config := Config{MaxSize: 2, EvictionPolicy: NoEviction}
+	cache, err := NewDefCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create DefCache: %v", err)
+	}
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+
+	var buf bytes.Buffer
+	if err := cache.Save(&buf); err != nil {
+		t.Fatalf("Unexpected error saving: %v", err)
+	}
+
+	restored, _ := NewDefCache(config)
+	restored.Set("stale", 0)
+	if err := restored.Load(&buf); err != nil {
+		t.Fatalf("Unexpected error loading: %v", err)
+	}
+
+	if _, exists := restored.Get("stale"); exists {
+		t.Errorf("Expected Load to replace existing contents")
+	}
+	if value, _ := restored.Get("b"); value != 2 {
+		t.Errorf("Expected b to be 2, got %v", value)
+	}
+}
+
+func TestLFUCache_SaveLoadFrequencies(t *testing.T) {
+	config := Config{MaxSize: 4, EvictionPolicy: LFU}
+	cache, err := NewLFUCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create LFU cache: %v", err)
+	}
+
+	cache.Set("cold", 0)
+	cache.Set("older", 1)
+	cache.Set("newer", 1)
+	cache.Set("hot", 2)
+	for i := 0; i < 4; i++ {
+		cache.Get("hot")
+	}
+	cache.Get("older")
+	cache.Get("newer")
+
+	var buf bytes.Buffer
+	if err := cache.Save(&buf); err != nil {
+		t.Fatalf("Unexpected error saving: %v", err)
+	}
+
+	snapshot := buf.Bytes()
+
+	restored, _ := NewLFUCache(config)
+	if err := restored.Load(bytes.NewReader(snapshot)); err != nil {
+		t.Fatalf("Unexpected error loading: %v", err)
+	}
+
+	expected := map[string]int{"cold": 1, "older": 2, "newer": 2, "hot": 5}
+	for key, want := range expected {
+		if freq, exists := restored.FrequencyOf(key); !exists || freq != want {
+			t.Errorf("Expected frequency %d for %s, got %d (exists=%v)", want, key, freq, exists)
+		}
+	}
+
+	if candidate, _ := restored.EvictionCandidate(); candidate != "cold" {
+		t.Errorf("Expected eviction candidate cold, got %s", candidate)
+	}
+
+	// A smaller cache keeps the hottest entries, and older still goes
+	// before newer within the same frequency
+	smaller, _ := NewLFUCache(Config{MaxSize: 3, EvictionPolicy: LFU})
+	if err := smaller.Load(bytes.NewReader(snapshot)); err != nil {
+		t.Fatalf("Unexpected error loading: %v", err)
+	}
+	if _, exists := smaller.FrequencyOf("cold"); exists {
+		t.Errorf("Expected cold to be dropped from the smaller cache")
+	}
+	if candidate, _ := smaller.EvictionCandidate(); candidate != "older" {
+		t.Errorf("Expected eviction candidate older, got %s", candidate)
+	}
+}
+
+func TestLFUCache_LoadInvalidInput(t *testing.T) {
+	cache, err := NewLFUCache(Config{MaxSize: 2, EvictionPolicy: LFU})
+	if err != nil {
+		t.Fatalf("Failed to create LFU cache: %v", err)
+	}
+	cache.Set("keep", 1)
+
+	if err := cache.Load(bytes.NewReader([]byte("not gob"))); err == nil {
+		t.Errorf("Expected error for invalid input")
+	}
+	if _, exists := cache.Get("keep"); !exists {
+		t.Errorf("Expected cache to be untouched after a failed load")
+	}
+}
+
+func TestLRUCache_AutoSave(t *testing.T) {
+	clock := newManualClock()
+	config := Config{MaxSize: 10, EvictionPolicy: LRU, Clock: clock}
+	cache, err := NewLRUCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "cache.gob")
+	if err := cache.StartAutoSave(path, time.Minute); err != nil {
+		t.Fatalf("Unexpected error starting auto-save: %v", err)
+	}
+	defer cache.StopAutoSave()
+
+	cache.Set("a", 1)
+	clock.Advance(time.Minute)
+	waitForSnapshot(t, path, map[string]interface{}{"a": 1})
+
+	cache.Set("b", 2)
+	cache.Delete("a")
+	clock.Advance(time.Minute)
+	waitForSnapshot(t, path, map[string]interface{}{"b": 2})
+
+	// No temporary files are left behind
+	files, _ := os.ReadDir(filepath.Dir(path))
+	if len(files) != 1 {
+		t.Errorf("Expected only the snapshot file, got %d files", len(files))
+	}
+}
+
+func TestAutoSave_WriteError(t *testing.T) {
+	clock := newManualClock()
+	cache, err := NewDefCache(Config{MaxSize: 10, Clock: clock})
+	if err != nil {
+		t.Fatalf("Failed to create default cache: %v", err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "missing")
+	path := filepath.Join(dir, "cache.gob")
+	if err := cache.StartAutoSave(path, time.Minute); err != nil {
+		t.Fatalf("Unexpected error starting auto-save: %v", err)
+	}
+	defer cache.StopAutoSave()
+
+	cache.Set("a", 1)
+	clock.Advance(time.Minute)
+	deadline := time.Now().Add(time.Second)
+	for cache.LastSaveError() == nil && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if err := cache.LastSaveError(); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Expected a missing directory error, got %v", err)
+	}
+
+	// The next successful write clears it
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	clock.Advance(time.Minute)
+	waitForSnapshot(t, path, map[string]interface{}{"a": 1})
+	deadline = time.Now().Add(time.Second)
+	for cache.LastSaveError() != nil && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if err := cache.LastSaveError(); err != nil {
+		t.Errorf("Expected no error after a successful save, got %v", err)
+	}
+}
+
+func TestAutoSave_InvalidInterval(t *testing.T) {
+	cache, _ := NewLFUCache(Config{MaxSize: 10, EvictionPolicy: LFU})
+	err := cache.StartAutoSave(filepath.Join(t.TempDir(), "cache.gob"), 0)
+	if !errors.Is(err, ErrInvalidInterval) {
+		t.Errorf("Expected ErrInvalidInterval, got %v", err)
+	}
+	cache.StopAutoSave()
+}
+
+// waitForSnapshot polls path until it decodes to want or a second passes.
+func waitForSnapshot(t *testing.T, path string, want map[string]interface{}) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		got := map[string]interface{}{}
+		if f, err := os.Open(path); err == nil {
+			entries, err := decodeEntries(f)
+			f.Close()
+			if err == nil {
+				for _, e := range entries {
+					got[e.Key] = e.Value
+				}
+			}
+		}
+
+		if snapshotEqual(got, want) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected snapshot %v, got %v", want, got)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func snapshotEqual(got, want map[string]interface{}) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for key, value := range want {
+		if got[key] != value {
+			return false
+		}
+	}
+	return true
+}
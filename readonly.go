@@ -0,0 +1,97 @@
+package littlecache
+
+import "time"
+
+// readOnlyCache passes reads through to cache and refuses writes.
+type readOnlyCache struct {
+	cache  LittleCache
+	strict bool
+}
+
+// ReadOnly returns a view of c that can be read but not changed. Set,
+// Swap, Delete and Clear are silently ignored and Resize fails with
+// ErrReadOnly; Get, Size and Has behave as they do on c. Writes through c
+// itself are still visible in the view.
+func ReadOnly(c LittleCache) LittleCache {
+	return &readOnlyCache{cache: c}
+}
+
+// ReadOnlyStrict is ReadOnly that panics on every write instead, for
+// catching code that wrongly tries to mutate the view.
+func ReadOnlyStrict(c LittleCache) LittleCache {
+	return &readOnlyCache{cache: c, strict: true}
+}
+
+// reject panics for a strict view and returns the error otherwise.
+func (r *readOnlyCache) reject(op string) error {
+	err := newError(op, ErrReadOnly)
+	if r.strict {
+		panic(err)
+	}
+	return err
+}
+
+func (r *readOnlyCache) Set(key string, value interface{}) {
+	r.reject("set")
+}
+
+func (r *readOnlyCache) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	r.reject("set")
+}
+
+func (r *readOnlyCache) Get(key string) (interface{}, bool) {
+	return r.cache.Get(key)
+}
+
+// Peek reads through the underlying cache's Peek, if it has one.
+func (r *readOnlyCache) Peek(key string) (interface{}, bool) {
+	return peek(r.cache, key)
+}
+
+// Swap stores nothing, so there is never a previous value.
+func (r *readOnlyCache) Swap(key string, value interface{}) (interface{}, bool) {
+	r.reject("swap")
+	return nil, false
+}
+
+func (r *readOnlyCache) Delete(key string) {
+	r.reject("delete")
+}
+
+func (r *readOnlyCache) Clear() {
+	r.reject("clear")
+}
+
+func (r *readOnlyCache) Size() int {
+	return r.cache.Size()
+}
+
+func (r *readOnlyCache) Resize(newSize int) error {
+	return r.reject("resize")
+}
+
+// Has reports whether key is cached. It uses Peek where c has it, so the
+// check doesn't count as an access; otherwise it falls back to Get.
+func Has(c LittleCache, key string) bool {
+	_, ok := peek(c, key)
+	return ok
+}
+
+// contains is Has for the cache's own bookkeeping: it looks past a
+// codecCache, so the check doesn't decode the value.
+func contains(c LittleCache, key string) bool {
+	if codec, ok := c.(*codecCache); ok {
+		c = codec.cache
+	}
+	return Has(c, key)
+}
+
+// peek reads key without touching eviction order if c allows it.
+func peek(c LittleCache, key string) (interface{}, bool) {
+	if peeker, ok := c.(interface {
+		Peek(key string) (interface{}, bool)
+	}); ok {
+		return peeker.Peek(key)
+	}
+	return c.Get(key)
+}
@@ -0,0 +1,87 @@
+package littlecache
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReadOnly(t *testing.T) {
+	cache, err := NewLRUCache(Config{MaxSize: 3})
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+
+	view := ReadOnly(cache)
+	view.Set("a", 10)
+	view.Set("c", 3)
+	view.Swap("b", 20)
+	view.Delete("a")
+	view.Clear()
+	if err := view.Resize(1); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly from Resize, got %v", err)
+	}
+
+	if cache.Size() != 2 {
+		t.Errorf("Expected the underlying cache to keep 2 entries, got %d", cache.Size())
+	}
+	if value, ok := cache.Get("a"); !ok || value != 1 {
+		t.Errorf("Expected a to be untouched, got %v (ok=%v)", value, ok)
+	}
+	if value, ok := cache.Get("b"); !ok || value != 2 {
+		t.Errorf("Expected b to be untouched, got %v (ok=%v)", value, ok)
+	}
+
+	// Reads pass through, including later writes to the underlying cache
+	cache.Set("c", 3)
+	if value, ok := view.Get("c"); !ok || value != 3 {
+		t.Errorf("Expected 3 through the view, got %v (ok=%v)", value, ok)
+	}
+	if view.Size() != 3 {
+		t.Errorf("Expected size 3 through the view, got %d", view.Size())
+	}
+	if !Has(view, "a") || Has(view, "missing") {
+		t.Errorf("Expected Has to see a but not missing")
+	}
+
+	// Has peeks, so a stays the eviction candidate
+	cache.Get("b")
+	cache.Get("c")
+	Has(view, "a")
+	if candidate, _ := cache.EvictionCandidate(); candidate != "a" {
+		t.Errorf("Expected Has not to promote a, got candidate %s", candidate)
+	}
+}
+
+func TestReadOnlyStrict(t *testing.T) {
+	cache, err := NewLRUCache(Config{MaxSize: 3})
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+	cache.Set("a", 1)
+	view := ReadOnlyStrict(cache)
+
+	writes := map[string]func(){
+		"set":    func() { view.Set("a", 2) },
+		"swap":   func() { view.Swap("a", 2) },
+		"delete": func() { view.Delete("a") },
+		"clear":  func() { view.Clear() },
+		"resize": func() { view.Resize(1) },
+	}
+	for name, write := range writes {
+		func() {
+			defer func() {
+				err, _ := recover().(error)
+				if !errors.Is(err, ErrReadOnly) {
+					t.Errorf("%s: expected a panic with ErrReadOnly, got %v", name, err)
+				}
+			}()
+			write()
+		}()
+	}
+
+	if value, ok := view.Get("a"); !ok || value != 1 {
+		t.Errorf("Expected 1, got %v (ok=%v)", value, ok)
+	}
+}
@@ -0,0 +1,193 @@
+package littlecache
+
+// Reset returns the cache to the state NewDefCache left it in: empty, at
+// its constructed capacity, with its stats and eviction history zeroed.
+// Unlike Clear, it lets a pooled cache be reused as if it were new. The
+// entries are reported to OnClear and OnEvict as Clear reports them.
+func (d *DefCache) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.config.OnClear != nil {
+		d.config.cleared(d.data)
+	}
+	d.reportCleared()
+	d.config.MaxSize = d.initial
+	d.data = make(map[string]interface{}, d.config.initialMapSize())
+	d.reads.reset()
+
+	d.counters.reset(0)
+	d.mu.resetWait()
+	d.config.history.reset()
+	d.config.callbacks.reset()
+}
+
+// Reset empties the cache like Clear, then restores its constructed
+// capacity and zeroes its stats, evictions and eviction history.
+func (lru *LRUCache) Reset() {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	if lru.config.OnClear != nil {
+		lru.config.cleared(lru.snapshot())
+	}
+	lru.clearAll()
+	lru.config.MaxSize = lru.initial
+
+	lru.counters.reset(0)
+	lru.evictions.reset()
+	lru.mu.resetWait()
+	lru.config.history.reset()
+	lru.config.callbacks.reset()
+}
+
+// Reset empties the cache like Clear, then restores its constructed
+// capacity and zeroes its stats, evictions and eviction history.
+func (lfu *LFUCache) Reset() {
+	lfu.mu.Lock()
+	defer lfu.mu.Unlock()
+
+	if lfu.config.OnClear != nil {
+		lfu.config.cleared(lfu.snapshot())
+	}
+	lfu.clearAll()
+	lfu.config.MaxSize = lfu.initial
+
+	lfu.counters.reset(0)
+	lfu.evictions.reset()
+	lfu.mu.resetWait()
+	lfu.config.history.reset()
+	lfu.config.callbacks.reset()
+}
+
+// Reset empties the cache like Clear, then restores its constructed
+// capacity and zeroes its stats, evictions and eviction history.
+func (r *RingCache) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.config.OnClear != nil {
+		r.config.cleared(r.snapshot())
+	}
+	r.clearAll()
+	r.config.MaxSize = r.initial
+	r.rebuild(nil, r.initial)
+
+	r.counters.reset(0)
+	r.evictions.reset()
+	r.mu.resetWait()
+	r.config.history.reset()
+	r.config.callbacks.reset()
+}
+
+// Reset empties the cache like Clear, then restores its constructed
+// capacity and zeroes its stats, evictions and eviction history.
+func (s *SecondChanceCache) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.config.OnClear != nil {
+		s.config.cleared(s.snapshot())
+	}
+	if s.config.OnEvict != nil {
+		for key, pos := range s.index {
+			s.config.notify(key, s.slots[pos].value, Cleared)
+		}
+	}
+	s.config.MaxSize = s.initial
+	s.rebuild(nil, s.initial)
+
+	s.counters.reset(0)
+	s.evictions.reset()
+	s.mu.resetWait()
+	s.config.history.reset()
+	s.config.callbacks.reset()
+}
+
+// Reset empties the cache like Clear, then restores its constructed
+// capacity and zeroes its stats, evictions and eviction history. With
+// Config.RandomSeed set, the generator is reseeded, so the cache goes on
+// to make the same choices a new one would.
+func (w *WeightedRandomCache) Reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.config.OnClear != nil {
+		w.config.cleared(w.snapshot())
+	}
+	if w.config.OnEvict != nil {
+		for _, entry := range w.slots {
+			w.config.notify(entry.key, entry.value, Cleared)
+		}
+	}
+	w.config.MaxSize = w.initial
+	w.index = make(map[string]*weightedEntry, w.config.initialMapSize())
+	w.slots = nil
+	w.weights = weightTree{}
+	w.rng = newSeededRand(w.config.RandomSeed)
+
+	w.counters.reset(0)
+	w.evictions.reset()
+	w.mu.resetWait()
+	w.config.history.reset()
+	w.config.callbacks.reset()
+}
+
+// Reset resets every shard, which restores each one's share of the
+// constructed capacity, or clears a shard that has no Reset.
+func (s *ShardedCache) Reset() {
+	for _, shard := range s.shards {
+		if resetter, ok := shard.(interface{ Reset() }); ok {
+			resetter.Reset()
+		} else {
+			shard.Clear()
+		}
+	}
+	s.config.history.reset()
+	s.config.callbacks.reset()
+}
+
+// Reset resets the underlying cache, or clears it if it has no Reset, and
+// restarts the cleanup goroutine, so the next pass is a full
+// CleanupInterval away, as for a new cache. It also restarts a goroutine
+// that Stop ended, so Reset must not run concurrently with Stop.
+func (t *TTLCache) Reset() {
+	if t.strategy != ExpireLazy {
+		select {
+		case t.stopCleanup <- true:
+		default:
+		}
+		<-t.cleanupDone
+	}
+
+	t.mu.Lock()
+	t.reportCleared()
+	t.untrackAll(0)
+	if resetter, ok := t.cache.(interface{ Reset() }); ok {
+		resetter.Reset()
+	} else {
+		t.cache.Clear()
+	}
+	t.callbacks.reset()
+	t.mu.Unlock()
+
+	if t.strategy != ExpireLazy {
+		t.stopCleanup = make(chan bool, 1)
+		t.cleanupDone = make(chan struct{})
+		t.startCleanup(t.ctx, t.cleanupInterval)
+	}
+}
+
+// Reset resets the underlying LRUCache.
+func (c *LRUTTLCache) Reset() {
+	c.lru.Reset()
+}
+
+// Reset resets the wrapped cache, or clears it if it has no Reset.
+func (c *codecCache) Reset() {
+	if resetter, ok := c.cache.(interface{ Reset() }); ok {
+		resetter.Reset()
+		return
+	}
+	c.cache.Clear()
+}
@@ -0,0 +1,139 @@
+package littlecache
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+// resetWorkload fills cache past capacity and reads hits and misses.
+func resetWorkload(cache LittleCache) {
+	for i := 0; i < 10; i++ {
+		cache.Set("key"+strconv.Itoa(i), i)
+		cache.Get("key" + strconv.Itoa(i/2))
+	}
+}
+
+func TestReset_MatchesNewCache(t *testing.T) {
+	ring := func(config Config) (LittleCache, error) { return NewRingCache(config) }
+	constructors := map[string]func(Config) (LittleCache, error){
+		"NoEviction":     NewLittleCache,
+		"LRU":            NewLittleCache,
+		"LFU":            NewLittleCache,
+		"Ring":           ring,
+		"SecondChance":   NewLittleCache,
+		"WeightedRandom": NewLittleCache,
+	}
+	policies := map[string]EvictionPolicy{
+		"LRU":            LRU,
+		"LFU":            LFU,
+		"SecondChance":   SecondChance,
+		"WeightedRandom": WeightedRandom,
+	}
+
+	for name, construct := range constructors {
+		config := Config{MaxSize: 4, EvictionPolicy: policies[name], EvictionHistory: 8, RandomSeed: 7}
+		cache, err := construct(config)
+		if err != nil {
+			t.Fatalf("%s: Failed to create cache: %v", name, err)
+		}
+		resetWorkload(cache)
+		if err := cache.Resize(2); err != nil {
+			t.Fatalf("%s: Unexpected error: %v", name, err)
+		}
+		resetWorkload(cache)
+
+		cache.(interface{ Reset() }).Reset()
+		fresh, _ := construct(config)
+		compareToFresh(t, name, cache, fresh)
+
+		// Both should now behave alike, capacity included.
+		resetWorkload(cache)
+		resetWorkload(fresh)
+		compareToFresh(t, name+" after reuse", cache, fresh)
+	}
+}
+
+func TestReset_Sharded(t *testing.T) {
+	config := Config{MaxSize: 8, EvictionPolicy: LRU, EvictionHistory: 8}
+	cache, err := NewShardedCache(config, 2)
+	if err != nil {
+		t.Fatalf("Failed to create sharded cache: %v", err)
+	}
+	resetWorkload(cache)
+	if err := cache.Resize(4); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	cache.Reset()
+	fresh, _ := NewShardedCache(config, 2)
+	compareToFresh(t, "Sharded", cache, fresh)
+	resetWorkload(cache)
+	resetWorkload(fresh)
+	compareToFresh(t, "Sharded after reuse", cache, fresh)
+}
+
+func TestReset_TTLCacheRestartsCleanup(t *testing.T) {
+	underlying, err := NewLRUCache(Config{MaxSize: 4})
+	if err != nil {
+		t.Fatalf("Failed to create underlying cache: %v", err)
+	}
+	ttlCache, err := NewTTLCache(TTLConfig{UnderlyingCache: underlying})
+	if err != nil {
+		t.Fatalf("Failed to create TTL cache: %v", err)
+	}
+	resetWorkload(ttlCache)
+
+	oldDone := ttlCache.cleanupDone
+	ttlCache.Reset()
+	select {
+	case <-oldDone:
+	default:
+		t.Errorf("Expected Reset to stop the old cleanup goroutine")
+	}
+	if ttlCache.Size() != 0 || len(ttlCache.ttlEntries) != 0 {
+		t.Errorf("Expected an empty cache, got %d entries and %d TTL records", ttlCache.Size(), len(ttlCache.ttlEntries))
+	}
+	if stats := underlying.Stats(); stats.Hits != 0 || stats.Misses != 0 || stats.Evictions != 0 {
+		t.Errorf("Expected the underlying stats to be zeroed, got %+v", stats)
+	}
+
+	ttlCache.Set("a", 1)
+	if value, ok := ttlCache.Get("a"); !ok || value != 1 {
+		t.Errorf("Expected 1, got %v (ok=%v)", value, ok)
+	}
+
+	// The new goroutine answers Stop like the original one.
+	ttlCache.Stop()
+	select {
+	case <-ttlCache.cleanupDone:
+	case <-time.After(time.Second):
+		t.Errorf("Expected Stop to end the restarted cleanup goroutine")
+	}
+}
+
+// compareToFresh fails the test if cache reports anything fresh doesn't.
+func compareToFresh(t *testing.T, name string, cache, fresh LittleCache) {
+	t.Helper()
+
+	type statser interface{ Stats() Stats }
+	if got, want := cache.(statser).Stats(), fresh.(statser).Stats(); got != want {
+		t.Errorf("%s: Expected stats %+v, got %+v", name, want, got)
+	}
+	for i := 0; i < 10; i++ {
+		key := "key" + strconv.Itoa(i)
+		if got, want := Has(cache, key), Has(fresh, key); got != want {
+			t.Errorf("%s: Expected Has(%s) to be %v, got %v", name, key, want, got)
+		}
+	}
+
+	type recorder interface{ RecentEvictions() []EvictionRecord }
+	if got, want := len(cache.(recorder).RecentEvictions()), len(fresh.(recorder).RecentEvictions()); got != want {
+		t.Errorf("%s: Expected %d recent evictions, got %d", name, want, got)
+	}
+	if marker, ok := cache.(interface{ HighWaterMark() int }); ok {
+		if got, want := marker.HighWaterMark(), fresh.(interface{ HighWaterMark() int }).HighWaterMark(); got != want {
+			t.Errorf("%s: Expected high-water mark %d, got %d", name, want, got)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package littlecache
+
+import "time"
+
+type ringSlot struct {
+	key   string
+	value interface{}
+	live  bool
+}
+
+// RingCache is a FIFO cache backed by a preallocated circular buffer of
+// MaxSize slots. Inserting into a full cache overwrites the oldest slot, so
+// steady-state writes do not allocate list nodes.
+type RingCache struct {
+	config  Config
+	initial int // MaxSize as constructed, for Reset
+	slots   []ringSlot
+	index   map[string]int
+	start   int // position of the oldest slot
+	used    int // slots from start to the write position, deleted ones included
+	size    int // live entries
+	mu      rwMutex
+	cacheStats
+}
+
+func NewRingCache(config Config) (*RingCache, error) {
+	if err := config.Validate(); err != nil {
+		return nil, config.error("new", err)
+	}
+	config.initShared()
+
+	r := &RingCache{
+		config:  config,
+		initial: config.MaxSize,
+		slots:   make([]ringSlot, config.MaxSize),
+		index:   make(map[string]int, config.MaxSize),
+		mu:      newRWMutex(config),
+	}
+	r.bind(&r.config, &r.mu, func() int { return r.size })
+	return r, nil
+}
+
+func (r *RingCache) evictOldest() {
+	slot := &r.slots[r.start]
+	if slot.live {
+		delete(r.index, slot.key)
+		r.size--
+		r.evictions.record(clockOrDefault(r.config.Clock).Now(), 1)
+		r.config.evicted(slot.key, slot.value, CapacityEviction)
+	}
+	*slot = ringSlot{}
+	r.start = (r.start + 1) % len(r.slots)
+	r.used--
+}
+
+// ordered returns the live slots from oldest to newest.
+func (r *RingCache) ordered() []ringSlot {
+	live := make([]ringSlot, 0, r.size)
+	for i := 0; i < r.used; i++ {
+		slot := r.slots[(r.start+i)%len(r.slots)]
+		if slot.live {
+			live = append(live, slot)
+		}
+	}
+	return live
+}
+
+// rebuild lays out live slots from position 0 in a buffer of the given capacity.
+func (r *RingCache) rebuild(live []ringSlot, capacity int) {
+	if len(r.slots) != capacity {
+		r.slots = make([]ringSlot, capacity)
+	} else {
+		clear(r.slots)
+	}
+
+	copy(r.slots, live)
+	for i, slot := range live {
+		r.index[slot.key] = i
+	}
+	r.start = 0
+	r.used = len(live)
+	r.size = len(live)
+}
+
+func (r *RingCache) Set(key string, value interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if pos, exists := r.index[key]; exists && (r.config.ImmutableKeys || r.config.unchanged(r.slots[pos].value, value)) {
+		return
+	}
+	if !r.config.admits(key, value, r.size) {
+		return
+	}
+	r.set(key, value)
+}
+
+// SetWithTTL stores key like Set; entries only leave a RingCache in FIFO
+// order, so ttl is ignored.
+func (r *RingCache) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	r.Set(key, value)
+}
+
+func (r *RingCache) set(key string, value interface{}) {
+	if pos, exists := r.index[key]; exists {
+		r.config.evicted(key, r.slots[pos].value, Replaced)
+		r.slots[pos].value = value
+		return
+	}
+
+	if r.used == len(r.slots) {
+		if r.size < len(r.slots) {
+			// Deleted slots leave holes; squeeze them out instead of
+			// evicting a live entry early.
+			r.rebuild(r.ordered(), len(r.slots))
+		} else {
+			r.evictOldest()
+		}
+	}
+
+	pos := (r.start + r.used) % len(r.slots)
+	r.slots[pos] = ringSlot{key: key, value: value, live: true}
+	r.index[key] = pos
+	r.used++
+	r.size++
+	r.counters.observeSize(r.size)
+}
+
+func (r *RingCache) Get(key string) (interface{}, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	pos, exists := r.index[key]
+	r.counters.record(exists)
+	if !exists {
+		return nil, false
+	}
+	return r.slots[pos].value, true
+}
+
+// LoadOrStore mirrors sync.Map.LoadOrStore. A miss stores value only if
+// Set would admit it.
+func (r *RingCache) LoadOrStore(key string, value interface{}) (interface{}, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pos, exists := r.index[key]
+	r.counters.record(exists)
+	if exists {
+		return r.slots[pos].value, true
+	}
+
+	if r.config.admits(key, value, r.size) {
+		r.set(key, value)
+	}
+	return value, false
+}
+
+// Swap stores value and returns the previous value, if any. Replacing a
+// value keeps the key's place in the FIFO order.
+func (r *RingCache) Swap(key string, value interface{}) (interface{}, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var previous interface{}
+	pos, exists := r.index[key]
+	if exists {
+		previous = r.slots[pos].value
+	}
+
+	r.set(key, value)
+	return previous, exists
+}
+
+func (r *RingCache) Delete(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pos, exists := r.index[key]
+	if !exists {
+		return
+	}
+
+	value := r.slots[pos].value
+	delete(r.index, key)
+	r.slots[pos] = ringSlot{}
+	r.size--
+	r.config.evicted(key, value, Deleted)
+
+	// Drop leading holes so the oldest slot is always live.
+	for r.used > 0 && !r.slots[r.start].live {
+		r.start = (r.start + 1) % len(r.slots)
+		r.used--
+	}
+}
+
+func (r *RingCache) Clear() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.config.OnClear != nil {
+		r.config.cleared(r.snapshot())
+	}
+	r.clearAll()
+}
+
+// clearAll empties the cache, reporting each entry as Cleared.
+func (r *RingCache) clearAll() {
+	if r.config.OnEvict != nil {
+		for key, pos := range r.index {
+			r.config.notify(key, r.slots[pos].value, Cleared)
+		}
+	}
+	r.reset()
+}
+
+// snapshot copies every entry into a map.
+func (r *RingCache) snapshot() map[string]interface{} {
+	entries := make(map[string]interface{}, r.size)
+	for key, pos := range r.index {
+		entries[key] = r.slots[pos].value
+	}
+	return entries
+}
+
+// ReplaceAll swaps in items as the entire contents under one write lock, so
+// readers see either the old or the new data set, never an empty cache.
+// Items are inserted in map order, so if they overflow the cache which ones
+// are evicted is arbitrary.
+func (r *RingCache) ReplaceAll(items map[string]interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.clearAll()
+	for key, value := range items {
+		r.set(key, value)
+	}
+}
+
+func (r *RingCache) reset() {
+	clear(r.slots)
+	r.index = make(map[string]int, len(r.slots))
+	r.start = 0
+	r.used = 0
+	r.size = 0
+}
+
+// Drain atomically removes and returns every entry.
+func (r *RingCache) Drain() map[string]interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := r.snapshot()
+	r.reset()
+	return entries
+}
+
+// Dump returns a consistent snapshot of every entry from oldest to newest.
+func (r *RingCache) Dump() []Entry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	live := r.ordered()
+	entries := make([]Entry, len(live))
+	for i, slot := range live {
+		entries[i] = Entry{Key: slot.key, Value: slot.value}
+	}
+	return entries
+}
+
+func (r *RingCache) Size() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.size
+}
+
+// Resize reallocates the buffer, keeping the newest entries in insertion
+// order when shrinking.
+func (r *RingCache) Resize(newSize int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := checkSize(newSize); err != nil {
+		return r.config.error("resize", err)
+	}
+
+	if newSize == r.config.MaxSize {
+		return nil
+	}
+
+	live := r.ordered()
+	if len(live) > newSize {
+		for _, slot := range live[:len(live)-newSize] {
+			delete(r.index, slot.key)
+			r.config.evicted(slot.key, slot.value, CapacityEviction)
+		}
+		r.evictions.record(clockOrDefault(r.config.Clock).Now(), len(live)-newSize)
+		live = live[len(live)-newSize:]
+	}
+
+	r.config.MaxSize = newSize
+	r.rebuild(live, newSize)
+	return nil
+}
+
+// chainOnEvict adds fn to the OnEvict callback, for a wrapping TTLCache.
+func (r *RingCache) chainOnEvict(fn func(key string, value interface{}, reason EvictionReason)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.config.chainOnEvict(fn)
+}
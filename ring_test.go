@@ -0,0 +1,216 @@
+package littlecache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestRingCache_BasicOperations(t *testing.T) {
+	config := Config{MaxSize: 3}
+	cache, err := NewRingCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create ring cache: %v", err)
+	}
+
+	cache.Set("key1", "value1")
+	value, exists := cache.Get("key1")
+	if !exists || value != "value1" {
+		t.Errorf("Expected value1, got %v", value)
+	}
+
+	cache.Set("key1", "updated_value1")
+	value, _ = cache.Get("key1")
+	if value != "updated_value1" {
+		t.Errorf("Expected updated_value1, got %v", value)
+	}
+	if cache.Size() != 1 {
+		t.Errorf("Expected size 1, got %d", cache.Size())
+	}
+
+	cache.Delete("key1")
+	if _, exists := cache.Get("key1"); exists {
+		t.Errorf("Expected key1 to be deleted")
+	}
+
+	cache.Set("key2", "value2")
+	cache.Clear()
+	if cache.Size() != 0 {
+		t.Errorf("Expected size 0 after clear, got %d", cache.Size())
+	}
+}
+
+func TestRingCache_WrapAroundEviction(t *testing.T) {
+	config := Config{MaxSize: 3}
+	cache, err := NewRingCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create ring cache: %v", err)
+	}
+
+	// Write enough keys to wrap the buffer several times
+	for i := 0; i < 10; i++ {
+		cache.Set("key"+strconv.Itoa(i), i)
+
+		// Reads don't change FIFO order
+		cache.Get("key0")
+	}
+
+	if cache.Size() != 3 {
+		t.Errorf("Expected size 3, got %d", cache.Size())
+	}
+	for i := 0; i < 7; i++ {
+		if _, exists := cache.Get("key" + strconv.Itoa(i)); exists {
+			t.Errorf("Expected key%d to be evicted", i)
+		}
+	}
+	for i := 7; i < 10; i++ {
+		if value, exists := cache.Get("key" + strconv.Itoa(i)); !exists || value != i {
+			t.Errorf("Expected key%d to hold %d, got %v", i, i, value)
+		}
+	}
+}
+
+func TestRingCache_DeleteLeavesRoom(t *testing.T) {
+	config := Config{MaxSize: 3}
+	cache, err := NewRingCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create ring cache: %v", err)
+	}
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.Set("c", 3)
+
+	// Deleting from the middle frees a slot without evicting anything
+	cache.Delete("b")
+	cache.Set("d", 4)
+	for _, key := range []string{"a", "c", "d"} {
+		if _, exists := cache.Get(key); !exists {
+			t.Errorf("Expected %s to exist", key)
+		}
+	}
+
+	// The next insert evicts the oldest live entry
+	cache.Set("e", 5)
+	if _, exists := cache.Get("a"); exists {
+		t.Errorf("Expected a to be evicted")
+	}
+	if cache.Size() != 3 {
+		t.Errorf("Expected size 3, got %d", cache.Size())
+	}
+}
+
+func TestRingCache_Resize(t *testing.T) {
+	config := Config{MaxSize: 4}
+	cache, err := NewRingCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create ring cache: %v", err)
+	}
+
+	// Wrap once so the oldest entry isn't at slot 0
+	for i := 0; i < 6; i++ {
+		cache.Set("key"+strconv.Itoa(i), i)
+	}
+
+	// Shrinking keeps the newest entries
+	if err := cache.Resize(2); err != nil {
+		t.Fatalf("Unexpected error during resize: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		if _, exists := cache.Get("key" + strconv.Itoa(i)); exists {
+			t.Errorf("Expected key%d to be dropped by resize", i)
+		}
+	}
+
+	// Growing preserves insertion order: key4 is still the oldest
+	if err := cache.Resize(3); err != nil {
+		t.Fatalf("Unexpected error during resize: %v", err)
+	}
+	cache.Set("key6", 6)
+	cache.Set("key7", 7)
+	if _, exists := cache.Get("key4"); exists {
+		t.Errorf("Expected key4 to be evicted first after resize")
+	}
+	for i := 5; i < 8; i++ {
+		if _, exists := cache.Get("key" + strconv.Itoa(i)); !exists {
+			t.Errorf("Expected key%d to exist", i)
+		}
+	}
+
+	if err := cache.Resize(0); err == nil {
+		t.Errorf("Expected error for invalid resize")
+	}
+}
+
+func TestRingCache_Concurrency(t *testing.T) {
+	config := Config{MaxSize: 100}
+	cache, err := NewRingCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create ring cache: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(goroutineID int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				key := "key_" + strconv.Itoa(goroutineID) + "_" + strconv.Itoa(j)
+				cache.Set(key, j)
+				cache.Get(key)
+				if j%10 == 0 {
+					cache.Delete(key)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if cache.Size() > 100 {
+		t.Errorf("Cache size exceeded capacity: %d", cache.Size())
+	}
+}
+
+func TestRingCache_Drain(t *testing.T) {
+	cache, err := NewRingCache(Config{MaxSize: 2})
+	if err != nil {
+		t.Fatalf("Failed to create ring cache: %v", err)
+	}
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.Set("c", 3)
+
+	entries := cache.Drain()
+	if len(entries) != 2 || entries["b"] != 2 || entries["c"] != 3 {
+		t.Errorf("Expected drained entries b and c, got %v", entries)
+	}
+	if cache.Size() != 0 {
+		t.Errorf("Expected size 0 after drain, got %d", cache.Size())
+	}
+}
+
+func BenchmarkFIFOOverflow(b *testing.B) {
+	keys := make([]string, 4096)
+	for i := range keys {
+		keys[i] = "key" + strconv.Itoa(i)
+	}
+	config := Config{MaxSize: 1024}
+
+	b.Run("ring", func(b *testing.B) {
+		cache, _ := NewRingCache(config)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			cache.Set(keys[i%len(keys)], i)
+		}
+	})
+
+	// Linked-list baseline: the LRU list allocates a node per insert.
+	b.Run("linked-list", func(b *testing.B) {
+		cache, _ := NewLRUCache(config)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			cache.Set(keys[i%len(keys)], i)
+		}
+	})
+}
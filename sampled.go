@@ -0,0 +1,229 @@
+package littlecache
+
+import (
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+)
+
+const defaultSampleSize = 5
+
+// SampledLRUCache approximates LRU the way Redis does: each entry records
+// when it was last used, and eviction picks the least recently used of
+// SampleSize random entries. There is no recency list to reorder, so Get
+// only needs the read lock.
+type SampledLRUCache struct {
+	config     Config
+	sampleSize int
+	entries    map[string]*sampledEntry
+	keys       []string // dense list of keys for uniform sampling
+	clock      atomic.Uint64
+	rng        *rand.Rand
+	mu         rwMutex
+	cacheStats
+}
+
+type sampledEntry struct {
+	value interface{}
+	index int // position in keys
+	// lastAccess is a logical timestamp from the cache-wide clock.
+	lastAccess atomic.Uint64
+}
+
+func NewSampledLRUCache(config Config) (*SampledLRUCache, error) {
+	if err := config.Validate(); err != nil {
+		return nil, config.error("new", err)
+	}
+	config.initShared()
+
+	sampleSize := config.SampleSize
+	if sampleSize == 0 {
+		sampleSize = defaultSampleSize
+	}
+
+	s := &SampledLRUCache{
+		config:     config,
+		sampleSize: sampleSize,
+		entries:    make(map[string]*sampledEntry),
+		rng:        rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+		mu:         newRWMutex(config),
+	}
+	s.bind(&s.config, &s.mu, func() int { return len(s.entries) })
+	return s, nil
+}
+
+func (s *SampledLRUCache) touch(entry *sampledEntry) {
+	entry.lastAccess.Store(s.clock.Add(1))
+}
+
+// remove drops key from the cache and returns its value.
+func (s *SampledLRUCache) remove(key string) interface{} {
+	entry := s.entries[key]
+	last := len(s.keys) - 1
+	s.keys[entry.index] = s.keys[last]
+	s.entries[s.keys[last]].index = entry.index
+	s.keys = s.keys[:last]
+	delete(s.entries, key)
+	return entry.value
+}
+
+// evict removes the least recently used of sampleSize randomly chosen
+// entries. Samples may repeat, which only matters for tiny caches.
+func (s *SampledLRUCache) evict() {
+	victim := ""
+	var oldest uint64
+	for i := 0; i < s.sampleSize; i++ {
+		key := s.keys[s.rng.IntN(len(s.keys))]
+		if access := s.entries[key].lastAccess.Load(); victim == "" || access < oldest {
+			victim, oldest = key, access
+		}
+	}
+	value := s.remove(victim)
+	s.evictions.record(clockOrDefault(s.config.Clock).Now(), 1)
+	s.config.evicted(victim, value, CapacityEviction)
+}
+
+func (s *SampledLRUCache) set(key string, value interface{}) {
+	if entry, exists := s.entries[key]; exists {
+		s.config.evicted(key, entry.value, Replaced)
+		entry.value = value
+		s.touch(entry)
+		return
+	}
+
+	for len(s.entries) >= s.config.MaxSize {
+		s.evict()
+	}
+
+	entry := &sampledEntry{value: value, index: len(s.keys)}
+	s.touch(entry)
+	s.entries[key] = entry
+	s.keys = append(s.keys, key)
+	s.counters.observeSize(len(s.entries))
+}
+
+func (s *SampledLRUCache) Set(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, exists := s.entries[key]; exists && (s.config.ImmutableKeys || s.config.unchanged(entry.value, value)) {
+		return
+	}
+	if !s.config.admits(key, value, len(s.entries)) {
+		return
+	}
+	s.set(key, value)
+}
+
+// SetWithTTL stores key like Set, ignoring ttl.
+func (s *SampledLRUCache) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	s.Set(key, value)
+}
+
+func (s *SampledLRUCache) Get(key string) (interface{}, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, exists := s.entries[key]
+	s.counters.record(exists)
+	if !exists {
+		return nil, false
+	}
+	s.touch(entry)
+	return entry.value, true
+}
+
+// LoadOrStore mirrors sync.Map.LoadOrStore; a hit counts as a use, as with
+// Get. A miss stores value only if Set would admit it.
+func (s *SampledLRUCache) LoadOrStore(key string, value interface{}) (interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, exists := s.entries[key]
+	s.counters.record(exists)
+	if exists {
+		s.touch(entry)
+		return entry.value, true
+	}
+
+	if s.config.admits(key, value, len(s.entries)) {
+		s.set(key, value)
+	}
+	return value, false
+}
+
+func (s *SampledLRUCache) Swap(key string, value interface{}) (interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var previous interface{}
+	entry, exists := s.entries[key]
+	if exists {
+		previous = entry.value
+	}
+
+	s.set(key, value)
+	return previous, exists
+}
+
+func (s *SampledLRUCache) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.entries[key]; exists {
+		s.config.evicted(key, s.remove(key), Deleted)
+	}
+}
+
+func (s *SampledLRUCache) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.config.OnClear != nil {
+		snapshot := make(map[string]interface{}, len(s.entries))
+		for key, entry := range s.entries {
+			snapshot[key] = entry.value
+		}
+		s.config.cleared(snapshot)
+	}
+	if s.config.OnEvict != nil {
+		for key, entry := range s.entries {
+			s.config.notify(key, entry.value, Cleared)
+		}
+	}
+	s.entries = make(map[string]*sampledEntry)
+	s.keys = nil
+}
+
+func (s *SampledLRUCache) Size() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.entries)
+}
+
+func (s *SampledLRUCache) Resize(newSize int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := checkSize(newSize); err != nil {
+		return s.config.error("resize", err)
+	}
+
+	// The same capacity needs no eviction pass.
+	if newSize == s.config.MaxSize {
+		return nil
+	}
+
+	s.config.MaxSize = newSize
+	for len(s.entries) > s.config.MaxSize {
+		s.evict()
+	}
+	return nil
+}
+
+// chainOnEvict adds fn to the OnEvict callback, for a wrapping TTLCache.
+func (s *SampledLRUCache) chainOnEvict(fn func(key string, value interface{}, reason EvictionReason)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config.chainOnEvict(fn)
+}
@@ -0,0 +1,194 @@
+package littlecache
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestSampledLRUCache_BasicOperations(t *testing.T) {
+	config := Config{MaxSize: 3, EvictionPolicy: LRU}
+	cache, err := NewSampledLRUCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create sampled LRU cache: %v", err)
+	}
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	if value, exists := cache.Get("a"); !exists || value != 1 {
+		t.Errorf("Expected 1, got %v (exists=%v)", value, exists)
+	}
+	if previous, existed := cache.Swap("b", 20); !existed || previous != 2 {
+		t.Errorf("Expected (2, true), got (%v, %v)", previous, existed)
+	}
+
+	cache.Delete("a")
+	if _, exists := cache.Get("a"); exists {
+		t.Errorf("Expected a to be deleted")
+	}
+
+	for i := 0; i < 10; i++ {
+		cache.Set("key"+strconv.Itoa(i), i)
+	}
+	if cache.Size() != 3 {
+		t.Errorf("Expected size 3, got %d", cache.Size())
+	}
+
+	if err := cache.Resize(1); err != nil {
+		t.Fatalf("Unexpected error during resize: %v", err)
+	}
+	if cache.Size() != 1 {
+		t.Errorf("Expected size 1 after resize, got %d", cache.Size())
+	}
+
+	cache.Clear()
+	if cache.Size() != 0 {
+		t.Errorf("Expected size 0 after clear, got %d", cache.Size())
+	}
+
+	if _, err := NewSampledLRUCache(Config{MaxSize: 1, SampleSize: -1}); !errors.Is(err, ErrInvalidSampleSize) {
+		t.Errorf("Expected ErrInvalidSampleSize, got %v", err)
+	}
+}
+
+func TestSampledLRUCache_SetChecks(t *testing.T) {
+	var log evictionLog
+	config := Config{
+		MaxSize:         1,
+		SkipEqualWrites: true,
+		EvictionHistory: 4,
+		OnEvict:         log.record,
+		Admit: func(key string, value interface{}, currentSize, maxSize int) bool {
+			return key != "rejected"
+		},
+	}
+	cache, err := NewSampledLRUCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create sampled LRU cache: %v", err)
+	}
+
+	cache.Set("rejected", 1)
+	cache.Set("a", 1)
+	cache.Set("a", 1)
+	cache.Set("a", 2)
+	cache.Set("b", 3)
+	cache.Delete("b")
+	cache.Set("c", 4)
+	cache.Clear()
+
+	want := []string{"a=1:replaced", "a=2:capacity", "b=3:deleted", "c=4:cleared"}
+	if fmt.Sprint(log) != fmt.Sprint(want) {
+		t.Errorf("Expected %v, got %v", want, log)
+	}
+	if stats := cache.Stats(); stats.Evictions != 1 {
+		t.Errorf("Expected 1 eviction, got %d", stats.Evictions)
+	}
+	if records := cache.RecentEvictions(); len(records) != 1 || records[0].Key != "a" {
+		t.Errorf("Expected a in the eviction history, got %v", records)
+	}
+
+	immutable, _ := NewSampledLRUCache(Config{MaxSize: 2, ImmutableKeys: true})
+	immutable.Set("a", 1)
+	immutable.Set("a", 2)
+	if value, _ := immutable.Get("a"); value != 1 {
+		t.Errorf("Expected ImmutableKeys to keep a=1, got %v", value)
+	}
+}
+
+func TestSampledLRUCache_HotKeysSurvive(t *testing.T) {
+	config := Config{MaxSize: 100, SampleSize: 10}
+	cache, err := NewSampledLRUCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create sampled LRU cache: %v", err)
+	}
+
+	hot := make([]string, 20)
+	for i := range hot {
+		hot[i] = "hot" + strconv.Itoa(i)
+		cache.Set(hot[i], i)
+	}
+
+	// Stream cold keys through while the hot set keeps being read
+	for i := 0; i < 1000; i++ {
+		cache.Set("cold"+strconv.Itoa(i), i)
+		for _, key := range hot {
+			cache.Get(key)
+		}
+	}
+
+	for _, key := range hot {
+		if _, exists := cache.Get(key); !exists {
+			t.Errorf("Expected hot key %s to survive", key)
+		}
+	}
+	if cache.Size() != 100 {
+		t.Errorf("Expected size 100, got %d", cache.Size())
+	}
+}
+
+func TestSampledLRUCache_Concurrency(t *testing.T) {
+	cache, err := NewSampledLRUCache(Config{MaxSize: 100})
+	if err != nil {
+		t.Fatalf("Failed to create sampled LRU cache: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(goroutineID int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				key := "key_" + strconv.Itoa(goroutineID) + "_" + strconv.Itoa(j)
+				cache.Set(key, j)
+				cache.Get(key)
+				if j%10 == 0 {
+					cache.Delete(key)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if cache.Size() > 100 {
+		t.Errorf("Cache size exceeded capacity: %d", cache.Size())
+	}
+}
+
+func BenchmarkSampledVsExactLRU(b *testing.B) {
+	keys := make([]string, 2048)
+	for i := range keys {
+		keys[i] = "key" + strconv.Itoa(i)
+	}
+	config := Config{MaxSize: 1024, EvictionPolicy: LRU}
+
+	run := func(b *testing.B, cache LittleCache) {
+		for i, key := range keys[:1024] {
+			cache.Set(key, i)
+		}
+		b.ReportAllocs()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				// Mostly reads, with enough writes to keep evicting
+				if i%10 == 0 {
+					cache.Set(keys[i%len(keys)], i)
+				} else {
+					cache.Get(keys[i%len(keys)])
+				}
+				i++
+			}
+		})
+	}
+
+	b.Run("sampled", func(b *testing.B) {
+		cache, _ := NewSampledLRUCache(config)
+		run(b, cache)
+	})
+	b.Run("exact", func(b *testing.B) {
+		cache, _ := NewLRUCache(config)
+		run(b, cache)
+	})
+}
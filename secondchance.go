@@ -0,0 +1,282 @@
+package littlecache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+type secondChanceEntry struct {
+	key   string
+	value interface{}
+	// referenced is set by every access and cleared as the hand passes.
+	// Get sets it under the read lock, hence the atomic.
+	referenced atomic.Bool
+}
+
+// SecondChanceCache approximates LRU with the CLOCK algorithm. Entries sit
+// in a circular buffer, and an access only sets the entry's reference bit.
+// To evict, a hand sweeps the buffer: a referenced entry has its bit
+// cleared and is passed over, and the first unreferenced one is evicted.
+// An entry read since the hand last passed therefore survives one more
+// sweep. With no list to reorder, Get only needs the read lock.
+type SecondChanceCache struct {
+	config  Config
+	initial int                  // MaxSize as constructed, for Reset
+	slots   []*secondChanceEntry // nil marks a free slot
+	free    []int                // positions of free slots
+	index   map[string]int
+	hand    int
+	size    int
+	mu      rwMutex
+	cacheStats
+}
+
+func NewSecondChanceCache(config Config) (*SecondChanceCache, error) {
+	if err := config.Validate(); err != nil {
+		return nil, config.error("new", err)
+	}
+	config.initShared()
+
+	s := &SecondChanceCache{
+		config:  config,
+		initial: config.MaxSize,
+		mu:      newRWMutex(config),
+	}
+	s.bind(&s.config, &s.mu, func() int { return s.size })
+	s.rebuild(nil, config.MaxSize)
+	return s, nil
+}
+
+// rebuild lays out entries from position 0 in a buffer of the given
+// capacity and points the hand at the first of them.
+func (s *SecondChanceCache) rebuild(entries []*secondChanceEntry, capacity int) {
+	s.slots = make([]*secondChanceEntry, capacity)
+	s.index = make(map[string]int, max(len(entries), s.config.initialMapSize()))
+	copy(s.slots, entries)
+	for i, entry := range entries {
+		s.index[entry.key] = i
+	}
+
+	// Pop from the end, so free slots are handed out in ascending order.
+	s.free = make([]int, 0, capacity-len(entries))
+	for i := capacity - 1; i >= len(entries); i-- {
+		s.free = append(s.free, i)
+	}
+	s.hand = 0
+	s.size = len(entries)
+}
+
+// ordered returns the entries in the order the hand will visit them.
+func (s *SecondChanceCache) ordered() []*secondChanceEntry {
+	entries := make([]*secondChanceEntry, 0, s.size)
+	for i := range s.slots {
+		if entry := s.slots[(s.hand+i)%len(s.slots)]; entry != nil {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// evict advances the hand to the first unreferenced entry, clearing
+// reference bits on the way, and removes it. The cache must not be empty.
+func (s *SecondChanceCache) evict() {
+	for {
+		pos := s.hand
+		s.hand = (s.hand + 1) % len(s.slots)
+
+		entry := s.slots[pos]
+		if entry == nil {
+			continue
+		}
+		if entry.referenced.Swap(false) {
+			continue
+		}
+
+		s.remove(pos)
+		s.evictions.record(clockOrDefault(s.config.Clock).Now(), 1)
+		s.config.evicted(entry.key, entry.value, CapacityEviction)
+		return
+	}
+}
+
+func (s *SecondChanceCache) remove(pos int) {
+	delete(s.index, s.slots[pos].key)
+	s.slots[pos] = nil
+	s.free = append(s.free, pos)
+	s.size--
+}
+
+func (s *SecondChanceCache) Set(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if pos, exists := s.index[key]; exists && (s.config.ImmutableKeys || s.config.unchanged(s.slots[pos].value, value)) {
+		return
+	}
+	if !s.config.admits(key, value, s.size) {
+		return
+	}
+	s.set(key, value)
+}
+
+// SetWithTTL stores key like Set, ignoring ttl.
+func (s *SecondChanceCache) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	s.Set(key, value)
+}
+
+// set stores key. Overwriting counts as an access; a new entry starts
+// unreferenced, so it gets no second chance until it is used.
+func (s *SecondChanceCache) set(key string, value interface{}) {
+	if pos, exists := s.index[key]; exists {
+		entry := s.slots[pos]
+		s.config.evicted(key, entry.value, Replaced)
+		entry.value = value
+		entry.referenced.Store(true)
+		return
+	}
+
+	if len(s.free) == 0 {
+		s.evict()
+	}
+	pos := s.free[len(s.free)-1]
+	s.free = s.free[:len(s.free)-1]
+
+	s.slots[pos] = &secondChanceEntry{key: key, value: value}
+	s.index[key] = pos
+	s.size++
+	s.counters.observeSize(s.size)
+}
+
+func (s *SecondChanceCache) Get(key string) (interface{}, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	pos, exists := s.index[key]
+	s.counters.record(exists)
+	if !exists {
+		return nil, false
+	}
+
+	entry := s.slots[pos]
+	entry.referenced.Store(true)
+	return entry.value, true
+}
+
+// Peek returns the value for key without setting its reference bit.
+func (s *SecondChanceCache) Peek(key string) (interface{}, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	pos, exists := s.index[key]
+	if !exists {
+		return nil, false
+	}
+	return s.slots[pos].value, true
+}
+
+// LoadOrStore mirrors sync.Map.LoadOrStore; a hit counts as an access and
+// a miss stores value only if Set would admit it.
+func (s *SecondChanceCache) LoadOrStore(key string, value interface{}) (interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pos, exists := s.index[key]
+	s.counters.record(exists)
+	if exists {
+		entry := s.slots[pos]
+		entry.referenced.Store(true)
+		return entry.value, true
+	}
+
+	if s.config.admits(key, value, s.size) {
+		s.set(key, value)
+	}
+	return value, false
+}
+
+// Swap stores value and returns the previous value, if any.
+func (s *SecondChanceCache) Swap(key string, value interface{}) (interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var previous interface{}
+	pos, exists := s.index[key]
+	if exists {
+		previous = s.slots[pos].value
+	}
+
+	s.set(key, value)
+	return previous, exists
+}
+
+func (s *SecondChanceCache) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if pos, exists := s.index[key]; exists {
+		entry := s.slots[pos]
+		s.remove(pos)
+		s.config.evicted(key, entry.value, Deleted)
+	}
+}
+
+func (s *SecondChanceCache) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.config.OnClear != nil {
+		s.config.cleared(s.snapshot())
+	}
+	if s.config.OnEvict != nil {
+		for key, pos := range s.index {
+			s.config.notify(key, s.slots[pos].value, Cleared)
+		}
+	}
+	s.rebuild(nil, len(s.slots))
+}
+
+// snapshot copies every entry into a map.
+func (s *SecondChanceCache) snapshot() map[string]interface{} {
+	entries := make(map[string]interface{}, s.size)
+	for key, pos := range s.index {
+		entries[key] = s.slots[pos].value
+	}
+	return entries
+}
+
+func (s *SecondChanceCache) Size() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.size
+}
+
+// Resize reallocates the buffer. Shrinking evicts with the usual sweep, and
+// the surviving entries keep their reference bits and their order relative
+// to the hand.
+func (s *SecondChanceCache) Resize(newSize int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := checkSize(newSize); err != nil {
+		return s.config.error("resize", err)
+	}
+
+	if newSize == s.config.MaxSize {
+		return nil
+	}
+
+	for s.size > newSize {
+		s.evict()
+	}
+
+	s.config.MaxSize = newSize
+	s.rebuild(s.ordered(), newSize)
+	return nil
+}
+
+// chainOnEvict adds fn to the OnEvict callback, for a wrapping TTLCache.
+func (s *SecondChanceCache) chainOnEvict(fn func(key string, value interface{}, reason EvictionReason)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config.chainOnEvict(fn)
+}
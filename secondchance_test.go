@@ -0,0 +1,161 @@
+package littlecache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestSecondChanceCache_BasicOperations(t *testing.T) {
+	cache, err := NewSecondChanceCache(Config{MaxSize: 3, EvictionPolicy: SecondChance})
+	if err != nil {
+		t.Fatalf("Failed to create second-chance cache: %v", err)
+	}
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	if value, ok := cache.Get("a"); !ok || value != 1 {
+		t.Errorf("Expected 1, got %v (ok=%v)", value, ok)
+	}
+	if previous, ok := cache.Swap("b", 20); !ok || previous != 2 {
+		t.Errorf("Expected previous value 2, got %v (ok=%v)", previous, ok)
+	}
+	if value, loaded := cache.LoadOrStore("c", 3); loaded || value != 3 {
+		t.Errorf("Expected c to be stored, got %v (loaded=%v)", value, loaded)
+	}
+
+	cache.Delete("a")
+	if _, ok := cache.Get("a"); ok {
+		t.Errorf("Expected a to be deleted")
+	}
+	if cache.Size() != 2 {
+		t.Errorf("Expected size 2, got %d", cache.Size())
+	}
+
+	// The freed slot is reused without evicting anything
+	cache.Set("d", 4)
+	if cache.Size() != 3 || cache.Stats().Evictions != 0 {
+		t.Errorf("Expected 3 entries and no evictions, got %d and %d", cache.Size(), cache.Stats().Evictions)
+	}
+
+	cache.Clear()
+	if cache.Size() != 0 {
+		t.Errorf("Expected size 0 after clear, got %d", cache.Size())
+	}
+}
+
+func TestSecondChanceCache_SecondChance(t *testing.T) {
+	cache, err := NewSecondChanceCache(Config{MaxSize: 3, EvictionPolicy: SecondChance})
+	if err != nil {
+		t.Fatalf("Failed to create second-chance cache: %v", err)
+	}
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.Set("c", 3)
+	cache.Get("a")
+
+	// The hand clears a's reference bit and evicts b instead
+	cache.Set("d", 4)
+	if _, ok := cache.Peek("a"); !ok {
+		t.Errorf("Expected a to survive the sweep after being read")
+	}
+	if _, ok := cache.Peek("b"); ok {
+		t.Errorf("Expected b to be evicted")
+	}
+
+	cache.Set("e", 5)
+	if _, ok := cache.Peek("c"); ok {
+		t.Errorf("Expected c to be evicted")
+	}
+
+	// a used up its second chance on the last sweep
+	cache.Set("f", 6)
+	if _, ok := cache.Peek("a"); ok {
+		t.Errorf("Expected a to be evicted once its reference bit was cleared")
+	}
+	for _, key := range []string{"d", "e", "f"} {
+		if _, ok := cache.Peek(key); !ok {
+			t.Errorf("Expected %s to be cached", key)
+		}
+	}
+	if evictions := cache.Stats().Evictions; evictions != 3 {
+		t.Errorf("Expected 3 evictions, got %d", evictions)
+	}
+}
+
+func TestSecondChanceCache_Resize(t *testing.T) {
+	cache, err := NewSecondChanceCache(Config{MaxSize: 4, EvictionPolicy: SecondChance})
+	if err != nil {
+		t.Fatalf("Failed to create second-chance cache: %v", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		cache.Set("key"+strconv.Itoa(i), i)
+	}
+	cache.Get("key0")
+	cache.Get("key2")
+
+	if err := cache.Resize(2); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, key := range []string{"key0", "key2"} {
+		if _, ok := cache.Peek(key); !ok {
+			t.Errorf("Expected referenced %s to survive the shrink", key)
+		}
+	}
+
+	if err := cache.Resize(8); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for i := 4; i < 10; i++ {
+		cache.Set("key"+strconv.Itoa(i), i)
+	}
+	if cache.Size() != 8 {
+		t.Errorf("Expected size 8, got %d", cache.Size())
+	}
+	if err := cache.Resize(0); err == nil {
+		t.Errorf("Expected an error for size 0")
+	}
+}
+
+func TestSecondChanceCache_Factory(t *testing.T) {
+	cache, err := NewLittleCache(Config{MaxSize: 10, EvictionPolicy: SecondChance})
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	if _, ok := cache.(*SecondChanceCache); !ok {
+		t.Errorf("Expected a *SecondChanceCache, got %T", cache)
+	}
+}
+
+func TestSecondChanceCache_Concurrency(t *testing.T) {
+	cache, err := NewSecondChanceCache(Config{MaxSize: 16, EvictionPolicy: SecondChance})
+	if err != nil {
+		t.Fatalf("Failed to create second-chance cache: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				key := "key" + strconv.Itoa((w*7+i)%40)
+				switch i % 4 {
+				case 0, 1:
+					cache.Get(key)
+				case 2:
+					cache.Set(key, i)
+				default:
+					cache.Delete(key)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if size := cache.Size(); size > 16 || size != len(cache.index) {
+		t.Errorf("Expected a consistent size of at most 16, got %d with %d indexed", size, len(cache.index))
+	}
+}
@@ -0,0 +1,169 @@
+package littlecache
+
+import "time"
+
+// ShardedCache spreads keys over several independently locked caches, so
+// writers to different shards don't contend for the same mutex. MaxSize is
+// split evenly across the shards and each shard evicts on its own.
+type ShardedCache struct {
+	config Config
+	shards []LittleCache
+	hash   func(key string) uint64
+}
+
+// NewShardedCache creates shardCount caches with NewLittleCache, each
+// holding its share of config.MaxSize.
+func NewShardedCache(config Config, shardCount int) (*ShardedCache, error) {
+	if err := config.Validate(); err != nil {
+		return nil, config.error("new", err)
+	}
+	config.initShared()
+	if shardCount <= 0 || shardCount > config.MaxSize {
+		return nil, config.error("new", ErrInvalidShardCount)
+	}
+
+	s := &ShardedCache{
+		config: config,
+		shards: make([]LittleCache, shardCount),
+		hash:   config.ShardHasher,
+	}
+	if s.hash == nil {
+		s.hash = fnv1a
+	}
+
+	for i := range s.shards {
+		shardConfig := config
+		shardConfig.MaxSize = s.shardSize(i, config.MaxSize)
+		if config.AutoTune.Interval != 0 {
+			// Each shard tunes itself within its share of the bounds.
+			shardConfig.AutoTune.MinSize = max(s.shardSize(i, config.AutoTune.MinSize), 1)
+			shardConfig.AutoTune.MaxSize = max(s.shardSize(i, config.AutoTune.MaxSize), 1)
+		}
+		shard, err := NewLittleCache(shardConfig)
+		if err != nil {
+			return nil, err
+		}
+		s.shards[i] = shard
+	}
+	return s, nil
+}
+
+// Stop stops every shard that has something to stop, such as an auto-tuner.
+func (s *ShardedCache) Stop() {
+	for _, shard := range s.shards {
+		if stopper, ok := shard.(interface{ Stop() }); ok {
+			stopper.Stop()
+		}
+	}
+}
+
+// fnv1a is the 64-bit FNV-1a hash of key.
+func fnv1a(key string) uint64 {
+	const (
+		offset64 = 14695981039346656037
+		prime64  = 1099511628211
+	)
+
+	hash := uint64(offset64)
+	for i := 0; i < len(key); i++ {
+		hash ^= uint64(key[i])
+		hash *= prime64
+	}
+	return hash
+}
+
+// shardSize is shard i's share of total, spreading the remainder over the
+// first shards.
+func (s *ShardedCache) shardSize(i, total int) int {
+	size := total / len(s.shards)
+	if i < total%len(s.shards) {
+		size++
+	}
+	return size
+}
+
+func (s *ShardedCache) shardFor(key string) LittleCache {
+	return s.shards[s.hash(key)%uint64(len(s.shards))]
+}
+
+func (s *ShardedCache) Set(key string, value interface{}) {
+	s.shardFor(key).Set(key, value)
+}
+
+// SetWithTTL passes ttl to the key's shard, which honors it if it expires
+// entries, as the shards of a TTL-policy ShardedCache do.
+func (s *ShardedCache) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	s.shardFor(key).SetWithTTL(key, value, ttl)
+}
+
+func (s *ShardedCache) Get(key string) (interface{}, bool) {
+	return s.shardFor(key).Get(key)
+}
+
+func (s *ShardedCache) Swap(key string, value interface{}) (interface{}, bool) {
+	return s.shardFor(key).Swap(key, value)
+}
+
+// LoadOrStore asks the key's shard, so the check and the store happen
+// under that shard's lock.
+func (s *ShardedCache) LoadOrStore(key string, value interface{}) (interface{}, bool) {
+	return loadOrStore(s.shardFor(key), key, value)
+}
+
+// loadOrStore uses cache's LoadOrStore. A cache without one falls back to
+// Get and Set, which is not atomic.
+func loadOrStore(cache LittleCache, key string, value interface{}) (interface{}, bool) {
+	if storer, ok := cache.(interface {
+		LoadOrStore(key string, value interface{}) (interface{}, bool)
+	}); ok {
+		return storer.LoadOrStore(key, value)
+	}
+	if existing, exists := cache.Get(key); exists {
+		return existing, true
+	}
+	cache.Set(key, value)
+	return value, false
+}
+
+func (s *ShardedCache) Delete(key string) {
+	s.shardFor(key).Delete(key)
+}
+
+// Clear empties every shard. Shards are cleared one at a time, so
+// concurrent writers may repopulate early shards before later ones clear.
+func (s *ShardedCache) Clear() {
+	for _, shard := range s.shards {
+		shard.Clear()
+	}
+}
+
+// Size returns the total number of entries across all shards. Each shard
+// is read under its own lock and never holds more than its share of
+// MaxSize, so however busy the writers, the total stays within the
+// aggregate capacity. The exception is entries a shrinking Resize keeps,
+// such as those of a NoEviction cache, which Size reports as they are.
+func (s *ShardedCache) Size() int {
+	size := 0
+	for _, shard := range s.shards {
+		size += shard.Size()
+	}
+	return size
+}
+
+// Resize splits newSize across the shards. It must leave every shard at
+// least one slot.
+func (s *ShardedCache) Resize(newSize int) error {
+	if err := checkSize(newSize); err != nil {
+		return s.config.error("resize", err)
+	}
+	if newSize < len(s.shards) {
+		return s.config.error("resize", ErrInvalidShardCount)
+	}
+
+	for i, shard := range s.shards {
+		if err := shard.Resize(s.shardSize(i, newSize)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package littlecache
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestShardedCache_BasicOperations(t *testing.T) {
+	config := Config{MaxSize: 8, EvictionPolicy: LRU}
+	cache, err := NewShardedCache(config, 4)
+	if err != nil {
+		t.Fatalf("Failed to create sharded cache: %v", err)
+	}
+
+	for i := 0; i < 8; i++ {
+		cache.Set("key"+strconv.Itoa(i), i)
+	}
+	for i := 0; i < 8; i++ {
+		cache.Get("key" + strconv.Itoa(i))
+	}
+
+	cache.Delete("key0")
+	if _, exists := cache.Get("key0"); exists {
+		t.Errorf("Expected key0 to be deleted")
+	}
+	if cache.Size() > 8 {
+		t.Errorf("Expected size at most 8, got %d", cache.Size())
+	}
+
+	cache.Clear()
+	if cache.Size() != 0 {
+		t.Errorf("Expected size 0 after clear, got %d", cache.Size())
+	}
+}
+
+func TestShardedCache_CustomHasher(t *testing.T) {
+	// Route "shard<N>:..." keys to shard N
+	hasher := func(key string) uint64 {
+		prefix, _, _ := strings.Cut(key, ":")
+		n, _ := strconv.Atoi(strings.TrimPrefix(prefix, "shard"))
+		return uint64(n)
+	}
+	config := Config{MaxSize: 12, EvictionPolicy: LRU, ShardHasher: hasher}
+	cache, err := NewShardedCache(config, 3)
+	if err != nil {
+		t.Fatalf("Failed to create sharded cache: %v", err)
+	}
+
+	keys := map[string]int{"shard0:a": 0, "shard1:b": 1, "shard2:c": 2, "shard4:d": 1}
+	for key := range keys {
+		cache.Set(key, key)
+	}
+
+	for key, want := range keys {
+		for i, shard := range cache.shards {
+			_, exists := shard.Get(key)
+			if exists != (i == want) {
+				t.Errorf("Expected %s only in shard %d, found=%v in shard %d", key, want, exists, i)
+			}
+		}
+	}
+}
+
+func TestShardedCache_DefaultHasherIsFNV1a(t *testing.T) {
+	// Reference values for 64-bit FNV-1a
+	if got := fnv1a(""); got != 0xcbf29ce484222325 {
+		t.Errorf("Unexpected hash for empty key: %x", got)
+	}
+	if got := fnv1a("a"); got != 0xaf63dc4c8601ec8c {
+		t.Errorf("Unexpected hash for \"a\": %x", got)
+	}
+}
+
+func TestShardedCache_CapacitySplit(t *testing.T) {
+	config := Config{MaxSize: 10, EvictionPolicy: LRU}
+	cache, err := NewShardedCache(config, 3)
+	if err != nil {
+		t.Fatalf("Failed to create sharded cache: %v", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		cache.Set("key"+strconv.Itoa(i), i)
+	}
+	if cache.Size() > 10 {
+		t.Errorf("Expected size at most 10, got %d", cache.Size())
+	}
+
+	if err := cache.Resize(2); !errors.Is(err, ErrInvalidShardCount) {
+		t.Errorf("Expected ErrInvalidShardCount resizing below the shard count, got %v", err)
+	}
+	if err := cache.Resize(3); err != nil {
+		t.Fatalf("Unexpected error during resize: %v", err)
+	}
+	if cache.Size() > 3 {
+		t.Errorf("Expected size at most 3 after resize, got %d", cache.Size())
+	}
+
+	if _, err := NewShardedCache(Config{MaxSize: 2}, 3); !errors.Is(err, ErrInvalidShardCount) {
+		t.Errorf("Expected ErrInvalidShardCount, got %v", err)
+	}
+}
+
+func TestShardedCache_Concurrency(t *testing.T) {
+	config := Config{MaxSize: 100, EvictionPolicy: LFU}
+	cache, err := NewShardedCache(config, 8)
+	if err != nil {
+		t.Fatalf("Failed to create sharded cache: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(goroutineID int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				key := "key_" + strconv.Itoa(goroutineID) + "_" + strconv.Itoa(j)
+				cache.Set(key, j)
+				cache.Get(key)
+				if j%10 == 0 {
+					cache.Delete(key)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if cache.Size() > 100 {
+		t.Errorf("Cache size exceeded capacity: %d", cache.Size())
+	}
+}
+
+func TestShardedCache_SizeWithinCapacity(t *testing.T) {
+	for _, policy := range []EvictionPolicy{NoEviction, LRU, LFU} {
+		cache, err := NewShardedCache(Config{MaxSize: 64, EvictionPolicy: policy}, 8)
+		if err != nil {
+			t.Fatalf("Failed to create sharded cache: %v", err)
+		}
+
+		var writers sync.WaitGroup
+		for w := 0; w < 8; w++ {
+			writers.Add(1)
+			go func(w int) {
+				defer writers.Done()
+				for i := 0; i < 2000; i++ {
+					cache.Set(strconv.Itoa(w)+":"+strconv.Itoa(i), i)
+				}
+			}(w)
+		}
+
+		// Resizing between the two capacities must not let Size slip above
+		// the larger one either
+		writers.Add(1)
+		go func() {
+			defer writers.Done()
+			for i := 0; i < 50; i++ {
+				if err := cache.Resize(32 + 32*(i%2)); err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+			}
+		}()
+
+		done := make(chan struct{})
+		checked := make(chan struct{})
+		go func() {
+			defer close(checked)
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if size := cache.Size(); size > 64 {
+					t.Errorf("Policy %d: expected size at most 64, got %d", policy, size)
+					return
+				}
+			}
+		}()
+
+		writers.Wait()
+		close(done)
+		<-checked
+
+		if size := cache.Size(); size > 64 {
+			t.Errorf("Policy %d: expected size at most 64 after the run, got %d", policy, size)
+		}
+	}
+}
+
+func TestShardedCache_SizeAfterShrinkingNoEviction(t *testing.T) {
+	cache, err := NewShardedCache(Config{MaxSize: 8, EvictionPolicy: NoEviction}, 2)
+	if err != nil {
+		t.Fatalf("Failed to create sharded cache: %v", err)
+	}
+
+	// Keep writing until both shards are full; NoEviction rejects the rest
+	for i := 0; cache.Size() < 8; i++ {
+		cache.Set(strconv.Itoa(i), i)
+	}
+
+	if err := cache.Resize(2); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The shards keep every entry, and Size must say so
+	if size := cache.Size(); size != 8 {
+		t.Errorf("Expected Size to report the 8 entries still held, got %d", size)
+	}
+}
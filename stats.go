@@ -0,0 +1,434 @@
+package littlecache
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a point-in-time summary of a cache's activity.
+type Stats struct {
+	// Name is the cache's Config.Name, for labeling exported metrics.
+	Name   string
+	Hits   int64
+	Misses int64
+	Size   int
+	// Evictions counts entries removed to make room over the cache's
+	// lifetime.
+	Evictions int64
+	// LockWaitAvg and LockWaitMax report how long callers waited to acquire
+	// the cache lock. They stay zero unless Config.TrackLockWait is set.
+	LockWaitAvg time.Duration
+	LockWaitMax time.Duration
+	// SlowCallbacks counts callbacks that outran Config.CallbackTimeout.
+	SlowCallbacks int64
+	// CallbackPanics counts callbacks whose panic was recovered; see
+	// Config.OnCallbackPanic.
+	CallbackPanics int64
+}
+
+// counters tracks Get hits and misses, and the largest size reached.
+type counters struct {
+	hits   atomic.Int64
+	misses atomic.Int64
+	peak   atomic.Int64
+}
+
+func (c *counters) record(hit bool) {
+	if hit {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+}
+
+// observeSize raises the high-water mark to size if it is higher.
+func (c *counters) observeSize(size int) {
+	for {
+		peak := c.peak.Load()
+		if int64(size) <= peak || c.peak.CompareAndSwap(peak, int64(size)) {
+			return
+		}
+	}
+}
+
+// reset zeroes the hits and misses and restarts the high-water mark at the
+// current size.
+func (c *counters) reset(size int) {
+	c.hits.Store(0)
+	c.misses.Store(0)
+	c.peak.Store(int64(size))
+}
+
+// evictionMeter counts evictions in one-second buckets covering the last
+// minute, plus a lifetime total.
+type evictionMeter struct {
+	mu      sync.Mutex
+	buckets [60]int64
+	newest  int64 // Unix second of the newest bucket
+	total   int64
+}
+
+// advance clears the buckets for seconds that passed since the newest one.
+func (m *evictionMeter) advance(now time.Time) {
+	sec := now.Unix()
+	if sec <= m.newest {
+		return
+	}
+
+	stale := sec - m.newest
+	if stale > int64(len(m.buckets)) {
+		stale = int64(len(m.buckets))
+	}
+	for i := int64(1); i <= stale; i++ {
+		m.buckets[(m.newest+i)%int64(len(m.buckets))] = 0
+	}
+	m.newest = sec
+}
+
+func (m *evictionMeter) record(now time.Time, n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.advance(now)
+	m.buckets[m.newest%int64(len(m.buckets))] += int64(n)
+	m.total += int64(n)
+}
+
+// rate returns evictions per second averaged over the last minute.
+func (m *evictionMeter) rate(now time.Time) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.advance(now)
+	var sum int64
+	for _, n := range m.buckets {
+		sum += n
+	}
+	return float64(sum) / float64(len(m.buckets))
+}
+
+func (m *evictionMeter) lifetime() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.total
+}
+
+// reset forgets every recorded eviction.
+func (m *evictionMeter) reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.buckets = [60]int64{}
+	m.total = 0
+}
+
+// rwMutex is a sync.RWMutex that, when timed, records how long each caller
+// waited to acquire it. Uncontended acquisitions go through TryLock and
+// count as zero wait, so the average covers every acquisition. When off,
+// every method is a no-op, for Config.Unsynchronized.
+type rwMutex struct {
+	sync.RWMutex
+	timed bool
+	off   bool
+
+	acquired  atomic.Int64
+	waitTotal atomic.Int64
+	waitMax   atomic.Int64
+}
+
+func newRWMutex(config Config) rwMutex {
+	return rwMutex{timed: config.TrackLockWait, off: config.Unsynchronized}
+}
+
+func (m *rwMutex) Lock() {
+	if m.off {
+		return
+	}
+	if !m.timed {
+		m.RWMutex.Lock()
+		return
+	}
+	if m.RWMutex.TryLock() {
+		m.recordWait(0)
+		return
+	}
+
+	start := time.Now()
+	m.RWMutex.Lock()
+	m.recordWait(time.Since(start))
+}
+
+func (m *rwMutex) Unlock() {
+	if !m.off {
+		m.RWMutex.Unlock()
+	}
+}
+
+func (m *rwMutex) RLock() {
+	if m.off {
+		return
+	}
+	if !m.timed {
+		m.RWMutex.RLock()
+		return
+	}
+	if m.RWMutex.TryRLock() {
+		m.recordWait(0)
+		return
+	}
+
+	start := time.Now()
+	m.RWMutex.RLock()
+	m.recordWait(time.Since(start))
+}
+
+func (m *rwMutex) RUnlock() {
+	if !m.off {
+		m.RWMutex.RUnlock()
+	}
+}
+
+func (m *rwMutex) recordWait(wait time.Duration) {
+	m.acquired.Add(1)
+	m.waitTotal.Add(int64(wait))
+	for {
+		max := m.waitMax.Load()
+		if int64(wait) <= max || m.waitMax.CompareAndSwap(max, int64(wait)) {
+			return
+		}
+	}
+}
+
+// resetWait forgets the lock wait figures.
+func (m *rwMutex) resetWait() {
+	m.acquired.Store(0)
+	m.waitTotal.Store(0)
+	m.waitMax.Store(0)
+}
+
+// cacheStats holds a cache's hit and miss counters, its eviction meter and
+// its eviction history. Embedding it gives the cache Stats, HighWaterMark,
+// FillRatio, ResetStats, EvictionRate and RecentEvictions; the cache binds
+// its config, its lock and a count of its entries once it is built.
+type cacheStats struct {
+	counters  counters
+	evictions evictionMeter
+	history   *historyRing
+
+	config *Config
+	mu     *rwMutex
+	// size returns the number of entries. It must be called with mu held.
+	size func() int
+}
+
+// bind connects the stats to the cache embedding them.
+func (s *cacheStats) bind(config *Config, mu *rwMutex, size func() int) {
+	s.config = config
+	s.mu = mu
+	s.size = size
+	s.history = config.history
+}
+
+// Stats returns hit, miss, size, eviction and lock wait figures for the cache.
+func (s *cacheStats) Stats() Stats {
+	s.mu.RLock()
+	stats := Stats{Name: s.config.Name, Size: s.size()}
+	s.mu.RUnlock()
+
+	stats.Hits = s.counters.hits.Load()
+	stats.Misses = s.counters.misses.Load()
+	stats.Evictions = s.evictions.lifetime()
+	stats.SlowCallbacks = s.config.callbacks.slowCount()
+	stats.CallbackPanics = s.config.callbacks.panicCount()
+	if n := s.mu.acquired.Load(); n > 0 {
+		stats.LockWaitAvg = time.Duration(s.mu.waitTotal.Load() / n)
+		stats.LockWaitMax = time.Duration(s.mu.waitMax.Load())
+	}
+	return stats
+}
+
+// HighWaterMark returns the most entries the cache has held since it was
+// created or ResetStats was last called.
+func (s *cacheStats) HighWaterMark() int {
+	return int(s.counters.peak.Load())
+}
+
+// FillRatio returns Size divided by MaxSize. On a DefCache it can pass 1
+// after Resize shrinks the capacity below the size, since NoEviction keeps
+// every entry.
+func (s *cacheStats) FillRatio() float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return float64(s.size()) / float64(s.config.MaxSize)
+}
+
+// ResetStats zeroes the hits, misses, evictions and lock wait figures,
+// and restarts HighWaterMark at the current size.
+func (s *cacheStats) ResetStats() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.counters.reset(s.size())
+	s.evictions.reset()
+	s.mu.resetWait()
+}
+
+// EvictionRate returns evictions per second averaged over the last minute.
+func (s *cacheStats) EvictionRate() float64 {
+	return s.evictions.rate(clockOrDefault(s.config.Clock).Now())
+}
+
+// ResetStats resets every shard that keeps Stats.
+func (s *ShardedCache) ResetStats() {
+	for _, shard := range s.shards {
+		if resetter, ok := shard.(interface{ ResetStats() }); ok {
+			resetter.ResetStats()
+		}
+	}
+}
+
+// ShardStats returns each shard's Stats, indexed like the shards, to show
+// how evenly the hash spreads keys and load. A shard without Stats of its
+// own, such as a TTLCache, reports only its Size.
+func (s *ShardedCache) ShardStats() []Stats {
+	stats := make([]Stats, len(s.shards))
+	for i, shard := range s.shards {
+		if source, ok := shard.(interface{ Stats() Stats }); ok {
+			stats[i] = source.Stats()
+		} else {
+			stats[i] = Stats{Name: s.config.Name, Size: shard.Size()}
+		}
+	}
+	return stats
+}
+
+// Stats sums the shards' hits, misses, sizes and evictions. LockWaitMax is
+// the largest of the shards' and LockWaitAvg the mean of their averages.
+func (s *ShardedCache) Stats() Stats {
+	total := Stats{
+		Name:           s.config.Name,
+		SlowCallbacks:  s.config.callbacks.slowCount(),
+		CallbackPanics: s.config.callbacks.panicCount(),
+	}
+	shards := s.ShardStats()
+	for _, stats := range shards {
+		total.Hits += stats.Hits
+		total.Misses += stats.Misses
+		total.Size += stats.Size
+		total.Evictions += stats.Evictions
+		total.LockWaitAvg += stats.LockWaitAvg
+		total.LockWaitMax = max(total.LockWaitMax, stats.LockWaitMax)
+	}
+	total.LockWaitAvg /= time.Duration(len(shards))
+	return total
+}
+
+// Stats returns the wrapped cache's Stats, or just its name and size if it
+// keeps none.
+func (c *codecCache) Stats() Stats {
+	if source, ok := c.cache.(interface{ Stats() Stats }); ok {
+		return source.Stats()
+	}
+	return Stats{Name: nameOf(c.cache), Size: c.cache.Size()}
+}
+
+// HighWaterMark returns the wrapped cache's high-water mark, or 0 if it
+// keeps none.
+func (c *codecCache) HighWaterMark() int {
+	if marker, ok := c.cache.(interface{ HighWaterMark() int }); ok {
+		return marker.HighWaterMark()
+	}
+	return 0
+}
+
+// FillRatio returns the wrapped cache's fill ratio, or 0 if it has none.
+func (c *codecCache) FillRatio() float64 {
+	if gauge, ok := c.cache.(interface{ FillRatio() float64 }); ok {
+		return gauge.FillRatio()
+	}
+	return 0
+}
+
+// ResetStats resets the wrapped cache's stats, if it keeps any.
+func (c *codecCache) ResetStats() {
+	if resetter, ok := c.cache.(interface{ ResetStats() }); ok {
+		resetter.ResetStats()
+	}
+}
+
+// EvictionRate returns the wrapped cache's eviction rate, or 0 if it
+// doesn't measure one.
+func (c *codecCache) EvictionRate() float64 {
+	if meter, ok := c.cache.(interface{ EvictionRate() float64 }); ok {
+		return meter.EvictionRate()
+	}
+	return 0
+}
+
+// Name returns Config.Name.
+func (d *DefCache) Name() string {
+	return d.config.Name
+}
+
+// Name returns Config.Name.
+func (lru *LRUCache) Name() string {
+	return lru.config.Name
+}
+
+// Name returns Config.Name.
+func (lfu *LFUCache) Name() string {
+	return lfu.config.Name
+}
+
+// Name returns Config.Name.
+func (r *RingCache) Name() string {
+	return r.config.Name
+}
+
+// Name returns Config.Name.
+func (s *SecondChanceCache) Name() string {
+	return s.config.Name
+}
+
+// Name returns Config.Name.
+func (w *WeightedRandomCache) Name() string {
+	return w.config.Name
+}
+
+// Name returns Config.Name.
+func (s *SampledLRUCache) Name() string {
+	return s.config.Name
+}
+
+// Name returns Config.Name.
+func (s *ShardedCache) Name() string {
+	return s.config.Name
+}
+
+// Name returns Config.Name.
+func (c *LRUTTLCache) Name() string {
+	return c.lru.config.Name
+}
+
+// Name returns the underlying cache's name.
+func (t *TTLCache) Name() string {
+	return nameOf(t.cache)
+}
+
+// Name returns the wrapped cache's name.
+func (l *LoadingCache) Name() string {
+	return nameOf(l.LittleCache)
+}
+
+// Name returns the wrapped cache's name.
+func (c *codecCache) Name() string {
+	return nameOf(c.cache)
+}
+
+// nameOf returns c's name, or "" if it has none.
+func nameOf(c LittleCache) string {
+	if named, ok := c.(interface{ Name() string }); ok {
+		return named.Name()
+	}
+	return ""
+}
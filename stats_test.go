@@ -0,0 +1,307 @@
+package littlecache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStats_HitsAndMisses(t *testing.T) {
+	config := Config{MaxSize: 10, EvictionPolicy: LRU}
+	def, _ := NewDefCache(config)
+	lru, _ := NewLRUCache(config)
+	lfu, _ := NewLFUCache(config)
+	ring, _ := NewRingCache(config)
+	sampled, _ := NewSampledLRUCache(config)
+
+	caches := map[string]interface {
+		LittleCache
+		Stats() Stats
+	}{
+		"def":     def,
+		"lru":     lru,
+		"lfu":     lfu,
+		"ring":    ring,
+		"sampled": sampled,
+	}
+
+	for name, cache := range caches {
+		cache.Set("a", 1)
+		cache.Set("b", 2)
+		cache.Get("a")
+		cache.Get("a")
+		cache.Get("b")
+		cache.Get("missing")
+
+		stats := cache.Stats()
+		if stats.Hits != 3 || stats.Misses != 1 || stats.Size != 2 {
+			t.Errorf("%s: expected 3 hits, 1 miss, size 2, got %+v", name, stats)
+		}
+		if stats.LockWaitAvg != 0 || stats.LockWaitMax != 0 {
+			t.Errorf("%s: expected no lock wait figures when tracking is off, got %+v", name, stats)
+		}
+	}
+}
+
+func TestStats_LockWait(t *testing.T) {
+	config := Config{MaxSize: 100, EvictionPolicy: LRU, TrackLockWait: true}
+	cache, err := NewLRUCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+
+	// Hold the lock while a crowd of goroutines piles up behind it
+	cache.mu.Lock()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(goroutineID int) {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				key := "key" + strconv.Itoa(goroutineID*10+j)
+				cache.Set(key, j)
+				cache.Get(key)
+			}
+		}(i)
+	}
+	time.Sleep(20 * time.Millisecond)
+	cache.mu.Unlock()
+	wg.Wait()
+
+	stats := cache.Stats()
+	if stats.LockWaitMax < 10*time.Millisecond {
+		t.Errorf("Expected max lock wait of at least 10ms, got %v", stats.LockWaitMax)
+	}
+	if stats.LockWaitAvg <= 0 || stats.LockWaitAvg > stats.LockWaitMax {
+		t.Errorf("Expected a positive average no larger than the max, got %v (max %v)", stats.LockWaitAvg, stats.LockWaitMax)
+	}
+}
+
+func TestEvictionRate(t *testing.T) {
+	clock := newManualClock()
+	config := Config{MaxSize: 1, EvictionPolicy: LRU, Clock: clock}
+	lru, _ := NewLRUCache(config)
+	lfu, _ := NewLFUCache(config)
+	ring, _ := NewRingCache(config)
+
+	caches := map[string]interface {
+		LittleCache
+		Stats() Stats
+		EvictionRate() float64
+	}{
+		"lru":  lru,
+		"lfu":  lfu,
+		"ring": ring,
+	}
+
+	// A burst of 121 writes into a one-slot cache evicts 120 entries
+	for i := 0; i <= 120; i++ {
+		for _, cache := range caches {
+			cache.Set("key"+strconv.Itoa(i), i)
+		}
+	}
+
+	for name, cache := range caches {
+		if rate := cache.EvictionRate(); rate != 2 {
+			t.Errorf("%s: expected 2 evictions/sec after the burst, got %v", name, rate)
+		}
+		if evictions := cache.Stats().Evictions; evictions != 120 {
+			t.Errorf("%s: expected 120 lifetime evictions, got %d", name, evictions)
+		}
+	}
+
+	// The burst stays in the window for a minute, then drops out
+	clock.Advance(59 * time.Second)
+	for name, cache := range caches {
+		if rate := cache.EvictionRate(); rate != 2 {
+			t.Errorf("%s: expected the burst to still count after 59s, got %v", name, rate)
+		}
+	}
+	clock.Advance(time.Second)
+	for name, cache := range caches {
+		if rate := cache.EvictionRate(); rate != 0 {
+			t.Errorf("%s: expected the rate to decay to 0, got %v", name, rate)
+		}
+		if evictions := cache.Stats().Evictions; evictions != 120 {
+			t.Errorf("%s: expected lifetime evictions to stay at 120, got %d", name, evictions)
+		}
+	}
+}
+
+func TestStats_Name(t *testing.T) {
+	config := Config{Name: "sessions", MaxSize: 10, EvictionPolicy: LRU}
+	def, _ := NewDefCache(config)
+	lru, _ := NewLRUCache(config)
+	lfu, _ := NewLFUCache(config)
+	ring, _ := NewRingCache(config)
+	secondChance, _ := NewSecondChanceCache(config)
+
+	caches := map[string]interface {
+		LittleCache
+		Stats() Stats
+		Name() string
+	}{
+		"def":          def,
+		"lru":          lru,
+		"lfu":          lfu,
+		"ring":         ring,
+		"secondChance": secondChance,
+	}
+
+	for name, cache := range caches {
+		if got := cache.Stats().Name; got != "sessions" {
+			t.Errorf("%s: expected Stats.Name sessions, got %q", name, got)
+		}
+		if got := cache.Name(); got != "sessions" {
+			t.Errorf("%s: expected Name sessions, got %q", name, got)
+		}
+
+		err := cache.Resize(0)
+		if err == nil || err.Error() != "littlecache: sessions: resize: invalid MaxSize: must be greater than 0" {
+			t.Errorf("%s: expected the name in the error, got %v", name, err)
+		}
+	}
+
+	// Wrappers report the name of the cache they wrap
+	ttl, _ := NewTTLCacheFromConfig(config, time.Minute)
+	defer ttl.Stop()
+	compressed, _ := NewLittleCache(Config{Name: "blobs", MaxSize: 10, EvictionPolicy: LRU, Compressor: GzipCompressor{}})
+	if got := ttl.Name(); got != "sessions" {
+		t.Errorf("Expected the TTL cache to be named sessions, got %q", got)
+	}
+	if got := nameOf(compressed); got != "blobs" {
+		t.Errorf("Expected the compressed cache to be named blobs, got %q", got)
+	}
+
+	// Managed caches default to their registered name
+	manager := NewManager()
+	defer manager.CloseAll()
+	cache, err := manager.GetOrCreate("tenant-1", Config{MaxSize: 10, EvictionPolicy: LFU})
+	if err != nil {
+		t.Fatalf("Failed to create managed cache: %v", err)
+	}
+	if got := nameOf(cache); got != "tenant-1" {
+		t.Errorf("Expected the managed cache to be named tenant-1, got %q", got)
+	}
+}
+
+func TestShardedCache_ShardStats(t *testing.T) {
+	cache, err := NewShardedCache(Config{MaxSize: 400, EvictionPolicy: LRU}, 4)
+	if err != nil {
+		t.Fatalf("Failed to create sharded cache: %v", err)
+	}
+
+	// Only use keys that hash to shard 2, as a badly chosen key scheme might
+	var hot []string
+	for i := 0; len(hot) < 50; i++ {
+		key := "key" + strconv.Itoa(i)
+		if fnv1a(key)%4 == 2 {
+			hot = append(hot, key)
+		}
+	}
+	for _, key := range hot {
+		cache.Set(key, key)
+		cache.Get(key)
+	}
+	cache.Get("missing")
+
+	shards := cache.ShardStats()
+	if len(shards) != 4 {
+		t.Fatalf("Expected 4 shard stats, got %d", len(shards))
+	}
+	for i, stats := range shards {
+		wantSize, wantHits := 0, int64(0)
+		if i == 2 {
+			wantSize, wantHits = 50, 50
+		}
+		if stats.Size != wantSize || stats.Hits != wantHits {
+			t.Errorf("Shard %d: expected size %d and %d hits, got %+v", i, wantSize, wantHits, stats)
+		}
+	}
+
+	total := cache.Stats()
+	if total.Size != 50 || total.Hits != 50 || total.Misses != 1 {
+		t.Errorf("Expected size 50, 50 hits and 1 miss in total, got %+v", total)
+	}
+
+	// Shards behind a codec still report their hits and misses
+	compressed, err := NewShardedCache(Config{MaxSize: 400, EvictionPolicy: LRU, Compressor: GzipCompressor{}}, 4)
+	if err != nil {
+		t.Fatalf("Failed to create sharded cache: %v", err)
+	}
+	for _, key := range hot {
+		compressed.Set(key, key)
+		compressed.Get(key)
+	}
+	if total := compressed.Stats(); total.Size != 50 || total.Hits != 50 {
+		t.Errorf("Expected size 50 and 50 hits over codec shards, got %+v", total)
+	}
+}
+
+func TestHighWaterMark(t *testing.T) {
+	config := Config{MaxSize: 10, EvictionPolicy: LRU}
+	def, _ := NewDefCache(config)
+	lru, _ := NewLRUCache(config)
+	lfu, _ := NewLFUCache(config)
+	ring, _ := NewRingCache(config)
+	secondChance, _ := NewSecondChanceCache(config)
+	weighted, _ := NewWeightedRandomCache(config)
+	sampled, _ := NewSampledLRUCache(config)
+	codec, _ := NewLittleCache(Config{MaxSize: 10, EvictionPolicy: LRU, Compressor: GzipCompressor{}})
+
+	type statsCache interface {
+		LittleCache
+		Stats() Stats
+		HighWaterMark() int
+		FillRatio() float64
+		ResetStats()
+	}
+	caches := map[string]statsCache{
+		"codec":        codec.(statsCache),
+		"def":          def,
+		"lru":          lru,
+		"lfu":          lfu,
+		"ring":         ring,
+		"secondChance": secondChance,
+		"weighted":     weighted,
+		"sampled":      sampled,
+	}
+
+	for name, cache := range caches {
+		for i := 0; i < 8; i++ {
+			cache.Set("key"+strconv.Itoa(i), i)
+		}
+		cache.Get("key0")
+		cache.Get("missing")
+		for i := 0; i < 6; i++ {
+			cache.Delete("key" + strconv.Itoa(i))
+		}
+
+		if got := cache.HighWaterMark(); got != 8 {
+			t.Errorf("%s: expected high-water mark 8 after draining, got %d", name, got)
+		}
+		if got := cache.FillRatio(); got != 0.2 {
+			t.Errorf("%s: expected fill ratio 0.2, got %v", name, got)
+		}
+
+		cache.ResetStats()
+		if got := cache.HighWaterMark(); got != 2 {
+			t.Errorf("%s: expected ResetStats to restart the mark at 2, got %d", name, got)
+		}
+		if stats := cache.Stats(); stats.Hits != 0 || stats.Misses != 0 || stats.Evictions != 0 {
+			t.Errorf("%s: expected zeroed stats after ResetStats, got %+v", name, stats)
+		}
+
+		// Overflowing the capacity caps the mark at MaxSize
+		for i := 0; i < 20; i++ {
+			cache.Set("more"+strconv.Itoa(i), i)
+		}
+		if got := cache.HighWaterMark(); got != 10 {
+			t.Errorf("%s: expected high-water mark 10 at capacity, got %d", name, got)
+		}
+		if got := cache.FillRatio(); got != 1 {
+			t.Errorf("%s: expected fill ratio 1, got %v", name, got)
+		}
+	}
+}
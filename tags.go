@@ -0,0 +1,149 @@
+package littlecache
+
+// tagIndex maps each tag to the keys carrying it and each key back to its
+// tags, so InvalidateTag finds its keys without a scan and a removed key
+// can drop out of its tags. The zero value is empty and ready to use; the
+// maps are only made once something is tagged.
+type tagIndex struct {
+	keys map[string]map[string]struct{} // tag -> keys
+	tags map[string][]string            // key -> tags
+}
+
+// tag replaces key's tags with tags, ignoring duplicates. No tags untags
+// the key.
+func (x *tagIndex) tag(key string, tags []string) {
+	x.untag(key)
+	if len(tags) == 0 {
+		return
+	}
+	if x.keys == nil {
+		x.keys = make(map[string]map[string]struct{})
+		x.tags = make(map[string][]string)
+	}
+
+	own := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		keys := x.keys[tag]
+		if keys == nil {
+			keys = make(map[string]struct{})
+			x.keys[tag] = keys
+		}
+		if _, dup := keys[key]; !dup {
+			keys[key] = struct{}{}
+			own = append(own, tag)
+		}
+	}
+	x.tags[key] = own
+}
+
+// untag drops key from every tag it carries, and a tag left without keys
+// along with it.
+func (x *tagIndex) untag(key string) {
+	for _, tag := range x.tags[key] {
+		delete(x.keys[tag], key)
+		if len(x.keys[tag]) == 0 {
+			delete(x.keys, tag)
+		}
+	}
+	delete(x.tags, key)
+}
+
+// keysOf returns a copy of the keys carrying tag, safe to remove them by.
+func (x *tagIndex) keysOf(tag string) []string {
+	keys := make([]string, 0, len(x.keys[tag]))
+	for key := range x.keys[tag] {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (x *tagIndex) reset() {
+	x.keys = nil
+	x.tags = nil
+}
+
+// SetWithTags stores key like Set and replaces its tags with tags, for
+// InvalidateTag. A plain Set or Swap on a tagged key keeps its tags; they
+// are dropped when the key leaves the cache, however it goes. Nothing is
+// tagged if the write is rejected.
+func (lru *LRUCache) SetWithTags(key string, value interface{}, tags ...string) {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	node, exists := lru.cache[key]
+	if exists && lru.config.ImmutableKeys {
+		return
+	}
+	if !exists || !lru.config.unchanged(node.value, value) {
+		if !lru.config.admits(key, value, lru.size) {
+			return
+		}
+		lru.set(key, value, 1)
+	}
+	if _, stored := lru.cache[key]; stored {
+		lru.tags.tag(key, tags)
+	}
+}
+
+// InvalidateTag deletes every entry carrying tag under one lock, reporting
+// each to OnEvict as Deleted, and returns how many it removed. A tagged
+// key that is no longer cached is only dropped from the index.
+func (lru *LRUCache) InvalidateTag(tag string) int {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	removed := 0
+	for _, key := range lru.tags.keysOf(tag) {
+		node, exists := lru.cache[key]
+		if !exists {
+			lru.tags.untag(key)
+			continue
+		}
+		lru.remove(node)
+		removed++
+	}
+	return removed
+}
+
+// SetWithTags stores key like Set and replaces its tags with tags, for
+// InvalidateTag. The tags stay with the key through later writes and go
+// when it is deleted or evicted. Nothing is tagged if the write is
+// rejected.
+func (lfu *LFUCache) SetWithTags(key string, value interface{}, tags ...string) {
+	lfu.mu.Lock()
+	defer lfu.mu.Unlock()
+
+	node, exists := lfu.cache[key]
+	if exists && lfu.config.ImmutableKeys {
+		return
+	}
+	if !exists || !lfu.config.unchanged(node.value, value) {
+		if !lfu.config.admits(key, value, lfu.size) {
+			return
+		}
+		lfu.set(key, value)
+	}
+	if _, stored := lfu.cache[key]; stored {
+		lfu.tags.tag(key, tags)
+	}
+}
+
+// InvalidateTag deletes every entry carrying tag under one lock, reporting
+// each to OnEvict as Deleted, and returns how many it removed. A tagged
+// key that is no longer cached is only dropped from the index.
+func (lfu *LFUCache) InvalidateTag(tag string) int {
+	lfu.mu.Lock()
+	defer lfu.mu.Unlock()
+
+	removed := 0
+	for _, key := range lfu.tags.keysOf(tag) {
+		node, exists := lfu.cache[key]
+		if !exists {
+			lfu.tags.untag(key)
+			continue
+		}
+		lfu.remove(node)
+		removed++
+	}
+	return removed
+}
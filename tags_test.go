@@ -0,0 +1,181 @@
+package littlecache
+
+import (
+	"bytes"
+	"io"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+type taggedCache interface {
+	LittleCache
+	SetWithTags(key string, value interface{}, tags ...string)
+	InvalidateTag(tag string) int
+	checkInvariants() error
+}
+
+func newTaggedCaches(t *testing.T, maxSize int) map[string]taggedCache {
+	t.Helper()
+
+	lru, err := NewLRUCache(Config{MaxSize: maxSize})
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+	lfu, err := NewLFUCache(Config{MaxSize: maxSize, EvictionPolicy: LFU})
+	if err != nil {
+		t.Fatalf("Failed to create LFU cache: %v", err)
+	}
+	return map[string]taggedCache{"LRU": lru, "LFU": lfu}
+}
+
+func TestInvalidateTag_OverlappingTags(t *testing.T) {
+	for name, cache := range newTaggedCaches(t, 10) {
+		cache.SetWithTags("user:1", 1, "users", "team:a")
+		cache.SetWithTags("user:2", 2, "users", "team:b")
+		cache.SetWithTags("user:3", 3, "users", "team:a", "team:a")
+		cache.SetWithTags("config", 4, "team:a")
+		cache.Set("plain", 5)
+
+		if removed := cache.InvalidateTag("team:a"); removed != 3 {
+			t.Errorf("%s: Expected 3 entries removed, got %d", name, removed)
+		}
+		var left []string
+		for _, key := range []string{"user:1", "user:2", "user:3", "config", "plain"} {
+			if Has(cache, key) {
+				left = append(left, key)
+			}
+		}
+		if len(left) != 2 || left[0] != "user:2" || left[1] != "plain" {
+			t.Errorf("%s: Expected only user:2 and plain to be left, got %v", name, left)
+		}
+
+		// user:1 and user:3 took their "users" tag with them.
+		if removed := cache.InvalidateTag("users"); removed != 1 {
+			t.Errorf("%s: Expected 1 entry removed, got %d", name, removed)
+		}
+		if removed := cache.InvalidateTag("team:a"); removed != 0 {
+			t.Errorf("%s: Expected an invalidated tag to be empty, got %d", name, removed)
+		}
+		if err := cache.checkInvariants(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestInvalidateTag_RemovedKeysLeaveTheIndex(t *testing.T) {
+	for name, cache := range newTaggedCaches(t, 2) {
+		cache.SetWithTags("a", 1, "x")
+		cache.SetWithTags("b", 2, "x", "y")
+		cache.SetWithTags("c", 3, "y") // evicts a
+		cache.Delete("b")
+		if err := cache.checkInvariants(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		if removed := cache.InvalidateTag("x"); removed != 0 {
+			t.Errorf("%s: Expected deleted and evicted keys to be untagged, got %d removed", name, removed)
+		}
+
+		// Retagging replaces the old tags; a plain Set keeps them.
+		cache.SetWithTags("c", 3, "z")
+		cache.Set("c", 30)
+		if removed := cache.InvalidateTag("y"); removed != 0 {
+			t.Errorf("%s: Expected retagged c to have dropped y, got %d removed", name, removed)
+		}
+		if removed := cache.InvalidateTag("z"); removed != 1 {
+			t.Errorf("%s: Expected c to keep tag z through Set, got %d removed", name, removed)
+		}
+
+		cache.SetWithTags("d", 4, "w")
+		cache.Clear()
+		if err := cache.checkInvariants(); err != nil {
+			t.Errorf("%s: after Clear: %v", name, err)
+		}
+	}
+}
+
+func TestInvalidateTag_AfterLoad(t *testing.T) {
+	for name, cache := range newTaggedCaches(t, 10) {
+		persister := cache.(interface {
+			Save(w io.Writer) error
+			Load(r io.Reader) error
+		})
+
+		cache.Set("b", 2)
+		var snapshot bytes.Buffer
+		if err := persister.Save(&snapshot); err != nil {
+			t.Fatalf("%s: Failed to save: %v", name, err)
+		}
+
+		// Load replaces a, so its tag must not outlive it
+		cache.SetWithTags("a", 1, "x")
+		if err := persister.Load(&snapshot); err != nil {
+			t.Fatalf("%s: Failed to load: %v", name, err)
+		}
+		if removed := cache.InvalidateTag("x"); removed != 0 {
+			t.Errorf("%s: Expected nothing tagged after Load, got %d removed", name, removed)
+		}
+		if !Has(cache, "b") {
+			t.Errorf("%s: Expected the loaded b to survive", name)
+		}
+		if err := cache.checkInvariants(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestInvalidateTag_RandomOpsInvariants(t *testing.T) {
+	tags := []string{"red", "green", "blue"}
+	for name, cache := range newTaggedCaches(t, 16) {
+		var wg sync.WaitGroup
+		for w := 0; w < 4; w++ {
+			wg.Add(1)
+			go func(seed uint64) {
+				defer wg.Done()
+				rng := rand.New(rand.NewPCG(seed, seed))
+				for i := 0; i < 2000; i++ {
+					key := "key" + strconv.Itoa(rng.IntN(32))
+					switch op := rng.IntN(100); {
+					case op < 50:
+						cache.SetWithTags(key, i, tags[rng.IntN(3)], tags[rng.IntN(3)])
+					case op < 70:
+						cache.Set(key, i)
+					case op < 90:
+						cache.Delete(key)
+					case op < 99:
+						cache.InvalidateTag(tags[rng.IntN(3)])
+					default:
+						cache.Resize(8 + rng.IntN(16))
+					}
+				}
+			}(uint64(w))
+		}
+		wg.Wait()
+
+		if err := cache.checkInvariants(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestTagIndex_KeysOf(t *testing.T) {
+	var index tagIndex
+	index.tag("a", []string{"x", "y"})
+	index.tag("b", []string{"x"})
+
+	keys := index.keysOf("x")
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "a" || keys[1] != "b" {
+		t.Errorf("Expected [a b], got %v", keys)
+	}
+	if keys := index.keysOf("missing"); len(keys) != 0 {
+		t.Errorf("Expected no keys for an unknown tag, got %v", keys)
+	}
+
+	index.tag("a", nil)
+	if keys := index.keysOf("y"); len(keys) != 0 {
+		t.Errorf("Expected tagging with nothing to untag, got %v", keys)
+	}
+}
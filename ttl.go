@@ -1,55 +1,289 @@
 package littlecache
 
 import (
+	"context"
+	"math"
+	"path"
+	"sort"
 	"sync"
 	"time"
 )
 
+const (
+	// NoExpiration is reported by GetTTL for entries that never expire.
+	// Passing it, or any ttl <= 0, to SetWithTTL stores such an entry.
+	NoExpiration time.Duration = math.MaxInt64
+	// DoNotStore makes SetWithTTL skip the write entirely.
+	DoNotStore time.Duration = math.MinInt64
+)
+
+// TTLEntry is the expiry record TTLCache keeps beside each key. The value
+// itself lives only in the underlying cache, so a Cipher there isn't
+// bypassed by a plaintext copy here.
 type TTLEntry struct {
-	Value     interface{}
+	// ExpiresAt is the zero time for entries that never expire. It is the
+	// wall clock time for reporting; the cache itself decides expiry on a
+	// monotonic deadline, so wall clock jumps don't affect it.
 	ExpiresAt time.Time
+	// InsertedAt is the wall clock time the entry was stored. Renewing or
+	// extending its TTL leaves it alone.
+	InsertedAt time.Time
+	// Hits counts Gets since the entry was stored. It is only tracked when
+	// TTLConfig.RenewAfterHits is set.
+	Hits int
+
+	// deadline is the monotonic reading at which the entry expires. It is
+	// only meaningful when ExpiresAt is set.
+	deadline time.Duration
+	// inserted is the monotonic reading at InsertedAt.
+	inserted time.Duration
+	// key and heapIndex place the entry in its cache's expiry heap;
+	// heapIndex is -1 when it isn't there.
+	key       string
+	heapIndex int
 }
 
+// IsExpired reports whether ExpiresAt has passed by the wall clock, which
+// can disagree with the cache after the wall clock jumps.
+//
+// Deprecated: a TTLCache decides expiry on a monotonic deadline that
+// IsExpired can't see. Use TTLCache.GetTTL, which reports a key as gone
+// exactly when the cache does.
 func (e *TTLEntry) IsExpired() bool {
-	return time.Now().After(e.ExpiresAt)
+	return !e.ExpiresAt.IsZero() && time.Now().After(e.ExpiresAt)
+}
+
+func (e *TTLEntry) expiredAt(now instant) bool {
+	return !e.ExpiresAt.IsZero() && now.mono > e.deadline
 }
 
+// remaining returns the time left before expiry, or NoExpiration.
+func (e *TTLEntry) remaining(now instant) time.Duration {
+	if e.ExpiresAt.IsZero() {
+		return NoExpiration
+	}
+	return e.deadline - now.mono
+}
+
+// expireAfter sets the entry to expire ttl after now.
+func (e *TTLEntry) expireAfter(now instant, ttl time.Duration) {
+	e.ExpiresAt = now.wall.Add(ttl)
+	e.deadline = now.mono + ttl
+}
+
+// extend pushes back the expiry of an entry that has one.
+func (e *TTLEntry) extend(d time.Duration) {
+	if !e.ExpiresAt.IsZero() {
+		e.ExpiresAt = e.ExpiresAt.Add(d)
+		e.deadline += d
+	}
+}
+
+// ExpirationStrategy selects where a TTLCache removes expired entries.
+type ExpirationStrategy int
+
+const (
+	// ExpireLazyAndEager checks expiry on Get and also runs the cleanup
+	// goroutine.
+	ExpireLazyAndEager ExpirationStrategy = iota
+	// ExpireLazy only checks expiry on Get; no cleanup goroutine is started.
+	ExpireLazy
+	// ExpireEager only removes entries in the cleanup goroutine. Get returns
+	// an expired entry until the next cleanup pass removes it.
+	ExpireEager
+)
+
 type TTLCache struct {
-	cache        LittleCache
-	ttlEntries   map[string]*TTLEntry
-	defaultTTL   time.Duration
+	cache       LittleCache
+	ttlEntries  map[string]*TTLEntry
+	expiries    expiryHeap // the ttlEntries that expire, soonest first
+	defaultTTL  time.Duration
+	strategy    ExpirationStrategy
+	renewAfter  int
+	slideBelow  time.Duration // SlidingThreshold
+	eagerDelete bool
+	clock       Clock
+	onEvict     func(key string, value interface{}, reason EvictionReason)
+	callbacks   *callbackGuard
+	history     *historyRing // the underlying cache's, if it keeps one
+	// innerConfig is the underlying cache's Config, whose write rules set
+	// follows, or nil when the cache isn't one of ours.
+	innerConfig *Config
+	// hooked is set when the underlying cache reports its evictions and
+	// overwrites to the TTLCache. An overwrite during set comes back
+	// through overwrote and overwritten, the replaced value as stored.
+	hooked       bool
+	overwrote    bool
+	overwritten  interface{}
 	cleanupTimer *time.Timer
-	mu           sync.RWMutex
-	stopCleanup  chan bool
+	ctx          context.Context // for restarting the cleanup goroutine
+	// cleanupInterval is CleanupInterval after defaulting and clamping.
+	cleanupInterval time.Duration
+	mu              sync.RWMutex
+	stopCleanup     chan bool
+	cleanupDone     chan struct{}
 }
 
 type TTLConfig struct {
+	// UnderlyingCache stores the values. Once wrapped, it should only be
+	// written through the TTLCache: when it is a DefCache, LRUCache,
+	// LFUCache, RingCache, SecondChanceCache or WeightedRandomCache, even
+	// behind a Compressor or Cipher, its capacity evictions drop the
+	// matching TTL records under the TTLCache's lock, and a write it turns
+	// down leaves the key's TTL record as it was.
 	UnderlyingCache LittleCache
 	DefaultTTL      time.Duration
 	CleanupInterval time.Duration
+	// ExpirationStrategy defaults to ExpireLazyAndEager.
+	ExpirationStrategy ExpirationStrategy
+	// RenewAfterHits, when positive, resets an entry's TTL to DefaultTTL on
+	// every Get once it has been read that many times. Zero never renews.
+	RenewAfterHits int
+	// SlidingThreshold, when positive, makes Get reset an entry's TTL to
+	// DefaultTTL once less than this much of it remains. A fresher entry
+	// is read under the read lock alone, so hot keys don't serialize on
+	// renewals that change next to nothing. With RenewAfterHits, an entry
+	// must meet both conditions to be renewed.
+	SlidingThreshold time.Duration
+	// Clock is the time source for expiry; nil uses the system clock. If it
+	// also has a Monotonic() time.Duration method, expiry follows that
+	// reading instead of differences between Now values.
+	Clock Clock
+	// EagerDeleteOnGet makes a Get that finds an expired entry delete it on
+	// the spot, taking the write lock. By default Get just reports a miss
+	// under the read lock and leaves the entry to the cleanup goroutine,
+	// so a burst of expired reads doesn't serialize. With ExpireLazy there
+	// is no cleanup goroutine, so Get always deletes.
+	EagerDeleteOnGet bool
+	// OnEvict, if set, is called under the cache lock for every entry that
+	// expires, is deleted, cleared or overwritten, with the reason. An
+	// entry deleted or overwritten after it expired is reported as
+	// Expired. Capacity evictions happen in UnderlyingCache, so they are
+	// reported through its own Config.OnEvict.
+	OnEvict func(key string, value interface{}, reason EvictionReason)
+	// CallbackTimeout bounds how long the cache waits for OnEvict, as
+	// Config.CallbackTimeout does for the underlying cache's callbacks.
+	CallbackTimeout time.Duration
+	// OnCallbackPanic receives what OnEvict panicked with, as
+	// Config.OnCallbackPanic does for the underlying cache.
+	OnCallbackPanic func(recovered interface{})
+}
+
+// minClampedCleanupInterval is the shortest interval NewTTLCache clamps
+// CleanupInterval to, so a tiny DefaultTTL doesn't turn cleanup into a
+// busy loop. An explicitly shorter CleanupInterval is left alone.
+const minClampedCleanupInterval = time.Second
+
+// NewTTLCache wraps config.UnderlyingCache. A zero DefaultTTL or
+// CleanupInterval picks the default; negative values are rejected, as is a
+// nil UnderlyingCache. A CleanupInterval longer than DefaultTTL would let
+// expired entries pile up between passes, so it is lowered to DefaultTTL,
+// though clamping never takes it below one second.
+func NewTTLCache(config TTLConfig) (*TTLCache, error) {
+	return NewTTLCacheWithContext(context.Background(), config)
 }
 
-func NewTTLCache(config TTLConfig) *TTLCache {
+// NewTTLCacheWithContext is like NewTTLCache, but canceling ctx stops the
+// cleanup goroutine just as Stop does.
+func NewTTLCacheWithContext(ctx context.Context, config TTLConfig) (*TTLCache, error) {
+	if err := config.validate(); err != nil {
+		return nil, newError("new", err)
+	}
 	if config.DefaultTTL == 0 {
 		config.DefaultTTL = 5 * time.Minute // default 5 minutes
 	}
 	if config.CleanupInterval == 0 {
 		config.CleanupInterval = 1 * time.Minute // cleanup every minute
 	}
+	if config.CleanupInterval > config.DefaultTTL {
+		config.CleanupInterval = min(config.CleanupInterval, max(config.DefaultTTL, minClampedCleanupInterval))
+	}
 
 	ttlCache := &TTLCache{
-		cache:       config.UnderlyingCache,
-		ttlEntries:  make(map[string]*TTLEntry),
-		defaultTTL:  config.DefaultTTL,
-		stopCleanup: make(chan bool, 1),
+		cache:           config.UnderlyingCache,
+		ttlEntries:      make(map[string]*TTLEntry),
+		defaultTTL:      config.DefaultTTL,
+		strategy:        config.ExpirationStrategy,
+		renewAfter:      config.RenewAfterHits,
+		slideBelow:      config.SlidingThreshold,
+		eagerDelete:     config.EagerDeleteOnGet || config.ExpirationStrategy == ExpireLazy,
+		clock:           clockOrDefault(config.Clock),
+		onEvict:         config.OnEvict,
+		callbacks:       newCallbackGuard(config.CallbackTimeout, config.OnCallbackPanic),
+		cleanupInterval: config.CleanupInterval,
+		history:         historyOf(config.UnderlyingCache),
+		innerConfig:     configOf(config.UnderlyingCache),
+		ctx:             ctx,
+		stopCleanup:     make(chan bool, 1),
+		cleanupDone:     make(chan struct{}),
+	}
+
+	// Capacity evictions and overwrites happen inside t.cache.Set, Swap
+	// and Resize, which are only called with t.mu held, so the hook can
+	// drop the TTL record, or tell set its write took, directly. Behind a
+	// codecCache it hears values as stored.
+	observed := config.UnderlyingCache
+	if codec, ok := observed.(*codecCache); ok {
+		observed = codec.cache
+	}
+	if observer, ok := observed.(interface {
+		chainOnEvict(fn func(key string, value interface{}, reason EvictionReason))
+	}); ok {
+		observer.chainOnEvict(func(key string, value interface{}, reason EvictionReason) {
+			switch reason {
+			case CapacityEviction:
+				ttlCache.untrack(key)
+			case Replaced:
+				ttlCache.overwrote, ttlCache.overwritten = true, value
+			}
+		})
+		ttlCache.hooked = true
 	}
 
-	ttlCache.startCleanup(config.CleanupInterval)
+	if config.ExpirationStrategy != ExpireLazy {
+		ttlCache.startCleanup(ctx, ttlCache.cleanupInterval)
+	} else {
+		close(ttlCache.cleanupDone)
+	}
 
-	return ttlCache
+	return ttlCache, nil
+}
+
+func (c TTLConfig) validate() error {
+	if c.UnderlyingCache == nil {
+		return ErrNilUnderlyingCache
+	}
+	if c.DefaultTTL < 0 {
+		return ErrInvalidDefaultTTL
+	}
+	if c.CleanupInterval < 0 {
+		return ErrInvalidInterval
+	}
+	if c.CallbackTimeout < 0 {
+		return ErrInvalidCallbackTimeout
+	}
+	if c.SlidingThreshold < 0 {
+		return ErrInvalidSlidingThreshold
+	}
+	return nil
 }
 
+// NewTTLCacheFromConfig builds the underlying cache from config and wraps
+// it. The TTL policy has no eviction of its own, so it gets an LRU cache.
+// config.OnEvict hears capacity evictions from the underlying cache and
+// everything else from the TTL layer, so each removal is reported once.
 func NewTTLCacheFromConfig(config Config, defaultTTL time.Duration) (*TTLCache, error) {
+	if config.EvictionPolicy == TTL {
+		config.EvictionPolicy = LRU
+	}
+	onEvict := config.OnEvict
+	if onEvict != nil {
+		config.OnEvict = func(key string, value interface{}, reason EvictionReason) {
+			if reason == CapacityEviction {
+				onEvict(key, value, reason)
+			}
+		}
+	}
 	underlyingCache, err := NewLittleCache(config)
 	if err != nil {
 		return nil, err
@@ -59,30 +293,172 @@ func NewTTLCacheFromConfig(config Config, defaultTTL time.Duration) (*TTLCache,
 		UnderlyingCache: underlyingCache,
 		DefaultTTL:      defaultTTL,
 		CleanupInterval: 1 * time.Minute,
+		Clock:           config.Clock,
+		OnEvict:         onEvict,
+		CallbackTimeout: config.CallbackTimeout,
+		OnCallbackPanic: config.OnCallbackPanic,
 	}
 
-	return NewTTLCache(ttlConfig), nil
+	return NewTTLCache(ttlConfig)
 }
 
 func (t *TTLCache) Set(key string, value interface{}) {
 	t.SetWithTTL(key, value, t.defaultTTL)
 }
 
+// SetWithTTL stores a value that expires after ttl. A ttl <= 0 (or
+// NoExpiration) stores it without expiry; DoNotStore skips the write.
 func (t *TTLCache) SetWithTTL(key string, value interface{}, ttl time.Duration) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	expiresAt := time.Now().Add(ttl)
-	ttlEntry := &TTLEntry{
-		Value:     value,
-		ExpiresAt: expiresAt,
+	t.set(key, value, ttl)
+}
+
+// SetWithTTLFunc stores value with the TTL ttlFn derives from it, for
+// values that carry their own expiry, such as a token with an exp claim.
+// ttlFn runs before the lock is taken. Unlike a ttl <= 0 passed to
+// SetWithTTL, a derived ttl <= 0 means the value has already expired, so
+// it isn't stored, as with DoNotStore; return NoExpiration for values that
+// never expire. A nil ttlFn uses the default TTL.
+func (t *TTLCache) SetWithTTLFunc(key string, value interface{}, ttlFn func(value interface{}) time.Duration) {
+	ttl := t.defaultTTL
+	if ttlFn != nil {
+		ttl = ttlFn(value)
+		if ttl <= 0 {
+			ttl = DoNotStore
+		}
 	}
+	t.SetWithTTL(key, value, ttl)
+}
 
-	t.ttlEntries[key] = ttlEntry
+func (t *TTLCache) set(key string, value interface{}, ttl time.Duration) {
+	if ttl == DoNotStore {
+		return
+	}
+
+	now := t.now()
+	var live *TTLEntry
+	if entry, exists := t.ttlEntries[key]; exists {
+		switch {
+		case entry.expiredAt(now):
+			// An expired value is gone already, so nothing in the
+			// underlying cache, ImmutableKeys included, may hold the key
+			// against the write.
+			t.evicted(key, entry, now, Expired)
+			t.untrack(key)
+			t.cache.Delete(key)
+		case t.keeps(key, value):
+			// The underlying cache keeps the value, so the key keeps its
+			// expiry too, and nothing was replaced.
+			return
+		case t.hooked:
+			live = entry
+		default:
+			// Without the underlying cache's report, assume the write
+			// takes and read the old value while it is still there.
+			t.evicted(key, entry, now, Replaced)
+		}
+	}
+
+	t.overwrote = false
 	t.cache.Set(key, value)
+	// The underlying cache may turn the write down, through Admit or
+	// MaxValueBytes: a key it doesn't hold gets no record here, and one
+	// whose value it kept keeps its expiry.
+	if !contains(t.cache, key) {
+		t.untrack(key)
+		return
+	}
+	if live != nil {
+		if !t.overwrote {
+			return
+		}
+		t.report(key, live, t.innerConfig.userValue(t.overwritten), now, Replaced)
+		t.overwritten = nil
+	}
+	t.track(key, newTTLEntry(ttl, now))
+}
+
+// keeps reports whether the underlying cache ignores a write of value over
+// key's live entry, under ImmutableKeys or SkipEqualWrites.
+func (t *TTLCache) keeps(key string, value interface{}) bool {
+	config := t.innerConfig
+	switch {
+	case config == nil:
+		return false
+	case config.ImmutableKeys:
+		return true
+	case !config.SkipEqualWrites:
+		return false
+	}
+	existing, exists := peek(t.cache, key)
+	return exists && config.unchanged(existing, value)
+}
+
+// evicted calls OnEvict for entry, with Expired in place of reason if the
+// entry had already expired, and records expiries in the history. The
+// value is read from the underlying cache, so it must be called before
+// the key is deleted there.
+func (t *TTLCache) evicted(key string, entry *TTLEntry, now instant, reason EvictionReason) {
+	var value interface{}
+	if t.onEvict != nil {
+		value, _ = peek(t.cache, key)
+	}
+	t.report(key, entry, value, now, reason)
+}
+
+// report is evicted for a value already in hand.
+func (t *TTLCache) report(key string, entry *TTLEntry, value interface{}, now instant, reason EvictionReason) {
+	if t.onEvict == nil && t.history == nil {
+		return
+	}
+	if entry.expiredAt(now) {
+		reason = Expired
+	}
+	t.history.observe(key, reason)
+	if t.onEvict != nil {
+		t.callbacks.run(func() { t.onEvict(key, value, reason) })
+	}
+}
+
+// Expire removes key if, and only if, it has expired, reporting it to
+// OnEvict as Expired, and returns whether it removed anything. A live key
+// is left untouched. Under ExpireLazy this reclaims one known-stale entry
+// without waiting for a sweep. The check is made under the write lock, so
+// a concurrent Set that refreshed the key wins.
+func (t *TTLCache) Expire(key string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	entry, exists := t.ttlEntries[key]
+	if !exists || !entry.expiredAt(now) {
+		return false
+	}
+	t.evicted(key, entry, now, Expired)
+	t.untrack(key)
+	t.cache.Delete(key)
+	return true
+}
+
+func newTTLEntry(ttl time.Duration, now instant) *TTLEntry {
+	entry := &TTLEntry{InsertedAt: now.wall, inserted: now.mono}
+	if ttl > 0 && ttl != NoExpiration {
+		entry.expireAfter(now, ttl)
+	}
+	return entry
+}
+
+func (t *TTLCache) now() instant {
+	return now(t.clock)
 }
 
 func (t *TTLCache) Get(key string) (interface{}, bool) {
+	if t.renewAfter > 0 {
+		return t.getAndCount(key)
+	}
+
 	t.mu.RLock()
 	ttlEntry, exists := t.ttlEntries[key]
 	if !exists {
@@ -90,21 +466,157 @@ func (t *TTLCache) Get(key string) (interface{}, bool) {
 		return nil, false
 	}
 
-	if ttlEntry.IsExpired() {
+	now := t.now()
+	if t.strategy != ExpireEager && ttlEntry.expiredAt(now) {
 		t.mu.RUnlock()
-		t.Delete(key)
+		if t.eagerDelete {
+			t.Expire(key)
+		}
 		return nil, false
 	}
+	slide := t.nearExpiry(ttlEntry, now)
 	t.mu.RUnlock()
 
+	if slide {
+		t.slide(key)
+	}
+	return t.cache.Get(key)
+}
+
+// nearExpiry reports whether entry has less than SlidingThreshold left.
+// It is always false without a threshold.
+func (t *TTLCache) nearExpiry(entry *TTLEntry, now instant) bool {
+	return t.slideBelow > 0 && !entry.ExpiresAt.IsZero() && entry.remaining(now) < t.slideBelow
+}
+
+// slide resets key's TTL if it is still live and near expiry once the
+// write lock is held; another Get may have renewed it in between.
+func (t *TTLCache) slide(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	if entry, exists := t.ttlEntries[key]; exists && !entry.expiredAt(now) && t.nearExpiry(entry, now) {
+		entry.expireAfter(now, t.defaultTTL)
+		t.retimed(entry)
+	}
+}
+
+// Peek returns the value for key if it hasn't expired, without touching the
+// underlying cache's recency or frequency and without counting a hit.
+func (t *TTLCache) Peek(key string) (interface{}, bool) {
+	value, _, ok := t.PeekWithTTL(key)
+	return value, ok
+}
+
+// PeekWithTTL is Peek that also returns the remaining TTL, or NoExpiration,
+// from the same lookup.
+func (t *TTLCache) PeekWithTTL(key string) (interface{}, time.Duration, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	now := t.now()
+	entry, exists := t.ttlEntries[key]
+	if !exists || entry.expiredAt(now) {
+		return nil, 0, false
+	}
+
+	// The underlying cache may have evicted the key to make room.
+	value, ok := peek(t.cache, key)
+	if !ok {
+		return nil, 0, false
+	}
+	return value, entry.remaining(now), true
+}
+
+// getAndCount is Get for caches that track hits. Counting a hit writes to
+// the entry, so it runs under the write lock.
+func (t *TTLCache) getAndCount(key string) (interface{}, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, exists := t.ttlEntries[key]
+	if !exists {
+		return nil, false
+	}
+
+	now := t.now()
+	if t.strategy != ExpireEager && entry.expiredAt(now) {
+		t.evicted(key, entry, now, Expired)
+		t.untrack(key)
+		t.cache.Delete(key)
+		return nil, false
+	}
+
+	if entry.Hits >= t.renewAfter && !entry.ExpiresAt.IsZero() && (t.slideBelow == 0 || t.nearExpiry(entry, now)) {
+		entry.expireAfter(now, t.defaultTTL)
+		t.retimed(entry)
+	}
+	entry.Hits++
+
 	return t.cache.Get(key)
 }
 
+// LoadOrStore returns the existing value for key if it is present and not
+// expired. Otherwise it stores value with the default TTL and returns it.
+// The loaded result is true if the value was loaded, false if stored.
+func (t *TTLCache) LoadOrStore(key string, value interface{}) (interface{}, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if entry, exists := t.ttlEntries[key]; exists && !entry.expiredAt(t.now()) {
+		if existing, exists := t.cache.Get(key); exists {
+			return existing, true
+		}
+	}
+
+	t.set(key, value, t.defaultTTL)
+	return value, false
+}
+
+// SetNXWithTTL stores key for ttl only if it is absent or expired, and
+// reports whether it did. A live entry is left untouched. The check and the
+// write happen under one lock, so among concurrent callers exactly one wins.
+func (t *TTLCache) SetNXWithTTL(key string, value interface{}, ttl time.Duration) bool {
+	if ttl == DoNotStore {
+		return false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if entry, exists := t.ttlEntries[key]; exists && !entry.expiredAt(t.now()) && Has(t.cache, key) {
+		return false
+	}
+
+	t.set(key, value, ttl)
+	return true
+}
+
+// Swap stores value with the default TTL and returns the previous value if
+// it hadn't expired.
+func (t *TTLCache) Swap(key string, value interface{}) (interface{}, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var previous interface{}
+	var exists bool
+	if entry, tracked := t.ttlEntries[key]; tracked && !entry.expiredAt(t.now()) {
+		previous, exists = t.cache.Get(key)
+	}
+
+	t.set(key, value, t.defaultTTL)
+	return previous, exists
+}
+
 func (t *TTLCache) Delete(key string) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	delete(t.ttlEntries, key)
+	if entry, exists := t.ttlEntries[key]; exists {
+		t.untrack(key)
+		t.evicted(key, entry, t.now(), Deleted)
+	}
 	t.cache.Delete(key)
 }
 
@@ -112,8 +624,102 @@ func (t *TTLCache) Clear() {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	t.ttlEntries = make(map[string]*TTLEntry)
+	t.reportCleared()
+	t.untrackAll(0)
+	t.cache.Clear()
+}
+
+// reportCleared reports every entry to OnEvict as Cleared, or Expired.
+func (t *TTLCache) reportCleared() {
+	if t.onEvict == nil && t.history == nil {
+		return
+	}
+	now := t.now()
+	for key, entry := range t.ttlEntries {
+		t.evicted(key, entry, now, Cleared)
+	}
+}
+
+// ReplaceAll swaps in items, each with the default TTL, as the entire
+// contents. The swap is only atomic for readers when the underlying cache
+// has its own ReplaceAll, as DefCache, LRUCache, LFUCache and RingCache do.
+func (t *TTLCache) ReplaceAll(items map[string]interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.reportCleared()
+	t.untrackAll(len(items))
+	now := t.now()
+	for key := range items {
+		t.track(key, newTTLEntry(t.defaultTTL, now))
+	}
+
+	if replacer, ok := t.cache.(interface{ ReplaceAll(map[string]interface{}) }); ok {
+		replacer.ReplaceAll(items)
+	} else {
+		t.cache.Clear()
+		for key, value := range items {
+			t.cache.Set(key, value)
+		}
+	}
+	// Drop the records of items the underlying cache didn't take.
+	for key := range items {
+		if !contains(t.cache, key) {
+			t.untrack(key)
+		}
+	}
+}
+
+// Drain removes every entry and returns the ones that haven't expired.
+// Expired entries are discarded along with the rest.
+func (t *TTLCache) Drain() map[string]interface{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entries := make(map[string]interface{}, len(t.ttlEntries))
+	for key, entry := range t.ttlEntries {
+		if entry.expiredAt(t.now()) {
+			continue
+		}
+		if value, exists := t.cache.Get(key); exists {
+			entries[key] = value
+		}
+	}
+
+	t.untrackAll(0)
 	t.cache.Clear()
+	return entries
+}
+
+// Dump returns a consistent snapshot of every live entry with its remaining
+// TTL. If the underlying cache supports Dump, its ordering and recency ranks
+// are kept.
+func (t *TTLCache) Dump() []Entry {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var underlying []Entry
+	if dumper, ok := t.cache.(interface{ Dump() []Entry }); ok {
+		underlying = dumper.Dump()
+	} else {
+		for key := range t.ttlEntries {
+			if value, ok := peek(t.cache, key); ok {
+				underlying = append(underlying, Entry{Key: key, Value: value})
+			}
+		}
+	}
+
+	now := t.now()
+	entries := make([]Entry, 0, len(underlying))
+	for _, e := range underlying {
+		ttlEntry, exists := t.ttlEntries[e.Key]
+		if !exists || ttlEntry.expiredAt(now) {
+			continue
+		}
+		e.TTL = ttlEntry.remaining(now)
+		entries = append(entries, e)
+	}
+	return entries
 }
 
 func (t *TTLCache) Size() int {
@@ -122,7 +728,7 @@ func (t *TTLCache) Size() int {
 
 	count := 0
 	for _, entry := range t.ttlEntries {
-		if !entry.IsExpired() {
+		if !entry.expiredAt(t.now()) {
 			count++
 		}
 	}
@@ -130,9 +736,14 @@ func (t *TTLCache) Size() int {
 }
 
 func (t *TTLCache) Resize(newSize int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
 	return t.cache.Resize(newSize)
 }
 
+// GetTTL returns the time left before key expires, or NoExpiration if it
+// never does.
 func (t *TTLCache) GetTTL(key string) (time.Duration, bool) {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
@@ -142,12 +753,97 @@ func (t *TTLCache) GetTTL(key string) (time.Duration, bool) {
 		return 0, false
 	}
 
-	if entry.IsExpired() {
+	if entry.expiredAt(t.now()) {
 		return 0, false
 	}
 
-	remaining := time.Until(entry.ExpiresAt)
-	return remaining, true
+	return entry.remaining(t.now()), true
+}
+
+// Age returns how long ago key was stored, as opposed to GetTTL's time
+// left. Every Set or SetWithTTL restarts it.
+func (t *TTLCache) Age(key string) (time.Duration, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	entry, exists := t.ttlEntries[key]
+	now := t.now()
+	if !exists || entry.expiredAt(now) {
+		return 0, false
+	}
+	return now.mono - entry.inserted, true
+}
+
+// KeysByExpiry returns the live keys ordered by expiry time, soonest first.
+// Keys that never expire come last.
+func (t *TTLCache) KeysByExpiry() []string {
+	t.mu.RLock()
+	now := t.now()
+	entries := make([]Entry, 0, len(t.ttlEntries))
+	for key, entry := range t.ttlEntries {
+		if !entry.expiredAt(now) {
+			entries = append(entries, Entry{Key: key, TTL: entry.remaining(now)})
+		}
+	}
+	t.mu.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].TTL != entries[j].TTL {
+			return entries[i].TTL < entries[j].TTL
+		}
+		return entries[i].Key < entries[j].Key
+	})
+
+	keys := make([]string, len(entries))
+	for i, e := range entries {
+		keys[i] = e.Key
+	}
+	return keys
+}
+
+// NextExpiry returns the entry that will expire soonest and when, in O(1).
+// It may already be past due if cleanup hasn't removed it yet. ok is false
+// when no entry has an expiry.
+func (t *TTLCache) NextExpiry() (key string, at time.Time, ok bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if len(t.expiries) == 0 {
+		return "", time.Time{}, false
+	}
+	return t.expiries[0].key, t.expiries[0].ExpiresAt, true
+}
+
+// TTLHistogram counts live entries by remaining TTL. Each entry lands in
+// the smallest bucket that is at least its remaining TTL; entries beyond the
+// largest bucket, including those that never expire, are counted under
+// NoExpiration. Every bucket appears in the result, even when empty.
+func (t *TTLCache) TTLHistogram(buckets []time.Duration) map[time.Duration]int {
+	bounds := append([]time.Duration(nil), buckets...)
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i] < bounds[j] })
+
+	histogram := make(map[time.Duration]int, len(bounds)+1)
+	for _, bound := range bounds {
+		histogram[bound] = 0
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	now := t.now()
+	for _, entry := range t.ttlEntries {
+		if entry.expiredAt(now) {
+			continue
+		}
+		remaining := entry.remaining(now)
+		i := sort.Search(len(bounds), func(i int) bool { return bounds[i] >= remaining })
+		if i == len(bounds) {
+			histogram[NoExpiration]++
+		} else {
+			histogram[bounds[i]]++
+		}
+	}
+	return histogram
 }
 
 func (t *TTLCache) ExtendTTL(key string, additionalTime time.Duration) bool {
@@ -155,16 +851,46 @@ func (t *TTLCache) ExtendTTL(key string, additionalTime time.Duration) bool {
 	defer t.mu.Unlock()
 
 	entry, exists := t.ttlEntries[key]
-	if !exists || entry.IsExpired() {
+	if !exists || entry.expiredAt(t.now()) {
 		return false
 	}
 
-	entry.ExpiresAt = entry.ExpiresAt.Add(additionalTime)
+	entry.extend(additionalTime)
+	t.retimed(entry)
 	return true
 }
 
-func (t *TTLCache) startCleanup(interval time.Duration) {
+// ExtendMatching extends the TTL of every live key matching pattern, using
+// path.Match syntax (for example "session:*"), under a single lock. It
+// returns the number of matching live entries; entries without expiry are
+// counted but stay non-expiring. A malformed pattern matches nothing.
+func (t *TTLCache) ExtendMatching(pattern string, additionalTime time.Duration) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	extended := 0
+	for key, entry := range t.ttlEntries {
+		if matched, err := path.Match(pattern, key); err != nil || !matched {
+			continue
+		}
+		if entry.expiredAt(now) {
+			continue
+		}
+
+		entry.extend(additionalTime)
+		t.retimed(entry)
+		extended++
+	}
+	return extended
+}
+
+func (t *TTLCache) startCleanup(ctx context.Context, interval time.Duration) {
+	// Reset swaps in new channels, so the goroutine keeps its own.
+	stop, done := t.stopCleanup, t.cleanupDone
 	go func() {
+		defer close(done)
+
 		ticker := time.NewTicker(interval)
 		defer ticker.Stop()
 
@@ -172,7 +898,9 @@ func (t *TTLCache) startCleanup(interval time.Duration) {
 			select {
 			case <-ticker.C:
 				t.cleanup()
-			case <-t.stopCleanup:
+			case <-stop:
+				return
+			case <-ctx.Done():
 				return
 			}
 		}
@@ -183,18 +911,13 @@ func (t *TTLCache) cleanup() {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	now := time.Now()
-	expiredKeys := make([]string, 0)
-
-	for key, entry := range t.ttlEntries {
-		if now.After(entry.ExpiresAt) {
-			expiredKeys = append(expiredKeys, key)
-		}
-	}
-
-	for _, key := range expiredKeys {
-		delete(t.ttlEntries, key)
-		t.cache.Delete(key)
+	// The heap yields exactly the expired entries, without a full scan.
+	now := t.now()
+	for len(t.expiries) > 0 && t.expiries[0].expiredAt(now) {
+		entry := t.expiries[0]
+		t.evicted(entry.key, entry, now, Expired)
+		t.untrack(entry.key)
+		t.cache.Delete(entry.key)
 	}
 }
 
@@ -203,4 +926,17 @@ func (t *TTLCache) Stop() {
 	case t.stopCleanup <- true:
 	default:
 	}
+	if stopper, ok := t.cache.(interface{ Stop() }); ok {
+		stopper.Stop()
+	}
+}
+
+// StopAndDrain stops the cleanup goroutine like Stop and waits for it to
+// exit, then removes and returns every live entry as Drain does, so what
+// is left at shutdown can be persisted rather than lost. With cleanup
+// stopped first, no entry can expire from under the drain.
+func (t *TTLCache) StopAndDrain() map[string]interface{} {
+	t.Stop()
+	<-t.cleanupDone
+	return t.Drain()
 }
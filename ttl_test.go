@@ -1,6 +1,11 @@
 package littlecache
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
@@ -250,7 +255,10 @@ func TestTTLCache_AutomaticCleanup(t *testing.T) {
 		CleanupInterval: 50 * time.Millisecond, // Fast cleanup for testing
 	}
 
-	ttlCache := NewTTLCache(ttlConfig)
+	ttlCache, err := NewTTLCache(ttlConfig)
+	if err != nil {
+		t.Fatalf("Failed to create TTL cache: %v", err)
+	}
 	defer ttlCache.Stop()
 
 	// Set multiple values
@@ -414,3 +422,1242 @@ func TestTTLCache_MixedTTLs(t *testing.T) {
 		t.Errorf("Expected long to still exist")
 	}
 }
+
+func TestTTLCache_Drain(t *testing.T) {
+	config := Config{MaxSize: 10, EvictionPolicy: LRU}
+	ttlCache, err := NewTTLCacheFromConfig(config, 5*time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create TTL cache: %v", err)
+	}
+	defer ttlCache.Stop()
+
+	ttlCache.Set("live1", "value1")
+	ttlCache.Set("live2", "value2")
+	ttlCache.SetWithTTL("expired", "stale", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	entries := ttlCache.Drain()
+	if len(entries) != 2 || entries["live1"] != "value1" || entries["live2"] != "value2" {
+		t.Errorf("Expected only live entries to be drained, got %v", entries)
+	}
+	if ttlCache.Size() != 0 {
+		t.Errorf("Expected size 0 after drain, got %d", ttlCache.Size())
+	}
+	if _, exists := ttlCache.GetTTL("live1"); exists {
+		t.Errorf("Expected TTLs to be cleared by drain")
+	}
+}
+
+func TestTTLCache_StopAndDrain(t *testing.T) {
+	clock := newManualClock()
+	underlyingCache, err := NewLRUCache(Config{MaxSize: 10})
+	if err != nil {
+		t.Fatalf("Failed to create underlying cache: %v", err)
+	}
+	ttlCache, err := NewTTLCache(TTLConfig{
+		UnderlyingCache: underlyingCache,
+		DefaultTTL:      time.Minute,
+		CleanupInterval: time.Hour,
+		Clock:           clock,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create TTL cache: %v", err)
+	}
+
+	ttlCache.Set("session:1", "alice")
+	ttlCache.SetWithTTL("session:2", "bob", time.Hour)
+	ttlCache.SetWithTTL("session:3", "carol", time.Second)
+	clock.Advance(2 * time.Second)
+
+	entries := ttlCache.StopAndDrain()
+	want := map[string]interface{}{"session:1": "alice", "session:2": "bob"}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("Expected exactly the live entries %v, got %v", want, entries)
+	}
+	select {
+	case <-ttlCache.cleanupDone:
+	default:
+		t.Errorf("Expected the cleanup goroutine to have exited")
+	}
+	if ttlCache.Size() != 0 || underlyingCache.Size() != 0 {
+		t.Errorf("Expected the drained cache to be empty, got %d tracked, %d underlying", ttlCache.Size(), underlyingCache.Size())
+	}
+
+	// Calling it again, or after Stop, doesn't block
+	if entries := ttlCache.StopAndDrain(); len(entries) != 0 {
+		t.Errorf("Expected nothing left to drain, got %v", entries)
+	}
+}
+
+func TestTTLCache_Dump(t *testing.T) {
+	config := Config{MaxSize: 10, EvictionPolicy: LRU}
+	ttlCache, err := NewTTLCacheFromConfig(config, 5*time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create TTL cache: %v", err)
+	}
+	defer ttlCache.Stop()
+
+	ttlCache.SetWithTTL("short", "s", time.Minute)
+	ttlCache.SetWithTTL("long", "l", time.Hour)
+	ttlCache.SetWithTTL("expired", "e", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	entries := ttlCache.Dump()
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 live entries, got %d", len(entries))
+	}
+
+	// Underlying LRU order and ranks are kept; the expired entry held rank 0
+	if entries[0].Key != "long" || entries[0].Rank != 1 || entries[1].Key != "short" || entries[1].Rank != 2 {
+		t.Errorf("Expected long at rank 1 then short at rank 2, got %s at %d then %s at %d",
+			entries[0].Key, entries[0].Rank, entries[1].Key, entries[1].Rank)
+	}
+	if entries[0].TTL <= 59*time.Minute || entries[0].TTL > time.Hour {
+		t.Errorf("Expected about an hour remaining for long, got %v", entries[0].TTL)
+	}
+	if entries[1].TTL <= 59*time.Second || entries[1].TTL > time.Minute {
+		t.Errorf("Expected about a minute remaining for short, got %v", entries[1].TTL)
+	}
+}
+
+func TestTTLCache_NoExpiry(t *testing.T) {
+	config := Config{MaxSize: 10, EvictionPolicy: LRU}
+	underlyingCache, err := NewLittleCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create underlying cache: %v", err)
+	}
+
+	ttlCache, err := NewTTLCache(TTLConfig{
+		UnderlyingCache: underlyingCache,
+		DefaultTTL:      20 * time.Millisecond,
+		CleanupInterval: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create TTL cache: %v", err)
+	}
+	defer ttlCache.Stop()
+
+	ttlCache.SetWithTTL("forever", "value", 0)
+	ttlCache.SetWithTTL("negative", "value", -time.Second)
+	ttlCache.SetWithTTL("explicit", "value", NoExpiration)
+	ttlCache.Set("short", "value")
+
+	// Several cleanup cycles run while the short entry expires
+	time.Sleep(60 * time.Millisecond)
+
+	for _, key := range []string{"forever", "negative", "explicit"} {
+		if _, exists := ttlCache.Get(key); !exists {
+			t.Errorf("Expected %s to survive cleanup", key)
+		}
+		ttl, exists := ttlCache.GetTTL(key)
+		if !exists || ttl != NoExpiration {
+			t.Errorf("Expected NoExpiration for %s, got %v (exists=%v)", key, ttl, exists)
+		}
+	}
+	if _, exists := ttlCache.Get("short"); exists {
+		t.Errorf("Expected short to expire")
+	}
+
+	// Extending a non-expiring entry keeps it non-expiring
+	if !ttlCache.ExtendTTL("forever", time.Minute) {
+		t.Errorf("Expected ExtendTTL to succeed for a live entry")
+	}
+	if ttl, _ := ttlCache.GetTTL("forever"); ttl != NoExpiration {
+		t.Errorf("Expected NoExpiration after extend, got %v", ttl)
+	}
+}
+
+func TestTTLCache_DoNotStore(t *testing.T) {
+	config := Config{MaxSize: 10, EvictionPolicy: LRU}
+	ttlCache, err := NewTTLCacheFromConfig(config, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create TTL cache: %v", err)
+	}
+	defer ttlCache.Stop()
+
+	ttlCache.SetWithTTL("key", "value", DoNotStore)
+	if _, exists := ttlCache.Get("key"); exists {
+		t.Errorf("Expected DoNotStore to skip the write")
+	}
+	if ttlCache.Size() != 0 {
+		t.Errorf("Expected size 0, got %d", ttlCache.Size())
+	}
+}
+
+func TestTTLCache_ExpirationStrategy(t *testing.T) {
+	newCache := func(strategy ExpirationStrategy, cleanupInterval time.Duration) *TTLCache {
+		underlyingCache, err := NewLittleCache(Config{MaxSize: 10, EvictionPolicy: LRU})
+		if err != nil {
+			t.Fatalf("Failed to create underlying cache: %v", err)
+		}
+		ttlCache, err := NewTTLCache(TTLConfig{
+			UnderlyingCache:    underlyingCache,
+			DefaultTTL:         10 * time.Millisecond,
+			CleanupInterval:    cleanupInterval,
+			ExpirationStrategy: strategy,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create TTL cache: %v", err)
+		}
+		return ttlCache
+	}
+	tracked := func(c *TTLCache) int {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		return len(c.ttlEntries)
+	}
+
+	t.Run("lazy", func(t *testing.T) {
+		ttlCache := newCache(ExpireLazy, 5*time.Millisecond)
+		defer ttlCache.Stop()
+
+		ttlCache.Set("key", "value")
+		time.Sleep(40 * time.Millisecond)
+
+		// No cleanup goroutine: the entry lingers until Get finds it
+		if tracked(ttlCache) != 1 {
+			t.Errorf("Expected expired entry to remain until Get, tracked %d", tracked(ttlCache))
+		}
+		if _, exists := ttlCache.Get("key"); exists {
+			t.Errorf("Expected Get to report the entry expired")
+		}
+		if tracked(ttlCache) != 0 {
+			t.Errorf("Expected Get to remove the expired entry, tracked %d", tracked(ttlCache))
+		}
+	})
+
+	t.Run("eager", func(t *testing.T) {
+		ttlCache := newCache(ExpireEager, time.Hour)
+		defer ttlCache.Stop()
+
+		ttlCache.Set("key", "value")
+		time.Sleep(20 * time.Millisecond)
+
+		// Get doesn't check expiry; only cleanup removes the entry
+		if value, exists := ttlCache.Get("key"); !exists || value != "value" {
+			t.Errorf("Expected Get to return the entry before cleanup, got %v (exists=%v)", value, exists)
+		}
+		ttlCache.cleanup()
+		if _, exists := ttlCache.Get("key"); exists {
+			t.Errorf("Expected cleanup to remove the expired entry")
+		}
+	})
+
+	t.Run("lazy and eager", func(t *testing.T) {
+		ttlCache := newCache(ExpireLazyAndEager, 5*time.Millisecond)
+		defer ttlCache.Stop()
+
+		ttlCache.Set("swept", "value")
+		ttlCache.Set("read", "value")
+		time.Sleep(40 * time.Millisecond)
+
+		if tracked(ttlCache) != 0 {
+			t.Errorf("Expected cleanup to remove expired entries, tracked %d", tracked(ttlCache))
+		}
+		if _, exists := ttlCache.Get("read"); exists {
+			t.Errorf("Expected entry to be expired")
+		}
+	})
+}
+
+func TestTTLCache_KeysByExpiry(t *testing.T) {
+	config := Config{MaxSize: 10, EvictionPolicy: LRU}
+	ttlCache, err := NewTTLCacheFromConfig(config, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create TTL cache: %v", err)
+	}
+	defer ttlCache.Stop()
+
+	ttlCache.SetWithTTL("hour", 1, time.Hour)
+	ttlCache.SetWithTTL("forever", 2, NoExpiration)
+	ttlCache.SetWithTTL("second", 3, time.Second)
+	ttlCache.SetWithTTL("expired", 4, time.Millisecond)
+	ttlCache.SetWithTTL("minute", 5, time.Minute)
+	time.Sleep(5 * time.Millisecond)
+
+	keys := ttlCache.KeysByExpiry()
+	expected := []string{"second", "minute", "hour", "forever"}
+	if len(keys) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, keys)
+	}
+	for i := range expected {
+		if keys[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, keys)
+			break
+		}
+	}
+}
+
+func TestTTLCache_ExtendMatching(t *testing.T) {
+	config := Config{MaxSize: 10, EvictionPolicy: LRU}
+	ttlCache, err := NewTTLCacheFromConfig(config, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create TTL cache: %v", err)
+	}
+	defer ttlCache.Stop()
+
+	ttlCache.SetWithTTL("session:alice", 1, time.Minute)
+	ttlCache.SetWithTTL("session:bob", 2, time.Minute)
+	ttlCache.SetWithTTL("session:gone", 3, time.Millisecond)
+	ttlCache.SetWithTTL("profile:alice", 4, time.Minute)
+	time.Sleep(5 * time.Millisecond)
+
+	if n := ttlCache.ExtendMatching("session:*", time.Hour); n != 2 {
+		t.Errorf("Expected 2 entries extended, got %d", n)
+	}
+
+	for _, key := range []string{"session:alice", "session:bob"} {
+		if ttl, _ := ttlCache.GetTTL(key); ttl < time.Hour {
+			t.Errorf("Expected %s to be extended past an hour, got %v", key, ttl)
+		}
+	}
+	if ttl, _ := ttlCache.GetTTL("profile:alice"); ttl > time.Minute {
+		t.Errorf("Expected non-matching key to keep its TTL, got %v", ttl)
+	}
+	if _, exists := ttlCache.Get("session:gone"); exists {
+		t.Errorf("Expected expired key to stay expired")
+	}
+
+	if n := ttlCache.ExtendMatching("[", time.Hour); n != 0 {
+		t.Errorf("Expected a malformed pattern to match nothing, got %d", n)
+	}
+}
+
+func TestTTLCache_RenewAfterHits(t *testing.T) {
+	config := Config{MaxSize: 10, EvictionPolicy: LRU}
+	underlyingCache, err := NewLittleCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create underlying cache: %v", err)
+	}
+
+	ttlCache, err := NewTTLCache(TTLConfig{
+		UnderlyingCache: underlyingCache,
+		DefaultTTL:      100 * time.Millisecond,
+		CleanupInterval: time.Hour,
+		RenewAfterHits:  3,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create TTL cache: %v", err)
+	}
+	defer ttlCache.Stop()
+
+	ttlCache.Set("oneoff", "value")
+	ttlCache.Set("popular", "value")
+	time.Sleep(60 * time.Millisecond)
+
+	// Three hits reach the threshold; only the fourth renews
+	for i := 0; i < 3; i++ {
+		ttlCache.Get("oneoff")
+	}
+	for i := 0; i < 4; i++ {
+		ttlCache.Get("popular")
+	}
+	time.Sleep(60 * time.Millisecond)
+
+	if _, exists := ttlCache.Get("oneoff"); exists {
+		t.Errorf("Expected oneoff to expire without renewal")
+	}
+	if _, exists := ttlCache.Get("popular"); !exists {
+		t.Errorf("Expected popular to be renewed after enough hits")
+	}
+}
+
+func TestTTLCache_SlidingThreshold(t *testing.T) {
+	underlyingCache, err := NewLRUCache(Config{MaxSize: 10})
+	if err != nil {
+		t.Fatalf("Failed to create underlying cache: %v", err)
+	}
+	clock := newManualClock()
+	ttlCache, err := NewTTLCache(TTLConfig{
+		UnderlyingCache:    underlyingCache,
+		DefaultTTL:         10 * time.Second,
+		SlidingThreshold:   3 * time.Second,
+		ExpirationStrategy: ExpireLazy,
+		Clock:              clock,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create TTL cache: %v", err)
+	}
+
+	ttlCache.Set("a", 1)
+	expiresAt := ttlCache.ttlEntries["a"].ExpiresAt
+
+	// getUnderReadLock runs Get while the test holds a read lock, so a Get
+	// that needs the write lock can't finish until it is released.
+	getUnderReadLock := func() (finishedUnderLock bool) {
+		ttlCache.mu.RLock()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			if value, ok := ttlCache.Get("a"); !ok || value != 1 {
+				t.Errorf("Expected 1, got %v (ok=%v)", value, ok)
+			}
+		}()
+		select {
+		case <-done:
+			finishedUnderLock = true
+		case <-time.After(50 * time.Millisecond):
+		}
+		ttlCache.mu.RUnlock()
+		<-done
+		return finishedUnderLock
+	}
+
+	// 8s left: fresh, so Get reads without renewing
+	clock.Advance(2 * time.Second)
+	if !getUnderReadLock() {
+		t.Errorf("Expected a Get on a fresh entry to need only the read lock")
+	}
+	if entry := ttlCache.ttlEntries["a"]; !entry.ExpiresAt.Equal(expiresAt) {
+		t.Errorf("Expected the expiry to stay at %v, got %v", expiresAt, entry.ExpiresAt)
+	}
+
+	// 2s left: below the threshold, so Get takes the write lock and renews
+	clock.Advance(6 * time.Second)
+	if getUnderReadLock() {
+		t.Errorf("Expected a Get near expiry to wait for the write lock")
+	}
+	if want, got := clock.Now().Add(10*time.Second), ttlCache.ttlEntries["a"].ExpiresAt; !got.Equal(want) {
+		t.Errorf("Expected the expiry to be reset to %v, got %v", want, got)
+	}
+
+	if _, err := NewTTLCache(TTLConfig{UnderlyingCache: underlyingCache, SlidingThreshold: -time.Second}); !errors.Is(err, ErrInvalidSlidingThreshold) {
+		t.Errorf("Expected ErrInvalidSlidingThreshold, got %v", err)
+	}
+}
+
+func TestTTLCache_ContextCancel(t *testing.T) {
+	config := Config{MaxSize: 10, EvictionPolicy: LRU}
+	underlyingCache, err := NewLittleCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create underlying cache: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ttlCache, err := NewTTLCacheWithContext(ctx, TTLConfig{
+		UnderlyingCache: underlyingCache,
+		DefaultTTL:      10 * time.Millisecond,
+		CleanupInterval: 5 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create TTL cache: %v", err)
+	}
+
+	cancel()
+	select {
+	case <-ttlCache.cleanupDone:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the cleanup goroutine to exit after cancel")
+	}
+
+	// Without cleanup, entries still expire lazily on Get
+	ttlCache.Set("key", "value")
+	if value, exists := ttlCache.Get("key"); !exists || value != "value" {
+		t.Errorf("Expected value, got %v (exists=%v)", value, exists)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, exists := ttlCache.Get("key"); exists {
+		t.Errorf("Expected key to expire lazily after cancel")
+	}
+
+	// Stop after cancel is harmless
+	ttlCache.Stop()
+}
+
+func TestTTLCache_PeekWithTTL(t *testing.T) {
+	config := Config{MaxSize: 3, EvictionPolicy: LRU}
+	underlyingCache, err := NewLRUCache(config)
+	if err != nil {
+		t.Fatalf("Failed to create underlying cache: %v", err)
+	}
+
+	ttlCache, err := NewTTLCache(TTLConfig{
+		UnderlyingCache: underlyingCache,
+		DefaultTTL:      time.Minute,
+		CleanupInterval: time.Hour,
+		RenewAfterHits:  1,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create TTL cache: %v", err)
+	}
+	defer ttlCache.Stop()
+
+	ttlCache.Set("a", 1)
+	ttlCache.Set("b", 2)
+	ttlCache.Set("c", 3)
+	expiresAt := ttlCache.ttlEntries["a"].ExpiresAt
+
+	for i := 0; i < 10; i++ {
+		value, ttl, ok := ttlCache.PeekWithTTL("a")
+		if !ok || value != 1 {
+			t.Fatalf("Expected 1, got %v (ok=%v)", value, ok)
+		}
+		if ttl <= 0 || ttl > time.Minute {
+			t.Errorf("Expected a TTL within a minute, got %v", ttl)
+		}
+	}
+
+	// a is still least recently used, and neither its expiry nor its hit
+	// count moved
+	if candidate, _ := underlyingCache.EvictionCandidate(); candidate != "a" {
+		t.Errorf("Expected a to remain the eviction candidate, got %s", candidate)
+	}
+	if entry := ttlCache.ttlEntries["a"]; !entry.ExpiresAt.Equal(expiresAt) || entry.Hits != 0 {
+		t.Errorf("Expected expiry and hits unchanged, got %v and %d", entry.ExpiresAt, entry.Hits)
+	}
+
+	if _, _, ok := ttlCache.PeekWithTTL("missing"); ok {
+		t.Errorf("Expected no result for a missing key")
+	}
+	ttlCache.SetWithTTL("forever", 4, NoExpiration)
+	if _, ttl, _ := ttlCache.PeekWithTTL("forever"); ttl != NoExpiration {
+		t.Errorf("Expected NoExpiration, got %v", ttl)
+	}
+}
+
+func TestNewTTLCache_InvalidConfig(t *testing.T) {
+	underlyingCache, err := NewLRUCache(Config{MaxSize: 10})
+	if err != nil {
+		t.Fatalf("Failed to create underlying cache: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		config TTLConfig
+		want   error
+	}{
+		{"nil underlying cache", TTLConfig{DefaultTTL: time.Minute}, ErrNilUnderlyingCache},
+		{"negative default TTL", TTLConfig{UnderlyingCache: underlyingCache, DefaultTTL: -time.Second}, ErrInvalidDefaultTTL},
+		{"negative cleanup interval", TTLConfig{UnderlyingCache: underlyingCache, CleanupInterval: -time.Second}, ErrInvalidInterval},
+	}
+
+	for _, tt := range tests {
+		ttlCache, err := NewTTLCache(tt.config)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+		if ttlCache != nil {
+			t.Errorf("%s: expected no cache on error", tt.name)
+		}
+	}
+
+	ttlCache, err := NewTTLCache(TTLConfig{UnderlyingCache: underlyingCache})
+	if err != nil {
+		t.Fatalf("Expected zero durations to pick defaults, got %v", err)
+	}
+	defer ttlCache.Stop()
+	if ttlCache.defaultTTL != 5*time.Minute {
+		t.Errorf("Expected default TTL of 5m, got %v", ttlCache.defaultTTL)
+	}
+}
+
+func TestTTLCache_EagerDeleteOnGet(t *testing.T) {
+	newCache := func(eager bool) *TTLCache {
+		underlyingCache, err := NewLRUCache(Config{MaxSize: 10})
+		if err != nil {
+			t.Fatalf("Failed to create underlying cache: %v", err)
+		}
+		ttlCache, err := NewTTLCache(TTLConfig{
+			UnderlyingCache:  underlyingCache,
+			DefaultTTL:       10 * time.Millisecond,
+			CleanupInterval:  50 * time.Millisecond,
+			EagerDeleteOnGet: eager,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create TTL cache: %v", err)
+		}
+		return ttlCache
+	}
+	tracked := func(c *TTLCache) int {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		return len(c.ttlEntries)
+	}
+
+	eager := newCache(true)
+	defer eager.Stop()
+	deferred := newCache(false)
+	defer deferred.Stop()
+
+	eager.Set("key", "value")
+	deferred.Set("key", "value")
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := eager.Get("key"); ok {
+		t.Errorf("Expected a miss for an expired key")
+	}
+	if tracked(eager) != 0 {
+		t.Errorf("Expected Get to delete the expired entry")
+	}
+
+	if _, ok := deferred.Get("key"); ok {
+		t.Errorf("Expected a miss for an expired key")
+	}
+	if tracked(deferred) != 1 {
+		t.Errorf("Expected Get to leave the expired entry for cleanup")
+	}
+	if deferred.Size() != 0 {
+		t.Errorf("Expected Size to skip the expired entry, got %d", deferred.Size())
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if tracked(deferred) != 0 {
+		t.Errorf("Expected cleanup to reap the expired entry")
+	}
+}
+
+func BenchmarkTTLCache_ExpiredGet(b *testing.B) {
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = "key" + strconv.Itoa(i)
+	}
+	const readers = 8
+
+	for _, eager := range []bool{true, false} {
+		name := "deferred"
+		if eager {
+			name = "eager"
+		}
+		b.Run(name, func(b *testing.B) {
+			underlyingCache, _ := NewLRUCache(Config{MaxSize: len(keys)})
+			ttlCache, _ := NewTTLCache(TTLConfig{
+				UnderlyingCache:  underlyingCache,
+				DefaultTTL:       time.Nanosecond,
+				CleanupInterval:  time.Hour,
+				EagerDeleteOnGet: eager,
+			})
+			defer ttlCache.Stop()
+
+			// Each iteration is a burst of readers hitting freshly expired keys.
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				for _, key := range keys {
+					ttlCache.Set(key, key)
+				}
+				time.Sleep(time.Microsecond)
+				b.StartTimer()
+
+				var wg sync.WaitGroup
+				for r := 0; r < readers; r++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						for _, key := range keys {
+							ttlCache.Get(key)
+						}
+					}()
+				}
+				wg.Wait()
+			}
+		})
+	}
+}
+
+func TestTTLCache_TTLHistogram(t *testing.T) {
+	config := Config{MaxSize: 10, EvictionPolicy: LRU}
+	ttlCache, err := NewTTLCacheFromConfig(config, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create TTL cache: %v", err)
+	}
+	defer ttlCache.Stop()
+
+	ttlCache.SetWithTTL("a", 1, 30*time.Second)
+	ttlCache.SetWithTTL("b", 2, 45*time.Second)
+	ttlCache.SetWithTTL("c", 3, 5*time.Minute)
+	ttlCache.SetWithTTL("d", 4, 2*time.Hour)
+	ttlCache.SetWithTTL("forever", 5, NoExpiration)
+	ttlCache.SetWithTTL("expired", 6, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	histogram := ttlCache.TTLHistogram([]time.Duration{time.Hour, time.Minute, 10 * time.Minute})
+
+	expected := map[time.Duration]int{
+		time.Minute:      2,
+		10 * time.Minute: 1,
+		time.Hour:        0,
+		NoExpiration:     2,
+	}
+	if len(histogram) != len(expected) {
+		t.Errorf("Expected %v, got %v", expected, histogram)
+	}
+	for bucket, count := range expected {
+		if histogram[bucket] != count {
+			t.Errorf("Expected %d entries in bucket %v, got %d", count, bucket, histogram[bucket])
+		}
+	}
+}
+
+func TestTTLCache_WallClockJump(t *testing.T) {
+	clock := newManualClock()
+	underlyingCache, err := NewLRUCache(Config{MaxSize: 10})
+	if err != nil {
+		t.Fatalf("Failed to create underlying cache: %v", err)
+	}
+	ttlCache, err := NewTTLCache(TTLConfig{
+		UnderlyingCache:    underlyingCache,
+		DefaultTTL:         time.Minute,
+		ExpirationStrategy: ExpireLazy,
+		Clock:              clock,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create TTL cache: %v", err)
+	}
+
+	ttlCache.Set("backward", 1)
+	ttlCache.Set("forward", 2)
+
+	// A backward jump must not keep entries alive past their TTL
+	clock.JumpWall(-time.Hour)
+	clock.Advance(30 * time.Second)
+	if _, ok := ttlCache.Get("backward"); !ok {
+		t.Errorf("Expected backward to be live after 30s")
+	}
+	clock.Advance(31 * time.Second)
+	if _, ok := ttlCache.Get("backward"); ok {
+		t.Errorf("Expected backward to expire after 61s despite the wall clock jump")
+	}
+
+	// A forward jump must not expire fresh entries early
+	ttlCache.Set("forward", 2)
+	clock.JumpWall(24 * time.Hour)
+	if ttl, ok := ttlCache.GetTTL("forward"); !ok || ttl != time.Minute {
+		t.Errorf("Expected a full minute left after a forward jump, got %v (ok=%v)", ttl, ok)
+	}
+	clock.Advance(59 * time.Second)
+	if _, ok := ttlCache.Get("forward"); !ok {
+		t.Errorf("Expected forward to be live after 59s")
+	}
+}
+
+func TestTTLCache_SetNXWithTTL(t *testing.T) {
+	clock := newManualClock()
+	underlyingCache, err := NewLRUCache(Config{MaxSize: 10})
+	if err != nil {
+		t.Fatalf("Failed to create underlying cache: %v", err)
+	}
+	ttlCache, err := NewTTLCache(TTLConfig{
+		UnderlyingCache:    underlyingCache,
+		DefaultTTL:         time.Minute,
+		ExpirationStrategy: ExpireLazy,
+		Clock:              clock,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create TTL cache: %v", err)
+	}
+
+	race := func() int {
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		winners := []int{}
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				if ttlCache.SetNXWithTTL("lock", i, 10*time.Second) {
+					mu.Lock()
+					winners = append(winners, i)
+					mu.Unlock()
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		if len(winners) != 1 {
+			t.Fatalf("Expected exactly one winner, got %v", winners)
+		}
+		return winners[0]
+	}
+
+	first := race()
+	if value, ok := ttlCache.Get("lock"); !ok || value != first {
+		t.Errorf("Expected the winner's value %d, got %v (ok=%v)", first, value, ok)
+	}
+
+	// A live entry is left alone, including its expiry
+	clock.Advance(5 * time.Second)
+	if ttlCache.SetNXWithTTL("lock", -1, time.Hour) {
+		t.Errorf("Expected SetNXWithTTL to fail while the key is held")
+	}
+	if ttl, _ := ttlCache.GetTTL("lock"); ttl != 5*time.Second {
+		t.Errorf("Expected the existing TTL to be kept, got %v", ttl)
+	}
+
+	// Once it expires, a new winner can acquire it
+	clock.Advance(6 * time.Second)
+	second := race()
+	if value, ok := ttlCache.Get("lock"); !ok || value != second {
+		t.Errorf("Expected the new winner's value %d, got %v (ok=%v)", second, value, ok)
+	}
+
+	if ttlCache.SetNXWithTTL("skipped", 1, DoNotStore) {
+		t.Errorf("Expected DoNotStore to report false")
+	}
+}
+
+func TestTTLCache_Age(t *testing.T) {
+	clock := newManualClock()
+	underlyingCache, err := NewLRUCache(Config{MaxSize: 10})
+	if err != nil {
+		t.Fatalf("Failed to create underlying cache: %v", err)
+	}
+	ttlCache, err := NewTTLCache(TTLConfig{
+		UnderlyingCache:    underlyingCache,
+		DefaultTTL:         time.Minute,
+		ExpirationStrategy: ExpireLazy,
+		Clock:              clock,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create TTL cache: %v", err)
+	}
+
+	if _, ok := ttlCache.Age("missing"); ok {
+		t.Errorf("Expected no age for a missing key")
+	}
+
+	ttlCache.Set("key", 1)
+	if age, ok := ttlCache.Age("key"); !ok || age != 0 {
+		t.Errorf("Expected age 0, got %v (ok=%v)", age, ok)
+	}
+
+	clock.Advance(20 * time.Second)
+	if age, _ := ttlCache.Age("key"); age != 20*time.Second {
+		t.Errorf("Expected age 20s, got %v", age)
+	}
+
+	// Extending the TTL doesn't make the value any fresher
+	ttlCache.ExtendTTL("key", time.Hour)
+	clock.Advance(10 * time.Second)
+	if age, _ := ttlCache.Age("key"); age != 30*time.Second {
+		t.Errorf("Expected age 30s after ExtendTTL, got %v", age)
+	}
+	if ttl, _ := ttlCache.GetTTL("key"); ttl != time.Hour+30*time.Second {
+		t.Errorf("Expected TTL and age to differ, got TTL %v", ttl)
+	}
+
+	// Storing again resets InsertedAt
+	ttlCache.SetWithTTL("key", 2, time.Minute)
+	if age, _ := ttlCache.Age("key"); age != 0 {
+		t.Errorf("Expected SetWithTTL to reset the age, got %v", age)
+	}
+	if entry := ttlCache.ttlEntries["key"]; !entry.InsertedAt.Equal(clock.Now()) {
+		t.Errorf("Expected InsertedAt %v, got %v", clock.Now(), entry.InsertedAt)
+	}
+
+	clock.Advance(2 * time.Minute)
+	if _, ok := ttlCache.Age("key"); ok {
+		t.Errorf("Expected no age for an expired key")
+	}
+}
+
+func TestTTLCache_OnEvict(t *testing.T) {
+	clock := newManualClock()
+	var log evictionLog
+	underlyingCache, err := NewLRUCache(Config{MaxSize: 10})
+	if err != nil {
+		t.Fatalf("Failed to create underlying cache: %v", err)
+	}
+	ttlCache, err := NewTTLCache(TTLConfig{
+		UnderlyingCache:    underlyingCache,
+		DefaultTTL:         time.Minute,
+		ExpirationStrategy: ExpireLazy,
+		Clock:              clock,
+		OnEvict:            log.record,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create TTL cache: %v", err)
+	}
+
+	ttlCache.Set("a", 1)
+	ttlCache.Set("a", 2)
+	ttlCache.SetWithTTL("b", 3, time.Hour)
+	ttlCache.Set("c", 4)
+	clock.Advance(2 * time.Minute)
+
+	ttlCache.Get("a")
+	ttlCache.Set("c", 5) // overwrites an expired entry
+	ttlCache.Delete("b")
+	ttlCache.Clear()
+
+	want := []string{"a=1:replaced", "a=2:expired", "c=4:expired", "b=3:deleted", "c=5:cleared"}
+	if fmt.Sprint(log) != fmt.Sprint(want) {
+		t.Errorf("Expected %v, got %v", want, log)
+	}
+}
+
+func TestTTLCache_OnEvictFromConfig(t *testing.T) {
+	var log evictionLog
+	ttlCache, err := NewTTLCacheFromConfig(Config{MaxSize: 1, EvictionPolicy: LRU, OnEvict: log.record}, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create TTL cache: %v", err)
+	}
+	defer ttlCache.Stop()
+
+	// The capacity eviction comes from the underlying cache and the delete
+	// from the TTL layer, each reported exactly once
+	ttlCache.Set("a", 1)
+	ttlCache.Set("b", 2)
+	ttlCache.Delete("b")
+
+	want := []string{"a=1:capacity", "b=2:deleted"}
+	if fmt.Sprint(log) != fmt.Sprint(want) {
+		t.Errorf("Expected %v, got %v", want, log)
+	}
+}
+
+func TestTTLCache_CapacityEvictionDropsTTL(t *testing.T) {
+	lru, _ := NewLRUCache(Config{MaxSize: 3})
+	lfu, _ := NewLFUCache(Config{MaxSize: 3})
+	caches := map[string]LittleCache{"lru": lru, "lfu": lfu}
+
+	for name, underlyingCache := range caches {
+		ttlCache, err := NewTTLCache(TTLConfig{
+			UnderlyingCache:    underlyingCache,
+			DefaultTTL:         time.Hour,
+			ExpirationStrategy: ExpireLazy,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create TTL cache: %v", err)
+		}
+
+		for i := 0; i < 10; i++ {
+			ttlCache.Set("key"+strconv.Itoa(i), i)
+		}
+
+		if len(ttlCache.ttlEntries) != 3 {
+			t.Errorf("%s: expected 3 TTL records, got %d", name, len(ttlCache.ttlEntries))
+		}
+		for i := 0; i < 7; i++ {
+			key := "key" + strconv.Itoa(i)
+			if _, ok := ttlCache.GetTTL(key); ok {
+				t.Errorf("%s: expected no TTL for evicted %s", name, key)
+			}
+		}
+		if _, ok := ttlCache.GetTTL("key9"); !ok {
+			t.Errorf("%s: expected a TTL for key9", name)
+		}
+
+		if err := ttlCache.Resize(1); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(ttlCache.ttlEntries) != 1 {
+			t.Errorf("%s: expected 1 TTL record after shrinking, got %d", name, len(ttlCache.ttlEntries))
+		}
+	}
+}
+
+func TestTTLCache_CleanupIntervalClamp(t *testing.T) {
+	tests := []struct {
+		name            string
+		defaultTTL      time.Duration
+		cleanupInterval time.Duration
+		want            time.Duration
+	}{
+		{"longer than TTL", 10 * time.Second, time.Hour, 10 * time.Second},
+		{"default longer than TTL", 5 * time.Second, 0, 5 * time.Second},
+		{"tiny TTL", time.Millisecond, time.Minute, time.Second},
+		{"shorter than TTL", time.Hour, time.Minute, time.Minute},
+		{"explicitly tiny", time.Hour, time.Millisecond, time.Millisecond},
+		{"between TTL and floor", time.Millisecond, 50 * time.Millisecond, 50 * time.Millisecond},
+		{"defaults", 0, 0, time.Minute},
+	}
+
+	for _, tt := range tests {
+		lru, _ := NewLRUCache(Config{MaxSize: 10})
+		ttlCache, err := NewTTLCache(TTLConfig{
+			UnderlyingCache: lru,
+			DefaultTTL:      tt.defaultTTL,
+			CleanupInterval: tt.cleanupInterval,
+		})
+		if err != nil {
+			t.Fatalf("%s: failed to create TTL cache: %v", tt.name, err)
+		}
+		ttlCache.Stop()
+
+		if ttlCache.cleanupInterval != tt.want {
+			t.Errorf("%s: expected cleanup interval %v, got %v", tt.name, tt.want, ttlCache.cleanupInterval)
+		}
+	}
+}
+
+func TestTTLCache_SetWithTTLFunc(t *testing.T) {
+	type token struct {
+		subject   string
+		expiresAt time.Time
+	}
+
+	underlyingCache, err := NewLRUCache(Config{MaxSize: 10})
+	if err != nil {
+		t.Fatalf("Failed to create underlying cache: %v", err)
+	}
+	clock := newManualClock()
+	ttlCache, err := NewTTLCache(TTLConfig{
+		UnderlyingCache:    underlyingCache,
+		DefaultTTL:         time.Hour,
+		ExpirationStrategy: ExpireLazy,
+		Clock:              clock,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create TTL cache: %v", err)
+	}
+
+	untilExpiry := func(value interface{}) time.Duration {
+		return value.(token).expiresAt.Sub(clock.Now())
+	}
+
+	ttlCache.SetWithTTLFunc("short", token{"alice", clock.Now().Add(time.Minute)}, untilExpiry)
+	ttlCache.SetWithTTLFunc("long", token{"bob", clock.Now().Add(10 * time.Minute)}, untilExpiry)
+	ttlCache.SetWithTTLFunc("stale", token{"carol", clock.Now().Add(-time.Minute)}, untilExpiry)
+	ttlCache.SetWithTTLFunc("expiring", token{"erin", clock.Now()}, untilExpiry)
+	ttlCache.SetWithTTLFunc("default", token{"dave", clock.Now()}, nil)
+
+	// A derived ttl <= 0 means already expired, not "never expires"
+	for _, key := range []string{"stale", "expiring"} {
+		if _, ok := ttlCache.Get(key); ok {
+			t.Errorf("Expected the already expired token %s not to be stored", key)
+		}
+	}
+	if ttl, ok := ttlCache.GetTTL("default"); !ok || ttl != time.Hour {
+		t.Errorf("Expected a nil ttlFn to use the default TTL, got %v (ok=%v)", ttl, ok)
+	}
+
+	clock.Advance(2 * time.Minute)
+	if _, ok := ttlCache.Get("short"); ok {
+		t.Errorf("Expected short to expire with its token")
+	}
+	if value, ok := ttlCache.Get("long"); !ok || value.(token).subject != "bob" {
+		t.Errorf("Expected long to outlive short, got %v (ok=%v)", value, ok)
+	}
+
+	clock.Advance(10 * time.Minute)
+	if _, ok := ttlCache.Get("long"); ok {
+		t.Errorf("Expected long to expire with its token")
+	}
+}
+
+func TestTTLCache_NextExpiry(t *testing.T) {
+	clock := newManualClock()
+	underlyingCache, err := NewLRUCache(Config{MaxSize: 10})
+	if err != nil {
+		t.Fatalf("Failed to create underlying cache: %v", err)
+	}
+	ttlCache, err := NewTTLCache(TTLConfig{
+		UnderlyingCache:    underlyingCache,
+		DefaultTTL:         NoExpiration,
+		ExpirationStrategy: ExpireLazy,
+		Clock:              clock,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create TTL cache: %v", err)
+	}
+
+	start := clock.Now()
+	expect := func(step, wantKey string, wantTTL time.Duration) {
+		t.Helper()
+		key, at, ok := ttlCache.NextExpiry()
+		if wantKey == "" {
+			if ok {
+				t.Errorf("%s: Expected no next expiry, got %s at %v", step, key, at)
+			}
+			return
+		}
+		if !ok || key != wantKey || !at.Equal(start.Add(wantTTL)) {
+			t.Errorf("%s: Expected %s at +%v, got %s at %v (ok=%v)", step, wantKey, wantTTL, key, at.Sub(start), ok)
+		}
+	}
+
+	expect("empty", "", 0)
+	ttlCache.Set("forever", 0)
+	expect("only non-expiring", "", 0)
+
+	ttlCache.SetWithTTL("c", 3, 30*time.Minute)
+	ttlCache.SetWithTTL("a", 1, 10*time.Minute)
+	ttlCache.SetWithTTL("d", 4, 40*time.Minute)
+	ttlCache.SetWithTTL("b", 2, 20*time.Minute)
+	expect("after sets", "a", 10*time.Minute)
+
+	ttlCache.SetWithTTL("e", 5, 5*time.Minute)
+	expect("shorter set", "e", 5*time.Minute)
+
+	ttlCache.Delete("e")
+	expect("delete root", "a", 10*time.Minute)
+
+	ttlCache.ExtendTTL("a", time.Hour)
+	expect("extend root", "b", 20*time.Minute)
+
+	ttlCache.SetWithTTL("b", 2, 50*time.Minute)
+	expect("overwrite root", "c", 30*time.Minute)
+
+	ttlCache.Set("c", 3)
+	expect("overwrite without expiry", "d", 40*time.Minute)
+
+	clock.Advance(45 * time.Minute)
+	expect("past due", "d", 40*time.Minute)
+	ttlCache.cleanup()
+	expect("after cleanup", "b", 50*time.Minute)
+	if _, ok := ttlCache.Get("d"); ok {
+		t.Errorf("Expected cleanup to remove d")
+	}
+
+	clock.Advance(time.Hour)
+	ttlCache.cleanup()
+	expect("all expired", "", 0)
+	if _, ok := ttlCache.Get("forever"); !ok {
+		t.Errorf("Expected the non-expiring entry to survive cleanup")
+	}
+}
+
+func TestTTLCache_Expire(t *testing.T) {
+	clock := newManualClock()
+	var log evictionLog
+	underlyingCache, err := NewLRUCache(Config{MaxSize: 10})
+	if err != nil {
+		t.Fatalf("Failed to create underlying cache: %v", err)
+	}
+	ttlCache, err := NewTTLCache(TTLConfig{
+		UnderlyingCache:    underlyingCache,
+		DefaultTTL:         time.Minute,
+		ExpirationStrategy: ExpireLazy,
+		Clock:              clock,
+		OnEvict:            log.record,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create TTL cache: %v", err)
+	}
+
+	ttlCache.SetWithTTL("stale", 1, time.Second)
+	ttlCache.Set("live", 2)
+	clock.Advance(2 * time.Second)
+
+	// Lazy expiry leaves the stale entry in place until something removes it
+	if _, ok := underlyingCache.Peek("stale"); !ok {
+		t.Fatalf("Expected stale to linger under ExpireLazy")
+	}
+	if !ttlCache.Expire("stale") {
+		t.Errorf("Expected Expire to remove an expired key")
+	}
+	if _, ok := underlyingCache.Peek("stale"); ok {
+		t.Errorf("Expected stale to be gone from the underlying cache")
+	}
+	if ttlCache.Expire("stale") {
+		t.Errorf("Expected a second Expire to find nothing")
+	}
+
+	if ttlCache.Expire("live") {
+		t.Errorf("Expected Expire to leave a live key alone")
+	}
+	if value, ttl, ok := ttlCache.PeekWithTTL("live"); !ok || value != 2 || ttl != 58*time.Second {
+		t.Errorf("Expected live retained with its TTL untouched, got %v, %v (ok=%v)", value, ttl, ok)
+	}
+
+	want := []string{"stale=1:expired"}
+	if fmt.Sprint(log) != fmt.Sprint(want) {
+		t.Errorf("Expected %v, got %v", want, log)
+	}
+}
+
+func TestTTLCache_RejectedWritesLeaveNoRecord(t *testing.T) {
+	cache, err := NewLittleCache(Config{
+		MaxSize:        10,
+		EvictionPolicy: TTL,
+		Admit: func(key string, value interface{}, currentSize, capacity int) bool {
+			return key != "bad"
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	ttlCache := cache.(*TTLCache)
+	defer ttlCache.Stop()
+
+	ttlCache.Set("good", 1)
+	ttlCache.Set("bad", 2)
+
+	if size := ttlCache.Size(); size != 1 {
+		t.Errorf("Expected only good to count, got size %d", size)
+	}
+	if ttl, ok := ttlCache.GetTTL("bad"); ok {
+		t.Errorf("Expected no TTL for a rejected key, got %v", ttl)
+	}
+	if keys := ttlCache.KeysByExpiry(); len(keys) != 1 || keys[0] != "good" {
+		t.Errorf("Expected only good by expiry, got %v", keys)
+	}
+}
+
+func TestTTLCache_ImmutableKeysKeepTheirExpiry(t *testing.T) {
+	clock := newManualClock()
+	var log evictionLog
+	cache, err := NewLittleCache(Config{
+		MaxSize:        10,
+		EvictionPolicy: TTL,
+		ImmutableKeys:  true,
+		Clock:          clock,
+		OnEvict:        log.record,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	ttlCache := cache.(*TTLCache)
+	defer ttlCache.Stop()
+
+	ttlCache.SetWithTTL("a", 1, time.Second)
+	clock.Advance(500 * time.Millisecond)
+
+	// The rewrite is ignored, so it neither renews a nor reports it
+	ttlCache.SetWithTTL("a", 2, time.Minute)
+	if value, _ := ttlCache.Get("a"); value != 1 {
+		t.Errorf("Expected a to keep its value, got %v", value)
+	}
+	if ttl, _ := ttlCache.GetTTL("a"); ttl != 500*time.Millisecond {
+		t.Errorf("Expected a to keep its expiry, got %v", ttl)
+	}
+	if len(log) != 0 {
+		t.Errorf("Expected no callbacks for an ignored write, got %v", log)
+	}
+
+	// Once expired, the key no longer holds out against a write
+	clock.Advance(time.Second)
+	ttlCache.SetWithTTL("a", 3, time.Minute)
+	if value, _ := ttlCache.Get("a"); value != 3 {
+		t.Errorf("Expected an expired a to be rewritten, got %v", value)
+	}
+	if want := []string{"a=1:expired"}; fmt.Sprint(log) != fmt.Sprint(want) {
+		t.Errorf("Expected %v, got %v", want, log)
+	}
+}
+
+func TestTTLCache_SkipEqualWrites(t *testing.T) {
+	clock := newManualClock()
+	var log evictionLog
+	cache, err := NewLittleCache(Config{
+		MaxSize:         10,
+		EvictionPolicy:  TTL,
+		SkipEqualWrites: true,
+		Clock:           clock,
+		OnEvict:         log.record,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	ttlCache := cache.(*TTLCache)
+	defer ttlCache.Stop()
+
+	ttlCache.SetWithTTL("a", []string{"x"}, time.Minute)
+	clock.Advance(30 * time.Second)
+
+	// An equal write is a no-op: no renewal and no Replaced
+	ttlCache.SetWithTTL("a", []string{"x"}, time.Hour)
+	if ttl, _ := ttlCache.GetTTL("a"); ttl != 30*time.Second {
+		t.Errorf("Expected an equal write to leave 30s, got %v", ttl)
+	}
+	if len(log) != 0 {
+		t.Errorf("Expected no callbacks for an equal write, got %v", log)
+	}
+
+	ttlCache.SetWithTTL("a", []string{"y"}, time.Hour)
+	if ttl, _ := ttlCache.GetTTL("a"); ttl != time.Hour {
+		t.Errorf("Expected a different value to renew a, got %v", ttl)
+	}
+	if want := []string{"a=[x]:replaced"}; fmt.Sprint(log) != fmt.Sprint(want) {
+		t.Errorf("Expected %v, got %v", want, log)
+	}
+}
@@ -0,0 +1,190 @@
+package littlecache
+
+import (
+	"container/heap"
+	"time"
+)
+
+// expiryHeap orders a TTLCache's expiring entries by deadline, soonest at
+// the root. Entries without expiry are left out. Each entry tracks its own
+// position, so it can be fixed or removed in O(log n).
+type expiryHeap []*TTLEntry
+
+func (h expiryHeap) Len() int           { return len(h) }
+func (h expiryHeap) Less(i, j int) bool { return h[i].deadline < h[j].deadline }
+
+func (h expiryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].heapIndex = i
+	h[j].heapIndex = j
+}
+
+func (h *expiryHeap) Push(x any) {
+	entry := x.(*TTLEntry)
+	entry.heapIndex = len(*h)
+	*h = append(*h, entry)
+}
+
+func (h *expiryHeap) Pop() any {
+	old := *h
+	entry := old[len(old)-1]
+	old[len(old)-1] = nil
+	entry.heapIndex = -1
+	*h = old[:len(old)-1]
+	return entry
+}
+
+// track records entry for key, replacing any previous entry.
+func (t *TTLCache) track(key string, entry *TTLEntry) {
+	t.untrack(key)
+	entry.key = key
+	entry.heapIndex = -1
+	t.ttlEntries[key] = entry
+	if !entry.ExpiresAt.IsZero() {
+		heap.Push(&t.expiries, entry)
+	}
+}
+
+// untrack forgets key's entry, if it has one.
+func (t *TTLCache) untrack(key string) {
+	entry, exists := t.ttlEntries[key]
+	if !exists {
+		return
+	}
+	delete(t.ttlEntries, key)
+	if entry.heapIndex >= 0 {
+		heap.Remove(&t.expiries, entry.heapIndex)
+	}
+}
+
+// retimed restores the heap order after entry's deadline changed.
+func (t *TTLCache) retimed(entry *TTLEntry) {
+	if entry.heapIndex >= 0 {
+		heap.Fix(&t.expiries, entry.heapIndex)
+	}
+}
+
+// untrackAll forgets every entry, sizing the new map for capacity entries.
+func (t *TTLCache) untrackAll(capacity int) {
+	t.ttlEntries = make(map[string]*TTLEntry, capacity)
+	t.expiries = nil
+}
+
+// nodeExpiries keeps an LRUTTLCache's deadlines off its LRU nodes: a map
+// from each expiring node to its deadline, plus a heap of the same entries,
+// soonest at the root. Nodes without expiry have no entry. A nil
+// nodeExpiries, as a plain LRUCache has, holds nothing.
+type nodeExpiries struct {
+	byNode map[*LRUNode]*nodeExpiry
+	heap   nodeExpiryHeap
+}
+
+type nodeExpiry struct {
+	node *LRUNode
+	// deadline is the monotonic reading at which the node expires.
+	deadline  time.Duration
+	heapIndex int
+}
+
+func newNodeExpiries() *nodeExpiries {
+	return &nodeExpiries{byNode: make(map[*LRUNode]*nodeExpiry)}
+}
+
+// set gives node the deadline, replacing any it had.
+func (e *nodeExpiries) set(node *LRUNode, deadline time.Duration) {
+	if expiry, exists := e.byNode[node]; exists {
+		expiry.deadline = deadline
+		heap.Fix(&e.heap, expiry.heapIndex)
+		return
+	}
+	expiry := &nodeExpiry{node: node, deadline: deadline}
+	e.byNode[node] = expiry
+	heap.Push(&e.heap, expiry)
+}
+
+// deadline returns node's deadline, or false if it never expires.
+func (e *nodeExpiries) deadline(node *LRUNode) (time.Duration, bool) {
+	if e == nil {
+		return 0, false
+	}
+	expiry, exists := e.byNode[node]
+	if !exists {
+		return 0, false
+	}
+	return expiry.deadline, true
+}
+
+// soonest returns the node that expires first, or nil if none expires.
+func (e *nodeExpiries) soonest() *LRUNode {
+	if e == nil || len(e.heap) == 0 {
+		return nil
+	}
+	return e.heap[0].node
+}
+
+// expiredCount returns how many nodes expired before mono. Only the part
+// of the heap above the first live deadline on each path is visited.
+func (e *nodeExpiries) expiredCount(mono time.Duration) int {
+	if e == nil {
+		return 0
+	}
+
+	count := 0
+	pending := []int{0}
+	for len(pending) > 0 {
+		i := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if i >= len(e.heap) || mono <= e.heap[i].deadline {
+			continue
+		}
+		count++
+		pending = append(pending, 2*i+1, 2*i+2)
+	}
+	return count
+}
+
+// drop forgets node's deadline, if it has one.
+func (e *nodeExpiries) drop(node *LRUNode) {
+	if e == nil {
+		return
+	}
+	if expiry, exists := e.byNode[node]; exists {
+		delete(e.byNode, node)
+		heap.Remove(&e.heap, expiry.heapIndex)
+	}
+}
+
+// reset forgets every deadline.
+func (e *nodeExpiries) reset() {
+	if e == nil {
+		return
+	}
+	e.byNode = make(map[*LRUNode]*nodeExpiry)
+	e.heap = nil
+}
+
+// nodeExpiryHeap is expiryHeap for nodeExpiries.
+type nodeExpiryHeap []*nodeExpiry
+
+func (h nodeExpiryHeap) Len() int           { return len(h) }
+func (h nodeExpiryHeap) Less(i, j int) bool { return h[i].deadline < h[j].deadline }
+
+func (h nodeExpiryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].heapIndex = i
+	h[j].heapIndex = j
+}
+
+func (h *nodeExpiryHeap) Push(x any) {
+	expiry := x.(*nodeExpiry)
+	expiry.heapIndex = len(*h)
+	*h = append(*h, expiry)
+}
+
+func (h *nodeExpiryHeap) Pop() any {
+	old := *h
+	expiry := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return expiry
+}
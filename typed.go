@@ -0,0 +1,38 @@
+package littlecache
+
+// GetString returns the value for key if it is cached and is a string.
+// A miss and a value of another type both report false.
+func GetString(c LittleCache, key string) (string, bool) {
+	return getAs[string](c, key)
+}
+
+// GetInt returns the value for key if it is cached and is an int. Other
+// integer types are not converted.
+func GetInt(c LittleCache, key string) (int, bool) {
+	return getAs[int](c, key)
+}
+
+// GetBytes returns the value for key if it is cached and is a []byte. The
+// slice is shared with the cache, not copied.
+func GetBytes(c LittleCache, key string) ([]byte, bool) {
+	return getAs[[]byte](c, key)
+}
+
+// GetOrDefault returns the value for key, or def on a miss. The lookup is
+// a plain Get, so it counts as an access; def is not stored.
+func GetOrDefault(c LittleCache, key string, def interface{}) interface{} {
+	if value, ok := c.Get(key); ok {
+		return value
+	}
+	return def
+}
+
+func getAs[T any](c LittleCache, key string) (T, bool) {
+	value, ok := c.Get(key)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	typed, ok := value.(T)
+	return typed, ok
+}
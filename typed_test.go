@@ -0,0 +1,75 @@
+package littlecache
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTypedGetters(t *testing.T) {
+	cache, err := NewLRUCache(Config{MaxSize: 10})
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+
+	cache.Set("string", "value")
+	cache.Set("int", 42)
+	cache.Set("int64", int64(42))
+	cache.Set("bytes", []byte("raw"))
+
+	if value, ok := GetString(cache, "string"); !ok || value != "value" {
+		t.Errorf("Expected value, got %q (ok=%v)", value, ok)
+	}
+	if value, ok := GetInt(cache, "int"); !ok || value != 42 {
+		t.Errorf("Expected 42, got %d (ok=%v)", value, ok)
+	}
+	if value, ok := GetBytes(cache, "bytes"); !ok || !bytes.Equal(value, []byte("raw")) {
+		t.Errorf("Expected raw, got %q (ok=%v)", value, ok)
+	}
+
+	// Wrong type
+	if value, ok := GetString(cache, "int"); ok || value != "" {
+		t.Errorf("Expected a type mismatch to report false, got %q (ok=%v)", value, ok)
+	}
+	if value, ok := GetInt(cache, "int64"); ok || value != 0 {
+		t.Errorf("Expected int64 not to be converted, got %d (ok=%v)", value, ok)
+	}
+	if value, ok := GetBytes(cache, "string"); ok || value != nil {
+		t.Errorf("Expected a type mismatch to report false, got %q (ok=%v)", value, ok)
+	}
+
+	// Miss
+	if _, ok := GetString(cache, "missing"); ok {
+		t.Errorf("Expected a miss to report false")
+	}
+	if _, ok := GetInt(cache, "missing"); ok {
+		t.Errorf("Expected a miss to report false")
+	}
+	if _, ok := GetBytes(cache, "missing"); ok {
+		t.Errorf("Expected a miss to report false")
+	}
+}
+
+func TestGetOrDefault(t *testing.T) {
+	cache, err := NewLRUCache(Config{MaxSize: 10})
+	if err != nil {
+		t.Fatalf("Failed to create LRU cache: %v", err)
+	}
+	cache.Set("present", "value")
+	cache.Set("nil", nil)
+
+	if value := GetOrDefault(cache, "present", "fallback"); value != "value" {
+		t.Errorf("Expected value, got %v", value)
+	}
+	if value := GetOrDefault(cache, "nil", "fallback"); value != nil {
+		t.Errorf("Expected a stored nil to win over the default, got %v", value)
+	}
+	if value := GetOrDefault(cache, "missing", "fallback"); value != "fallback" {
+		t.Errorf("Expected fallback, got %v", value)
+	}
+	if _, ok := cache.Peek("missing"); ok {
+		t.Errorf("Expected the default not to be stored")
+	}
+	if stats := cache.Stats(); stats.Hits != 2 || stats.Misses != 1 {
+		t.Errorf("Expected 2 hits and 1 miss, got %d and %d", stats.Hits, stats.Misses)
+	}
+}
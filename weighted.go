@@ -0,0 +1,334 @@
+package littlecache
+
+import (
+	"math/bits"
+	"math/rand/v2"
+	"time"
+)
+
+// weightScale is the eviction weight of an entry used once; one used n
+// times weighs weightScale/n. Integer weights keep the tree's sums exact
+// however many updates it sees.
+const weightScale = 1 << 24
+
+// weightOf returns the eviction weight for an access count.
+func weightOf(frequency int) int64 {
+	return max(weightScale/int64(frequency), 1)
+}
+
+// weightTree is a Fenwick tree over the entries' eviction weights, indexed
+// by slot, so updating a weight and drawing a weighted slot are both
+// O(log n).
+type weightTree struct {
+	tree  []int64 // Fenwick layout, 1-based: tree[i-1] covers slot i-1 and below
+	total int64
+}
+
+func (w *weightTree) add(slot int, delta int64) {
+	w.total += delta
+	for i := slot + 1; i <= len(w.tree); i += i & -i {
+		w.tree[i-1] += delta
+	}
+}
+
+// find returns the slot whose share of the cumulative weight contains
+// target, for 0 <= target < total.
+func (w *weightTree) find(target int64) int {
+	pos := 0
+	for step := 1 << (bits.Len(uint(len(w.tree))) - 1); step > 0; step >>= 1 {
+		if next := pos + step; next <= len(w.tree) && w.tree[next-1] <= target {
+			pos = next
+			target -= w.tree[next-1]
+		}
+	}
+	return pos
+}
+
+type weightedEntry struct {
+	key       string
+	value     interface{}
+	frequency int
+	slot      int // position in slots and in the weight tree
+}
+
+// WeightedRandomCache evicts a random entry, each chosen with probability
+// inversely proportional to its access count. Cold keys usually go first,
+// as with LFU, but a hot key can still be evicted now and then, so a key
+// that was popular once can't hold its place forever the way it can under
+// strict LFU. Counts are capped at Config.MaxFrequency, and
+// Config.RandomSeed makes the choices reproducible.
+type WeightedRandomCache struct {
+	config       Config
+	initial      int // MaxSize as constructed, for Reset
+	index        map[string]*weightedEntry
+	slots        []*weightedEntry // dense, so removal swaps the last entry in
+	weights      weightTree
+	maxFrequency int
+	rng          *rand.Rand
+	mu           rwMutex
+	cacheStats
+}
+
+func NewWeightedRandomCache(config Config) (*WeightedRandomCache, error) {
+	if err := config.Validate(); err != nil {
+		return nil, config.error("new", err)
+	}
+	config.initShared()
+
+	w := &WeightedRandomCache{
+		config:       config,
+		initial:      config.MaxSize,
+		index:        make(map[string]*weightedEntry, config.initialMapSize()),
+		maxFrequency: maxFrequency(config),
+		rng:          newSeededRand(config.RandomSeed),
+		mu:           newRWMutex(config),
+	}
+	w.bind(&w.config, &w.mu, func() int { return len(w.slots) })
+	return w, nil
+}
+
+// newSeededRand returns a generator seeded with seed, or with a random
+// seed when it is zero.
+func newSeededRand(seed uint64) *rand.Rand {
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	return rand.New(rand.NewPCG(seed, seed))
+}
+
+// growWeights rebuilds the weight tree with room for capacity slots.
+func (w *WeightedRandomCache) growWeights(capacity int) {
+	tree := make([]int64, capacity)
+	for _, entry := range w.slots {
+		tree[entry.slot] = weightOf(entry.frequency)
+	}
+	for i := 1; i <= len(tree); i++ {
+		if parent := i + i&-i; parent <= len(tree) {
+			tree[parent-1] += tree[i-1]
+		}
+	}
+	w.weights.tree = tree
+}
+
+func (w *WeightedRandomCache) insert(key string, value interface{}) {
+	if len(w.slots) == len(w.weights.tree) {
+		w.growWeights(max(2*len(w.slots), 16))
+	}
+
+	entry := &weightedEntry{key: key, value: value, frequency: 1, slot: len(w.slots)}
+	w.slots = append(w.slots, entry)
+	w.index[key] = entry
+	w.weights.add(entry.slot, weightOf(1))
+	w.counters.observeSize(len(w.slots))
+}
+
+// remove drops entry, moving the last entry into its slot.
+func (w *WeightedRandomCache) remove(entry *weightedEntry) {
+	last := w.slots[len(w.slots)-1]
+	w.weights.add(entry.slot, -weightOf(entry.frequency))
+	if last != entry {
+		weight := weightOf(last.frequency)
+		w.weights.add(last.slot, -weight)
+		w.weights.add(entry.slot, weight)
+		w.slots[entry.slot] = last
+		last.slot = entry.slot
+	}
+
+	w.slots[len(w.slots)-1] = nil
+	w.slots = w.slots[:len(w.slots)-1]
+	delete(w.index, entry.key)
+}
+
+// touch counts an access, which makes entry less likely to be evicted.
+func (w *WeightedRandomCache) touch(entry *weightedEntry) {
+	if entry.frequency >= w.maxFrequency {
+		return
+	}
+	before := weightOf(entry.frequency)
+	entry.frequency++
+	w.weights.add(entry.slot, weightOf(entry.frequency)-before)
+}
+
+// evict removes one entry drawn by weight. The cache must not be empty.
+func (w *WeightedRandomCache) evict() {
+	entry := w.slots[w.weights.find(w.rng.Int64N(w.weights.total))]
+	w.remove(entry)
+	w.evictions.record(clockOrDefault(w.config.Clock).Now(), 1)
+	w.config.evicted(entry.key, entry.value, CapacityEviction)
+}
+
+func (w *WeightedRandomCache) Set(key string, value interface{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if entry, exists := w.index[key]; exists && (w.config.ImmutableKeys || w.config.unchanged(entry.value, value)) {
+		return
+	}
+	if !w.config.admits(key, value, len(w.slots)) {
+		return
+	}
+	w.set(key, value)
+}
+
+// SetWithTTL stores key like Set, ignoring ttl.
+func (w *WeightedRandomCache) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	w.Set(key, value)
+}
+
+// set stores key. Overwriting counts as an access.
+func (w *WeightedRandomCache) set(key string, value interface{}) {
+	if entry, exists := w.index[key]; exists {
+		w.config.evicted(key, entry.value, Replaced)
+		entry.value = value
+		w.touch(entry)
+		return
+	}
+
+	for len(w.slots) >= w.config.MaxSize {
+		w.evict()
+	}
+	w.insert(key, value)
+}
+
+// Get returns the value for key and counts the access. That reweights the
+// entry, so Get takes the write lock.
+func (w *WeightedRandomCache) Get(key string) (interface{}, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	entry, exists := w.index[key]
+	w.counters.record(exists)
+	if !exists {
+		return nil, false
+	}
+	w.touch(entry)
+	return entry.value, true
+}
+
+// Peek returns the value for key without counting an access.
+func (w *WeightedRandomCache) Peek(key string) (interface{}, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	entry, exists := w.index[key]
+	if !exists {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// FrequencyOf returns key's access count.
+func (w *WeightedRandomCache) FrequencyOf(key string) (int, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	entry, exists := w.index[key]
+	if !exists {
+		return 0, false
+	}
+	return entry.frequency, true
+}
+
+// LoadOrStore mirrors sync.Map.LoadOrStore; a hit counts as an access and
+// a miss stores value only if Set would admit it.
+func (w *WeightedRandomCache) LoadOrStore(key string, value interface{}) (interface{}, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	entry, exists := w.index[key]
+	w.counters.record(exists)
+	if exists {
+		w.touch(entry)
+		return entry.value, true
+	}
+
+	if w.config.admits(key, value, len(w.slots)) {
+		w.set(key, value)
+	}
+	return value, false
+}
+
+// Swap stores value and returns the previous value, if any.
+func (w *WeightedRandomCache) Swap(key string, value interface{}) (interface{}, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var previous interface{}
+	entry, exists := w.index[key]
+	if exists {
+		previous = entry.value
+	}
+
+	w.set(key, value)
+	return previous, exists
+}
+
+func (w *WeightedRandomCache) Delete(key string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if entry, exists := w.index[key]; exists {
+		w.remove(entry)
+		w.config.evicted(key, entry.value, Deleted)
+	}
+}
+
+func (w *WeightedRandomCache) Clear() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.config.OnClear != nil {
+		w.config.cleared(w.snapshot())
+	}
+	if w.config.OnEvict != nil {
+		for _, entry := range w.slots {
+			w.config.notify(entry.key, entry.value, Cleared)
+		}
+	}
+
+	w.index = make(map[string]*weightedEntry)
+	w.slots = nil
+	w.weights = weightTree{}
+}
+
+// snapshot copies every entry into a map.
+func (w *WeightedRandomCache) snapshot() map[string]interface{} {
+	entries := make(map[string]interface{}, len(w.slots))
+	for _, entry := range w.slots {
+		entries[entry.key] = entry.value
+	}
+	return entries
+}
+
+func (w *WeightedRandomCache) Size() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return len(w.slots)
+}
+
+// Resize changes the capacity, evicting by weight until the entries fit.
+func (w *WeightedRandomCache) Resize(newSize int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := checkSize(newSize); err != nil {
+		return w.config.error("resize", err)
+	}
+
+	if newSize == w.config.MaxSize {
+		return nil
+	}
+
+	w.config.MaxSize = newSize
+	for len(w.slots) > newSize {
+		w.evict()
+	}
+	return nil
+}
+
+// chainOnEvict adds fn to the OnEvict callback, for a wrapping TTLCache.
+func (w *WeightedRandomCache) chainOnEvict(fn func(key string, value interface{}, reason EvictionReason)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.config.chainOnEvict(fn)
+}
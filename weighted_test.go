@@ -0,0 +1,152 @@
+package littlecache
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWeightedRandomCache_BasicOperations(t *testing.T) {
+	cache, err := NewWeightedRandomCache(Config{MaxSize: 3, EvictionPolicy: WeightedRandom})
+	if err != nil {
+		t.Fatalf("Failed to create weighted random cache: %v", err)
+	}
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	if value, ok := cache.Get("a"); !ok || value != 1 {
+		t.Errorf("Expected 1, got %v (ok=%v)", value, ok)
+	}
+	if freq, _ := cache.FrequencyOf("a"); freq != 2 {
+		t.Errorf("Expected a read once after Set to have frequency 2, got %d", freq)
+	}
+	if previous, ok := cache.Swap("b", 20); !ok || previous != 2 {
+		t.Errorf("Expected previous value 2, got %v (ok=%v)", previous, ok)
+	}
+	if value, loaded := cache.LoadOrStore("c", 3); loaded || value != 3 {
+		t.Errorf("Expected c to be stored, got %v (loaded=%v)", value, loaded)
+	}
+
+	cache.Delete("a")
+	if _, ok := cache.Get("a"); ok {
+		t.Errorf("Expected a to be deleted")
+	}
+
+	cache.Set("d", 4)
+	cache.Set("e", 5)
+	if cache.Size() != 3 || cache.Stats().Evictions != 1 {
+		t.Errorf("Expected 3 entries and 1 eviction, got %d and %d", cache.Size(), cache.Stats().Evictions)
+	}
+
+	cache.Clear()
+	if cache.Size() != 0 {
+		t.Errorf("Expected size 0 after clear, got %d", cache.Size())
+	}
+	cache.Set("f", 6)
+	if value, ok := cache.Get("f"); !ok || value != 6 {
+		t.Errorf("Expected the cleared cache to be usable, got %v (ok=%v)", value, ok)
+	}
+}
+
+func TestWeightedRandomCache_FavorsColdVictims(t *testing.T) {
+	const trials = 1000
+	hotEvictions, coldEvictions := 0, 0
+
+	for trial := 0; trial < trials; trial++ {
+		cache, err := NewWeightedRandomCache(Config{MaxSize: 10, RandomSeed: uint64(trial + 1)})
+		if err != nil {
+			t.Fatalf("Failed to create weighted random cache: %v", err)
+		}
+		for i := 0; i < 5; i++ {
+			cache.Set("cold"+strconv.Itoa(i), i)
+			cache.Set("hot"+strconv.Itoa(i), i)
+			for j := 0; j < 100; j++ {
+				cache.Get("hot" + strconv.Itoa(i))
+			}
+		}
+
+		var victim string
+		cache.config.OnEvict = func(key string, value interface{}, reason EvictionReason) {
+			victim = key
+		}
+		cache.Set("new", 0)
+
+		switch {
+		case strings.HasPrefix(victim, "hot"):
+			hotEvictions++
+		case strings.HasPrefix(victim, "cold"):
+			coldEvictions++
+		default:
+			t.Fatalf("Expected one eviction, got victim %q", victim)
+		}
+	}
+
+	// A hot key weighs 1/101 of a cold one, so it should lose about 1% of
+	// the draws: rarely, but not never.
+	if hotEvictions == 0 {
+		t.Errorf("Expected hot keys to be evicted occasionally, got 0 of %d", trials)
+	}
+	if coldEvictions < 20*hotEvictions {
+		t.Errorf("Expected cold keys to be evicted far more often, got %d cold and %d hot", coldEvictions, hotEvictions)
+	}
+}
+
+func TestWeightedRandomCache_Seeded(t *testing.T) {
+	run := func() []string {
+		var victims []string
+		cache, err := NewWeightedRandomCache(Config{
+			MaxSize:    8,
+			RandomSeed: 42,
+			OnEvict: func(key string, value interface{}, reason EvictionReason) {
+				if reason == CapacityEviction {
+					victims = append(victims, key)
+				}
+			},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create weighted random cache: %v", err)
+		}
+		for i := 0; i < 100; i++ {
+			cache.Set("key"+strconv.Itoa(i), i)
+			cache.Get("key" + strconv.Itoa(i/2))
+		}
+		return victims
+	}
+
+	first, second := run(), run()
+	if len(first) != 92 {
+		t.Fatalf("Expected 92 evictions, got %d", len(first))
+	}
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("Expected the same seed to evict the same keys, diverged at %d: %s vs %s", i, first[i], second[i])
+		}
+	}
+}
+
+func TestWeightedRandomCache_Resize(t *testing.T) {
+	cache, err := NewLittleCache(Config{MaxSize: 10, EvictionPolicy: WeightedRandom})
+	if err != nil {
+		t.Fatalf("Failed to create weighted random cache: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		cache.Set("key"+strconv.Itoa(i), i)
+	}
+
+	if err := cache.Resize(4); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cache.Size() != 4 {
+		t.Errorf("Expected 4 entries after shrinking, got %d", cache.Size())
+	}
+
+	if err := cache.Resize(100); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		cache.Set("more"+strconv.Itoa(i), i)
+	}
+	if cache.Size() != 100 {
+		t.Errorf("Expected 100 entries after growing, got %d", cache.Size())
+	}
+}